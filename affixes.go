@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// typeReferenceRegex matches the identifiers of a TypeScript type, and the string literals and
+// comments in it so identifiers inside them are left alone
+var typeReferenceRegex = regexp.MustCompile(`'[^']*'|"[^"]*"|/\*.*?\*/|[A-Za-z_$][\w$]*`)
+
+// typeSuffixRegex matches the characters a type_suffix may have, which can't start an identifier
+// but can continue one
+var typeSuffixRegex = regexp.MustCompile(`^[\w$]*$`)
+
+// validTypeAffixes returns type_prefix and type_suffix, dropping either when it would make the
+// type names invalid identifiers
+func validTypeAffixes(prefix, suffix string, w io.Writer) (string, string) {
+	if prefix != "" && !tsIdentifierRegex.MatchString(prefix) {
+		fmt.Fprintf(w, "Warning: type_prefix %s isn't a valid identifier. No prefix will be added.\n", prefix)
+		prefix = ""
+	}
+	if !typeSuffixRegex.MatchString(suffix) {
+		fmt.Fprintf(w, "Warning: type_suffix %s has characters that aren't valid in an identifier. No suffix will be added.\n", suffix)
+		suffix = ""
+	}
+	return prefix, suffix
+}
+
+// affixTypeNames adds prefix and suffix to the name of every type and to every reference to one:
+// in fields, derived types, @TSType overrides and extended interfaces, and in the inputs, outputs
+// and query parameters of handlers
+func affixTypeNames(types []TypeInfo, handlers []HandlerInfo, prefix, suffix string) {
+	if prefix == "" && suffix == "" {
+		return
+	}
+	affixTypes(types, handlers, typeNameSet(types), prefix, suffix)
+}
+
+// affixNamespaceTypes adds prefix and suffix to the types of a bundle, including references into
+// other namespaces such as Models.User
+func affixNamespaceTypes(namespaces []NamespaceInfo, prefix, suffix string) {
+	if prefix == "" && suffix == "" {
+		return
+	}
+	names := make(map[string]bool)
+	for _, ns := range namespaces {
+		for name := range typeNameSet(ns.Types) {
+			names[name] = true
+		}
+	}
+	for _, ns := range namespaces {
+		affixTypes(ns.Types, ns.Handlers, names, prefix, suffix)
+	}
+}
+
+func typeNameSet(types []TypeInfo) map[string]bool {
+	names := make(map[string]bool)
+	for _, t := range types {
+		names[strings.Split(t.Name, " ")[0]] = true
+	}
+	return names
+}
+
+// affixTypes renames the types in names where types and handlers declare or reference them. The
+// constants of enums are renamed as well, so the members of a TypeScript enum keep their names.
+func affixTypes(types []TypeInfo, handlers []HandlerInfo, names map[string]bool, prefix, suffix string) {
+	rename := func(tsType string) string {
+		return typeReferenceRegex.ReplaceAllStringFunc(tsType, func(ref string) string {
+			if !names[ref] {
+				return ref
+			}
+			return prefix + ref + suffix
+		})
+	}
+
+	for i := range types {
+		t := &types[i]
+		name, rest, _ := strings.Cut(t.Name, " ")
+		t.Name = strings.TrimSpace(prefix + name + suffix + " " + rest)
+		for j := range t.Fields {
+			t.Fields[j].Type = rename(t.Fields[j].Type)
+		}
+		for j := range t.Extends {
+			t.Extends[j] = rename(t.Extends[j])
+		}
+		for j, constant := range t.EnumNames {
+			if strings.HasPrefix(constant, name) {
+				t.EnumNames[j] = prefix + name + suffix + strings.TrimPrefix(constant, name)
+			}
+		}
+		t.Derived = rename(t.Derived)
+		t.TSType = rename(t.TSType)
+	}
+	for i := range handlers {
+		handlers[i].InputType = rename(handlers[i].InputType)
+		handlers[i].OutputType = rename(handlers[i].OutputType)
+		for j := range handlers[i].Outputs {
+			handlers[i].Outputs[j].Type = rename(handlers[i].Outputs[j].Type)
+		}
+		for j := range handlers[i].QueryParams {
+			handlers[i].QueryParams[j].Type = rename(handlers[i].QueryParams[j].Type)
+		}
+	}
+}
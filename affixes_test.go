@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTypePrefix(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"models/user.go": `package models
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+`,
+		"api/api.go": `package api
+
+import "github.com/example/testmodule/models"
+
+type Visibility string
+
+const (
+	VisibilityPublic  Visibility = "public"
+	VisibilityPrivate Visibility = "private"
+)
+
+type Team struct {
+	Name       string        ` + "`json:\"name\"`" + `
+	Members    []models.User ` + "`json:\"members\"`" + `
+	Lead       *models.User  ` + "`json:\"lead\"`" + `
+	Visibility Visibility    ` + "`json:\"visibility\"`" + `
+}
+
+type TeamInput struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+// @Method POST
+// @Path /teams
+// @Input TeamInput
+// @Output Team
+func CreateTeamHandler() {}
+`,
+		"go2type.yaml": `auth_token: token
+type_prefix: Api
+type_suffix: Dto
+enum_style: enum
+hooks: "false"
+packages:
+  - path: api
+    output_path: api.generated.ts
+`,
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	captureOutput(t, func() {
+		if err := generate(GenerateOptions{}); err != nil {
+			t.Errorf("Failed to generate: %v", err)
+		}
+	})
+	data, err := os.ReadFile(filepath.Join(dir, "api.generated.ts"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	content := string(data)
+
+	declarations := regexp.MustCompile(`export (?:type|interface|enum) (\w+)`).FindAllStringSubmatch(content, -1)
+	if len(declarations) == 0 {
+		t.Fatalf("Expected type declarations, got:\n%s", content)
+	}
+	for _, d := range declarations {
+		if !strings.HasPrefix(d[1], "Api") || !strings.HasSuffix(d[1], "Dto") {
+			t.Errorf("Expected %s to have the prefix and suffix", d[1])
+		}
+	}
+	for _, expected := range []string{
+		"members: Array<ApiModelsUserDto>;",
+		"lead?: ApiModelsUserDto | null;",
+		"visibility: ApiVisibilityDto;",
+		"  Public = \"public\",",
+		"input: ApiTeamInputDto",
+		"createQuery<ApiTeamInputDto, ApiTeamDto>(",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected the output to contain %q, got:\n%s", expected, content)
+		}
+	}
+}
+
+func TestAffixTypeNames(t *testing.T) {
+	types := []TypeInfo{
+		{Name: "UserID", Derived: "Brand<number, 'User'>"},
+		{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "UserID"}, {Name: "note", Type: "unknown /* unresolved: other.User */"}}},
+	}
+	handlers := []HandlerInfo{{Name: "GetUser", OutputType: "User", QueryParams: []QueryParamInfo{{Name: "id", Type: "UserID"}}}}
+	affixTypeNames(types, handlers, "Api", "")
+
+	if types[0].Name != "ApiUserID" || types[0].Derived != "Brand<number, 'User'>" {
+		t.Errorf("Expected the brand's literal to be left alone, got %+v", types[0])
+	}
+	if types[1].Fields[0].Type != "ApiUserID" || types[1].Fields[1].Type != "unknown /* unresolved: other.User */" {
+		t.Errorf("Expected only references outside comments to be renamed, got %+v", types[1].Fields)
+	}
+	if handlers[0].OutputType != "ApiUser" || handlers[0].QueryParams[0].Type != "ApiUserID" {
+		t.Errorf("Expected handler types to be renamed, got %+v", handlers[0])
+	}
+
+	if prefix, suffix := validTypeAffixes("1Api", "-Dto", io.Discard); prefix != "" || suffix != "" {
+		t.Errorf("Expected invalid affixes to be dropped, got %q and %q", prefix, suffix)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// booleanPrefixes are the prefixes that already mark a field name as a boolean
+var booleanPrefixes = []string{"is", "has", "can", "should", "was", "were", "will", "did", "does", "needs", "allow"}
+
+// prefixBooleanFields renames boolean fields without a boolean prefix, such as active, to isActive
+// (or is_email_verified for snake_case names) in the generated types. JSONName keeps the name the
+// field is sent and received with, and the client renames the field on the wire.
+func prefixBooleanFields(types []TypeInfo) []TypeInfo {
+	for i := range types {
+		for j, field := range types[i].Fields {
+			if !isBooleanType(field.Type) || hasBooleanPrefix(field.Name) {
+				continue
+			}
+			if field.JSONName == "" {
+				types[i].Fields[j].JSONName = field.Name
+			}
+			types[i].Fields[j].Name = booleanFieldName(field.Name)
+		}
+	}
+	return types
+}
+
+// isBooleanType reports whether tsType is boolean, possibly with null or undefined
+func isBooleanType(tsType string) bool {
+	isBoolean := false
+	for _, part := range splitTopLevel(tsType, " | ") {
+		switch strings.TrimSpace(part) {
+		case "boolean":
+			isBoolean = true
+		case "null", "undefined":
+		default:
+			return false
+		}
+	}
+	return isBoolean
+}
+
+// hasBooleanPrefix reports whether name starts with a boolean prefix followed by a word boundary,
+// as in isActive, has_access or can-edit, but not isolated
+func hasBooleanPrefix(name string) bool {
+	for _, prefix := range booleanPrefixes {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		next := rune(rest[0])
+		if next == '_' || next == '-' || unicode.IsUpper(next) {
+			return true
+		}
+	}
+	return false
+}
+
+// booleanFieldName adds the is prefix to name, in the name's own style
+func booleanFieldName(name string) string {
+	if strings.Contains(name, "_") {
+		return "is_" + name
+	}
+	return "is" + strings.ToUpper(name[:1]) + name[1:]
+}
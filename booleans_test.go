@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrefixBooleanFields(t *testing.T) {
+	types := prefixBooleanFields([]TypeInfo{{Name: "User", Fields: []FieldInfo{
+		{Name: "active", JSONName: "active", Type: "boolean"},
+		{Name: "email_verified", JSONName: "email_verified", Type: "boolean | undefined", IsOptional: true},
+		{Name: "hasAccess", JSONName: "hasAccess", Type: "boolean"},
+		{Name: "is_admin", JSONName: "is_admin", Type: "boolean"},
+		{Name: "isolated", JSONName: "isolated", Type: "boolean | null"},
+		{Name: "status", JSONName: "status", Type: "string"},
+		{Name: "flags", JSONName: "flags", Type: "Array<boolean>"},
+	}}})
+
+	expected := []string{"isActive", "is_email_verified", "hasAccess", "is_admin", "isIsolated", "status", "flags"}
+	for i, field := range types[0].Fields {
+		if field.Name != expected[i] {
+			t.Errorf("Expected %s to be named %s, got %s", field.JSONName, expected[i], field.Name)
+		}
+	}
+
+	renamed := renamedTypes([]NamespaceInfo{{Types: types}})
+	expectedRenames := []renamedField{
+		{Wire: "active", Name: "isActive"},
+		{Wire: "email_verified", Name: "is_email_verified"},
+		{Wire: "isolated", Name: "isIsolated"},
+	}
+	if len(renamed) != 1 || !reflect.DeepEqual(renamed[0].Fields, expectedRenames) {
+		t.Errorf("Unexpected renamed boolean fields: %+v", renamed)
+	}
+}
+
+func TestBooleanPrefixClient(t *testing.T) {
+	newTypes := func() []TypeInfo {
+		return []TypeInfo{{Name: "User", Fields: []FieldInfo{
+			{Name: "id", JSONName: "id", Type: "number"},
+			{Name: "active", JSONName: "active", Type: "boolean"},
+			{Name: "permissions", JSONName: "permissions", Type: "{ [key: string]: boolean }"},
+		}}}
+	}
+	types := prefixBooleanFields(newTypes())
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+		{Name: "UpdateUser", Method: "PUT", Path: "/users/:id", InputType: "User", OutputType: "User", URLParams: []string{"id"}},
+		{Name: "GetFlags", Method: "GET", Path: "/flags", OutputType: "{ [key: string]: boolean }"},
+	}
+
+	for _, httpClient := range []string{"fetch", "axios"} {
+		content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, HTTPClient: httpClient, BooleanPrefix: true})
+		for _, str := range []string{
+			"isActive: boolean;",
+			"'User': [\n    ['active', 'isActive'],\n  ],",
+			"return renameFields(await createQuery<void, User>('GET', url, undefined, headers, onResponse), 'User');",
+			"renameFields(input, 'User', true)",
+			// Only the fields of the declared type are renamed, not the keys of a map of booleans
+			"return createQuery<void, { [key: string]: boolean }>('GET', url, undefined, headers, onResponse);",
+		} {
+			if !strings.Contains(content, str) {
+				t.Errorf("%s: expected %q in generated file:\n%s", httpClient, str, content)
+			}
+		}
+	}
+
+	// Without boolean_prefix the client sends and returns values as-is
+	content := renderTestFile(t, GenerateFileOptions{Types: newTypes(), Handlers: handlers})
+	if strings.Contains(content, "renameFields") {
+		t.Errorf("Expected no renaming without boolean_prefix")
+	}
+}
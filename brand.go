@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// defaultIDTypes are the Go types branded with brand_ids when id_types isn't set
+var defaultIDTypes = []string{"uuid.UUID", "xid.ID"}
+
+// idTypeMappings returns the TypeScript type of each ID type: its type mapping if it has one, and
+// string otherwise, since ID types such as xid.ID are serialized as text
+func idTypeMappings(idTypes []string, typeMappings map[string]string) map[string]string {
+	mappings := make(map[string]string)
+	for _, idType := range idTypes {
+		if tsType, ok := typeMappings[idType]; ok {
+			mappings[idType] = tsType
+		} else if tsType, ok := defaultTypeMappings[idType]; ok {
+			mappings[idType] = tsType
+		} else {
+			mappings[idType] = "string"
+		}
+	}
+	return mappings
+}
+
+// brandIDFields replaces the type of every field whose Go type is an ID type with a branded ID type
+// such as `UserID = Brand<string, 'User'>`, so IDs of different types can't be mixed up. The brand
+// is keyed on the type the ID belongs to: the owning type for id fields, the referenced type for
+// foreign keys like user_id, and the owning type and field name, less any id suffix, otherwise.
+// The branded ID types are returned ahead of the other types.
+func brandIDFields(types []TypeInfo, idTypes map[string]string) []TypeInfo {
+	typeNames := make(map[string]bool)
+	for _, t := range types {
+		typeNames[strings.Split(t.Name, " ")[0]] = true
+	}
+
+	brands := make(map[string]TypeInfo)
+	for i := range types {
+		owner := strings.Split(types[i].Name, " ")[0]
+		for j, field := range types[i].Fields {
+			tsType, ok := idTypes[field.PackageName]
+			if !ok {
+				continue
+			}
+			// The ID is the last occurrence of its type, after any map key
+			at := strings.LastIndex(field.Type, tsType)
+			if at < 0 {
+				continue
+			}
+			brand := idBrand(owner, field.Name, typeNames)
+			name := brand + "ID"
+			brands[name] = TypeInfo{Name: name, Derived: fmt.Sprintf("Brand<%s, '%s'>", tsType, brand)}
+			types[i].Fields[j].Type = field.Type[:at] + name + field.Type[at+len(tsType):]
+		}
+	}
+
+	var branded []TypeInfo
+	for _, t := range brands {
+		branded = append(branded, t)
+	}
+	sort.Slice(branded, func(i, j int) bool { return branded[i].Name < branded[j].Name })
+	return append(branded, types...)
+}
+
+// idBrand returns the brand of an ID field of owner
+func idBrand(owner, fieldName string, typeNames map[string]bool) string {
+	if strings.EqualFold(fieldName, "id") {
+		return owner
+	}
+	for _, suffix := range []string{"_ids", "IDs", "Ids", "_id", "ID", "Id"} {
+		if prefix := strings.TrimSuffix(fieldName, suffix); prefix != fieldName && prefix != "" {
+			if referenced := pascalCase(prefix); typeNames[referenced] {
+				return referenced
+			}
+			return owner + pascalCase(prefix)
+		}
+	}
+	return owner + pascalCase(fieldName)
+}
+
+// pascalCase converts a snake_case or camelCase name to PascalCase, e.g. billing_account -> BillingAccount
+func pascalCase(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// brandHintPrefix marks the brand of a type mapping's value, e.g. "string brand:Email" emits the
+// mapped type as Email, declared as string & { readonly __brand: 'Email' }
+const brandHintPrefix = "brand:"
+
+// splitBrandHint returns a type mapping's value without its brand hint, and the brand it names.
+// Invalid brands are dropped with a warning on w.
+func splitBrandHint(tsType string, w io.Writer) (string, string) {
+	at := strings.LastIndex(tsType, " ")
+	if at < 0 || !strings.HasPrefix(tsType[at+1:], brandHintPrefix) {
+		return tsType, ""
+	}
+	brand := strings.TrimPrefix(tsType[at+1:], brandHintPrefix)
+	if !tsIdentifierRegex.MatchString(brand) {
+		fmt.Fprintf(w, "Warning: Ignoring invalid brand %s of type mapping %s\n", brand, tsType)
+		brand = ""
+	}
+	return strings.TrimSpace(tsType[:at]), brand
+}
+
+// unbrandedMappings returns typeMappings without their brand hints, the types they're parsed with
+func unbrandedMappings(typeMappings map[string]string, w io.Writer) map[string]string {
+	if typeMappings == nil {
+		return nil
+	}
+	mappings := make(map[string]string)
+	for goType, tsType := range typeMappings {
+		mappings[goType], _ = splitBrandHint(tsType, w)
+	}
+	return mappings
+}
+
+// brandedMapping is the branded type a mapped Go type is emitted as
+type brandedMapping struct {
+	// Base is the mapped TypeScript type, which the brand is added to
+	Base  string
+	Brand string
+}
+
+// brandedMappings returns the branded type of each mapped Go type whose mapping has a brand hint,
+// and of each of idTypes, the ID types branded with branded_ids, unless its mapping names another brand
+func brandedMappings(typeMappings map[string]string, idTypes []string, w io.Writer) map[string]brandedMapping {
+	branded := make(map[string]brandedMapping)
+	for idType, base := range idTypeMappings(idTypes, unbrandedMappings(typeMappings, w)) {
+		branded[idType] = brandedMapping{Base: base, Brand: idTypeBrand(idType)}
+	}
+	for goType, tsType := range typeMappings {
+		if base, brand := splitBrandHint(tsType, w); brand != "" {
+			branded[goType] = brandedMapping{Base: base, Brand: brand}
+		}
+	}
+	return branded
+}
+
+// idTypeBrand returns the brand of an ID type with branded_ids: its name, or for a type named ID,
+// its package in upper case, e.g. UUID for uuid.UUID and XID for xid.ID
+func idTypeBrand(idType string) string {
+	at := strings.LastIndex(idType, ".")
+	if name := idType[at+1:]; name != "ID" || at < 0 {
+		return name
+	}
+	return strings.ToUpper(idType[:at])
+}
+
+// brandMappedFields replaces the mapped type of every field whose Go type is branded with its
+// brand, declared with the Brand utility type like the branded ID types of brand_ids, so a plain
+// value needs converting with the brand's function, e.g. UUID(s), to be assigned to it. The branded
+// types are returned ahead of the other types. Fields branded by brand_ids already have a branded
+// ID type and are left alone.
+func brandMappedFields(types []TypeInfo, branded map[string]brandedMapping) []TypeInfo {
+	if len(branded) == 0 {
+		return types
+	}
+	declared := make(map[string]bool)
+	for _, t := range types {
+		declared[strings.Split(t.Name, " ")[0]] = true
+	}
+
+	brands := make(map[string]TypeInfo)
+	for i := range types {
+		for j, field := range types[i].Fields {
+			mapping, ok := branded[field.PackageName]
+			if !ok {
+				continue
+			}
+			// The branded type is the last occurrence of its mapping, after any map key
+			at := strings.LastIndex(field.Type, mapping.Base)
+			if at < 0 {
+				continue
+			}
+			types[i].Fields[j].Type = field.Type[:at] + mapping.Brand + field.Type[at+len(mapping.Base):]
+			if !declared[mapping.Brand] {
+				brands[mapping.Brand] = TypeInfo{
+					Name:      mapping.Brand,
+					Derived:   fmt.Sprintf("Brand<%s, '%s'>", mapping.Base, mapping.Brand),
+					BrandBase: mapping.Base,
+				}
+			}
+		}
+	}
+
+	var brandTypes []TypeInfo
+	for _, t := range brands {
+		brandTypes = append(brandTypes, t)
+	}
+	sort.Slice(brandTypes, func(i, j int) bool { return brandTypes[i].Name < brandTypes[j].Name })
+	return append(brandTypes, types...)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBrandIDFields(t *testing.T) {
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{
+			{Name: "id", Type: "string /* uuid */", PackageName: "uuid.UUID"},
+			{Name: "name", Type: "string", PackageName: "string"},
+		}},
+		{Name: "Order", Fields: []FieldInfo{
+			{Name: "id", Type: "string", PackageName: "xid.ID"},
+			{Name: "user_id", Type: "string /* uuid */", PackageName: "uuid.UUID"},
+			{Name: "coupon_id", Type: "string /* uuid */ | null", PackageName: "uuid.UUID"},
+			{Name: "item_ids", Type: "Array<string /* uuid */>", PackageName: "uuid.UUID"},
+		}},
+	}
+
+	branded := brandIDFields(types, idTypeMappings(defaultIDTypes, nil))
+
+	derived := make(map[string]string)
+	fields := make(map[string]string)
+	for _, ty := range branded {
+		if ty.Derived != "" {
+			derived[ty.Name] = ty.Derived
+		}
+		for _, field := range ty.Fields {
+			fields[ty.Name+"."+field.Name] = field.Type
+		}
+	}
+
+	expectedDerived := map[string]string{
+		"UserID":        "Brand<string /* uuid */, 'User'>",
+		"OrderID":       "Brand<string, 'Order'>",
+		"OrderCouponID": "Brand<string /* uuid */, 'OrderCoupon'>",
+		"OrderItemID":   "Brand<string /* uuid */, 'OrderItem'>",
+	}
+	if len(derived) != len(expectedDerived) {
+		t.Errorf("Expected %d branded ID types, got %v", len(expectedDerived), derived)
+	}
+	for name, expected := range expectedDerived {
+		if derived[name] != expected {
+			t.Errorf("Expected %s = %s, got %q", name, expected, derived[name])
+		}
+	}
+
+	expectedFields := map[string]string{
+		"User.id":         "UserID",
+		"User.name":       "string",
+		"Order.id":        "OrderID",
+		"Order.user_id":   "UserID",
+		"Order.coupon_id": "OrderCouponID | null",
+		"Order.item_ids":  "Array<OrderItemID>",
+	}
+	for name, expected := range expectedFields {
+		if fields[name] != expected {
+			t.Errorf("Expected %s to be %s, got %s", name, expected, fields[name])
+		}
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: branded, BrandIDs: true})
+	for _, expected := range []string{
+		"export type Brand<T, K extends string> = T & { readonly __brand: K };",
+		"export type UserID = Brand<string /* uuid */, 'User'>;",
+		"export type OrderID = Brand<string, 'Order'>;",
+		"user_id: UserID;",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected output to contain %q", expected)
+		}
+	}
+}
+
+func TestIDTypeMappings(t *testing.T) {
+	mappings := idTypeMappings([]string{"uuid.UUID", "xid.ID", "ksuid.KSUID"}, map[string]string{"ksuid.KSUID": "string /* ksuid */"})
+	expected := map[string]string{
+		"uuid.UUID":   "string /* uuid */",
+		"xid.ID":      "string",
+		"ksuid.KSUID": "string /* ksuid */",
+	}
+	for idType, tsType := range expected {
+		if mappings[idType] != tsType {
+			t.Errorf("Expected %s to map to %s, got %s", idType, tsType, mappings[idType])
+		}
+	}
+}
+
+func TestBrandMappedFields(t *testing.T) {
+	typeMappings := map[string]string{"mail.Address": "string brand:Email"}
+	if mappings := unbrandedMappings(typeMappings, io.Discard); mappings["mail.Address"] != "string" {
+		t.Errorf("Expected the brand hint to be stripped, got %q", mappings["mail.Address"])
+	}
+
+	types := []TypeInfo{{Name: "User", Fields: []FieldInfo{
+		{Name: "id", Type: "string /* uuid */", PackageName: "uuid.UUID"},
+		{Name: "team_ids", Type: "Array<string /* uuid */>", PackageName: "uuid.UUID"},
+		{Name: "email", Type: "string | null", PackageName: "mail.Address"},
+		{Name: "name", Type: "string", PackageName: "string"},
+	}}}
+	branded := brandMappedFields(types, brandedMappings(typeMappings, defaultIDTypes, io.Discard))
+
+	if len(branded) != 3 || branded[0].Name != "Email" || branded[1].Name != "UUID" {
+		t.Fatalf("Expected the Email and UUID brands ahead of the types, got %v", branded)
+	}
+	if branded[1].Derived != "Brand<string /* uuid */, 'UUID'>" {
+		t.Errorf("Unexpected UUID brand: %s", branded[1].Derived)
+	}
+	expected := map[string]string{"id": "UUID", "team_ids": "Array<UUID>", "email": "Email | null", "name": "string"}
+	for _, field := range branded[2].Fields {
+		if field.Type != expected[field.Name] {
+			t.Errorf("Expected %s to be %s, got %s", field.Name, expected[field.Name], field.Type)
+		}
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: branded, BrandIDs: true})
+	for _, str := range []string{
+		"export type Brand<T, K extends string> = T & { readonly __brand: K };",
+		"export type UUID = Brand<string /* uuid */, 'UUID'>;",
+		"export function UUID(value: string /* uuid */): UUID {\n  return value as UUID;\n}",
+		"export function Email(value: string): Email {",
+		"id: UUID;",
+	} {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected %q in generated file:\n%s", str, content)
+		}
+	}
+
+	// ID types are branded after their name, or their package for types named ID
+	if brand := brandedMappings(nil, defaultIDTypes, io.Discard)["xid.ID"]; brand.Brand != "XID" || brand.Base != "string" {
+		t.Errorf("Expected xid.ID to be branded as XID, got %+v", brand)
+	}
+
+	// Without branded_ids, uuid.UUID keeps its mapping
+	if _, ok := brandedMappings(nil, nil, io.Discard)["uuid.UUID"]; ok {
+		t.Errorf("Expected uuid.UUID not to be branded without branded_ids")
+	}
+}
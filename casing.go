@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// validFieldCasing returns how the names of generated properties are cased: "camel", "snake", or ""
+// to keep their wire names
+func validFieldCasing(fieldCasing string, w io.Writer) string {
+	if fieldCasing == "camel" || fieldCasing == "snake" {
+		return fieldCasing
+	} else if fieldCasing != "preserve" && fieldCasing != "" {
+		fmt.Fprintf(w, "Warning: Unknown field_casing %s. Using preserve instead.\n", fieldCasing)
+	}
+	return ""
+}
+
+// caseFields renames the fields of types to fieldCasing, e.g. created_at to createdAt with camel.
+// JSONName keeps the name the field is sent and received with, and the client renames the field on
+// the wire. The field names of @TSDerive expressions, and a union discriminant naming a field, are
+// renamed to match.
+func caseFields(types []TypeInfo, fieldCasing string) []TypeInfo {
+	if fieldCasing == "" {
+		return types
+	}
+	for i := range types {
+		for j, field := range types[i].Fields {
+			if field.JSONName == "" {
+				types[i].Fields[j].JSONName = field.Name
+			} else if field.JSONName != field.Name {
+				// Fields renamed by a ts tag keep that name
+				continue
+			}
+			types[i].Fields[j].Name = caseFieldName(field.Name, fieldCasing)
+			if field.Name == types[i].UnionDiscriminant {
+				types[i].UnionDiscriminant = types[i].Fields[j].Name
+			}
+		}
+		if types[i].Derived != "" {
+			types[i].Derived = caseDerivedFields(types[i].Derived, fieldCasing)
+		}
+	}
+	return types
+}
+
+// derivedFieldRegex matches the quoted field names of a @TSDerive expression
+var derivedFieldRegex = regexp.MustCompile(`'[^']*'|"[^"]*"`)
+
+// caseDerivedFields renames the quoted field names of a Pick or Omit expression to fieldCasing
+func caseDerivedFields(expr, fieldCasing string) string {
+	return derivedFieldRegex.ReplaceAllStringFunc(expr, func(key string) string {
+		return key[:1] + caseFieldName(key[1:len(key)-1], fieldCasing) + key[len(key)-1:]
+	})
+}
+
+// caseFieldName converts name, in any casing, to camelCase or snake_case. Initialisms are treated
+// as one word, so UserID is userId or user_id.
+func caseFieldName(name, fieldCasing string) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return name
+	}
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	if fieldCasing == "snake" {
+		return strings.Join(words, "_")
+	}
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return strings.Join(words, "")
+}
+
+// splitWords splits a name at underscores, hyphens and changes of case, e.g. HTTPServer_id into
+// HTTP, Server and id
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == ' ' {
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+			word = nil
+			continue
+		}
+		// A word starts at an upper case letter after a lower case one or a digit, or at the last
+		// upper case letter of an initialism followed by a lower case one
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCaseFieldName(t *testing.T) {
+	for _, tc := range []struct {
+		name, camel, snake string
+	}{
+		{"created_at", "createdAt", "created_at"},
+		{"createdAt", "createdAt", "created_at"},
+		{"UserID", "userId", "user_id"},
+		{"HTTPServer_id", "httpServerId", "http_server_id"},
+		{"page-size", "pageSize", "page_size"},
+		{"v2Token", "v2Token", "v2_token"},
+		{"id", "id", "id"},
+	} {
+		if got := caseFieldName(tc.name, "camel"); got != tc.camel {
+			t.Errorf("Expected %s in camelCase to be %s, got %s", tc.name, tc.camel, got)
+		}
+		if got := caseFieldName(tc.name, "snake"); got != tc.snake {
+			t.Errorf("Expected %s in snake_case to be %s, got %s", tc.name, tc.snake, got)
+		}
+	}
+}
+
+func TestFieldCasing(t *testing.T) {
+	types := caseFields([]TypeInfo{
+		{Name: "User", Fields: []FieldInfo{
+			{Name: "id", JSONName: "id", Type: "number"},
+			{Name: "created_at", JSONName: "created_at", Type: "string /* date-time */"},
+			{Name: "LastSeen", Type: "string /* date-time */"},
+			{Name: "label", JSONName: "display_name", Type: "string"},
+		}},
+		{Name: "UserSummary", Derived: "Pick<User, 'id' | 'created_at'>"},
+	}, "camel")
+
+	expected := []FieldInfo{
+		{Name: "id", JSONName: "id"},
+		{Name: "createdAt", JSONName: "created_at"},
+		{Name: "lastSeen", JSONName: "LastSeen"},
+		{Name: "label", JSONName: "display_name"},
+	}
+	for i, field := range types[0].Fields {
+		if field.Name != expected[i].Name || field.JSONName != expected[i].JSONName {
+			t.Errorf("Expected field %s sent as %s, got %s sent as %s", expected[i].Name, expected[i].JSONName, field.Name, field.JSONName)
+		}
+	}
+	if types[1].Derived != "Pick<User, 'id' | 'createdAt'>" {
+		t.Errorf("Expected the derived type's fields to be cased, got %s", types[1].Derived)
+	}
+
+	// The client renames snake_case wire names to the camelCase properties and back
+	handlers := []HandlerInfo{{Name: "CreateUser", Method: "POST", Path: "/users", InputType: "User", OutputType: "User"}}
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+	for _, str := range []string{
+		"createdAt: string /* date-time */;",
+		"['created_at', 'createdAt'],",
+		"['LastSeen', 'lastSeen'],",
+		"return renameFields(await createQuery<User, User>('POST', url, renameFields(input, 'User', true), headers, onResponse), 'User');",
+	} {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected %q in generated file:\n%s", str, content)
+		}
+	}
+	if strings.Contains(content, "['id', 'id']") {
+		t.Errorf("Expected fields whose name is unchanged not to be renamed")
+	}
+}
+
+func TestFieldCasingMapKeys(t *testing.T) {
+	// Only declared fields are renamed, so the keys of maps keep the names they're sent with
+	types := caseFields([]TypeInfo{
+		{Name: "Report", Fields: []FieldInfo{
+			{Name: "created_at", JSONName: "created_at", Type: "string"},
+			{Name: "user_scores", JSONName: "user_scores", Type: "{ [key: string]: number }"},
+			{Name: "teams_by_id", JSONName: "teams_by_id", Type: "{ [key: string]: Team }"},
+		}},
+		{Name: "Team", Fields: []FieldInfo{
+			{Name: "team_name", JSONName: "team_name", Type: "string"},
+		}},
+	}, "camel")
+
+	content := renderTestFile(t, GenerateFileOptions{
+		Types:    types,
+		Handlers: []HandlerInfo{{Name: "GetReport", Method: "GET", Path: "/report", OutputType: "Report"}},
+	})
+	for _, str := range []string{
+		"'Report': [\n    ['created_at', 'createdAt'],\n    ['user_scores', 'userScores'],\n    ['teams_by_id', 'teamsById', { values: 'Team' }],\n  ],",
+		"'Team': [\n    ['team_name', 'teamName'],\n  ],",
+		"return renameFields(await createQuery<void, Report>('GET', url, undefined, headers, onResponse), 'Report');",
+	} {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected %q in generated file:\n%s", str, content)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// displayPosition returns a source position relative to the working directory when it's inside it,
+// so diagnostics are short and clickable
+func displayPosition(pos token.Position) string {
+	if wd, err := os.Getwd(); err == nil && filepath.IsAbs(pos.Filename) {
+		if rel, err := filepath.Rel(wd, pos.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+			pos.Filename = rel
+		}
+	}
+	return pos.String()
+}
+
+// routeKey returns the method and path a handler is served at, with its URL parameters unnamed so
+// /users/:id and /users/:userId are the same route
+func routeKey(h HandlerInfo) string {
+	parts := strings.Split(strings.Trim(h.Path, "/"), "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			parts[i] = ":"
+		}
+	}
+	return strings.ToUpper(h.Method) + " /" + strings.Join(parts, "/")
+}
+
+// routeCollisions returns a description of each handler that's served at the same method and path
+// as an earlier one, naming both handlers and where they're declared
+func routeCollisions(handlers []HandlerInfo) []string {
+	var collisions []string
+	first := make(map[string]HandlerInfo)
+	for _, h := range handlers {
+		key := routeKey(h)
+		existing, ok := first[key]
+		if !ok {
+			first[key] = h
+			continue
+		}
+		collisions = append(collisions, fmt.Sprintf("Handlers %s and %s are both %s %s",
+			handlerRef(existing), handlerRef(h), strings.ToUpper(h.Method), h.Path))
+	}
+	return collisions
+}
+
+// handlerRef returns a handler's name followed by where it's declared, when that's known
+func handlerRef(h HandlerInfo) string {
+	if h.Position == "" {
+		return h.Name
+	}
+	return fmt.Sprintf("%s (%s)", h.Name, h.Position)
+}
+
+// reportRouteCollisions prints the route collisions among handlers as warnings to out or, with
+// --strict, as errors to errOut, returning an error so the output isn't written
+func reportRouteCollisions(genOpts GenerateOptions, handlers []HandlerInfo, out, errOut io.Writer) error {
+	collisions := routeCollisions(handlers)
+	for _, collision := range collisions {
+		if genOpts.Strict {
+			fmt.Fprintf(errOut, "Error: %s\n", collision)
+		} else {
+			fmt.Fprintf(out, "Warning: %s\n", collision)
+		}
+	}
+	if genOpts.Strict && len(collisions) > 0 {
+		return fmt.Errorf("handler routes collide")
+	}
+	return nil
+}
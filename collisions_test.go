@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRouteCollisions(t *testing.T) {
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", Position: "api/users.go:12:1"},
+		{Name: "FindUser", Method: "get", Path: "/users/:userId/", Position: "api/find.go:8:1"},
+		{Name: "DeleteUser", Method: "DELETE", Path: "/users/:id"},
+		{Name: "ListUsers", Method: "GET", Path: "/users"},
+		{Name: "GetMe", Method: "GET", Path: "/users/me"},
+	}
+	collisions := routeCollisions(handlers)
+	expected := "Handlers GetUser (api/users.go:12:1) and FindUser (api/find.go:8:1) are both GET /users/:userId/"
+	if len(collisions) != 1 || collisions[0] != expected {
+		t.Errorf("Expected %q, got %q", expected, collisions)
+	}
+}
+
+func TestGenerateStrictCollisions(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"api/users.go": `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users
+// @Output User
+func ListUsersHandler() {}
+`,
+		"api/search.go": `package api
+
+// @Method GET
+// @Path /users
+// @Output User
+func SearchUsersHandler() {}
+`,
+		"go2type.yaml": `auth_token: token
+hooks: "false"
+packages:
+  - path: api
+    output_path: out/api.generated.ts
+`,
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	// Files are parsed in name order, so search.go declares the first handler
+	collision := "Handlers SearchUsers (api/search.go:6:1) and ListUsers (api/users.go:10:1) are both GET /users"
+	output := captureOutput(t, func() {
+		if err := generate(GenerateOptions{}); err != nil {
+			t.Errorf("Expected collisions to only warn, got %v", err)
+		}
+	})
+	if !strings.Contains(output, "Warning: "+collision) {
+		t.Errorf("Expected a warning naming both handlers, got:\n%s", output)
+	}
+	outputFile := filepath.Join(dir, "out", "api.generated.ts")
+	if _, err := os.Stat(outputFile); err != nil {
+		t.Fatalf("Expected the file to be generated despite the warning: %v", err)
+	}
+	if err := os.Remove(outputFile); err != nil {
+		t.Fatalf("Failed to remove the generated file: %v", err)
+	}
+
+	opts, err := parseGenerateFlags([]string{"--strict"})
+	if err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	output = captureOutput(t, func() {
+		if err := generate(opts); err == nil {
+			t.Errorf("Expected --strict to fail on colliding handlers")
+		}
+	})
+	if !strings.Contains(output, "Error: "+collision) {
+		t.Errorf("Expected an error naming both handlers, got:\n%s", output)
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be generated with --strict, got %v", err)
+	}
+}
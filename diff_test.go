@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name:     "equal",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "changed line",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			expected: `--- old
++++ new
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name: "separate hunks",
+			old:  "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			new:  "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			expected: `--- old
++++ new
+@@ -1,4 +1,4 @@
+-a
++A
+ 1
+ 2
+ 3
+@@ -7,4 +7,4 @@
+ 6
+ 7
+ 8
+-b
++B
+`,
+		},
+		{
+			name: "new file",
+			old:  "",
+			new:  "a\nb\n",
+			expected: `--- old
++++ new
+@@ -0,0 +1,2 @@
++a
++b
+`,
+		},
+		{
+			name: "insertion",
+			old:  "a\nc\n",
+			new:  "a\nb\nc\n",
+			expected: `--- old
++++ new
+@@ -1,2 +1,3 @@
+ a
++b
+ c
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unifiedDiff("old", "new", tt.old, tt.new)
+			if got != tt.expected {
+				t.Errorf("Expected diff:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffLargeChange(t *testing.T) {
+	var old, new []string
+	for i := 0; i < 3000; i++ {
+		old = append(old, "old")
+		new = append(new, "new")
+	}
+	diff := unifiedDiff("old", "new", strings.Join(old, "\n"), strings.Join(new, "\n"))
+	if strings.Count(diff, "\n-old") != 3000 || strings.Count(diff, "\n+new") != 3000 {
+		t.Errorf("Expected changes too large to compare line by line to be a removal and an addition")
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// enumConst is a constant declared with a named type, e.g. StatusActive Status = "active"
+type enumConst struct {
+	Name     string
+	TypeName string
+	Value    constant.Value
+	// Label is the constant's trailing comment, e.g. Active user for StatusActive Status = "active" // Active user
+	Label string
+}
+
+// parseConstDecl evaluates the constants of a const declaration and returns those with a named
+// type, in declaration order. Every evaluated constant is recorded in values so later
+// declarations can refer to it. Specs without values repeat the previous spec's type and
+// expressions with the next iota, as in Go.
+func parseConstDecl(decl *ast.GenDecl, values map[string]constant.Value) []enumConst {
+	var consts []enumConst
+	var typeExpr ast.Expr
+	var valueExprs []ast.Expr
+
+	for iota, spec := range decl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		if len(valueSpec.Values) > 0 {
+			typeExpr, valueExprs = valueSpec.Type, valueSpec.Values
+		}
+
+		for i, name := range valueSpec.Names {
+			if i >= len(valueExprs) {
+				break
+			}
+
+			typeName := ""
+			if ident, ok := typeExpr.(*ast.Ident); ok {
+				typeName = ident.Name
+			}
+
+			valueExpr := valueExprs[i]
+			// Conversions such as Status("active") give an untyped constant a type
+			if call, ok := valueExpr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+				if ident, ok := call.Fun.(*ast.Ident); ok {
+					typeName, valueExpr = ident.Name, call.Args[0]
+				}
+			}
+
+			value := evalConstExpr(valueExpr, iota, values)
+			if value.Kind() == constant.Unknown {
+				continue
+			}
+			if name.Name != "_" {
+				values[name.Name] = value
+			}
+			if typeName != "" && name.Name != "_" {
+				consts = append(consts, enumConst{Name: name.Name, TypeName: typeName, Value: value, Label: strings.TrimSpace(valueSpec.Comment.Text())})
+			}
+		}
+	}
+
+	return consts
+}
+
+// evalConstExpr evaluates a constant expression built from literals, iota and other constants,
+// returning an unknown value for anything else
+func evalConstExpr(expr ast.Expr, iota int, values map[string]constant.Value) constant.Value {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return constant.MakeFromLiteral(e.Value, e.Kind, 0)
+	case *ast.Ident:
+		if e.Name == "iota" {
+			return constant.MakeInt64(int64(iota))
+		}
+		if value, ok := values[e.Name]; ok {
+			return value
+		}
+	case *ast.ParenExpr:
+		return evalConstExpr(e.X, iota, values)
+	case *ast.UnaryExpr:
+		x := evalConstExpr(e.X, iota, values)
+		if x.Kind() == constant.Unknown || x.Kind() == constant.String {
+			return constant.MakeUnknown()
+		}
+		return constant.UnaryOp(e.Op, x, 0)
+	case *ast.BinaryExpr:
+		x := evalConstExpr(e.X, iota, values)
+		y := evalConstExpr(e.Y, iota, values)
+		if x.Kind() == constant.Unknown || y.Kind() == constant.Unknown {
+			return constant.MakeUnknown()
+		}
+		// Strings and bools only combine with their own kind
+		if x.Kind() != y.Kind() && (x.Kind() == constant.String || x.Kind() == constant.Bool || y.Kind() == constant.String || y.Kind() == constant.Bool) {
+			return constant.MakeUnknown()
+		}
+		switch e.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(x, e.Op, y))
+		case token.SHL, token.SHR:
+			shift, ok := constant.Uint64Val(y)
+			if !ok || x.Kind() != constant.Int {
+				return constant.MakeUnknown()
+			}
+			return constant.Shift(x, e.Op, uint(shift))
+		case token.QUO:
+			if constant.Sign(y) == 0 {
+				return constant.MakeUnknown()
+			}
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y)
+			}
+		}
+		return constant.BinaryOp(x, e.Op, y)
+	}
+	return constant.MakeUnknown()
+}
+
+// enumLiteral renders a constant as a TypeScript literal type
+func enumLiteral(value constant.Value) string {
+	switch value.Kind() {
+	case constant.String:
+		return tsStringLiteral(constant.StringVal(value))
+	case constant.Int:
+		return value.ExactString()
+	case constant.Float:
+		f, _ := constant.Float64Val(value)
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	case constant.Bool:
+		return value.String()
+	}
+	return ""
+}
+
+// tsStringLiteral quotes s as a double-quoted TypeScript string literal
+func tsStringLiteral(s string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return strconv.Quote(s)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// enumTypes groups typed constants into union types for the named types declared in the package,
+// in the order each type's first constant appears
+func enumTypes(consts []enumConst, namedTypes map[string]bool) []TypeInfo {
+	var enums []TypeInfo
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+	labelled := make(map[string]bool)
+
+	for _, c := range consts {
+		if !namedTypes[c.TypeName] {
+			continue
+		}
+		literal := enumLiteral(c.Value)
+		if literal == "" || seen[c.TypeName+"\x00"+literal] {
+			continue
+		}
+		seen[c.TypeName+"\x00"+literal] = true
+
+		i, ok := index[c.TypeName]
+		if !ok {
+			i = len(enums)
+			index[c.TypeName] = i
+			enums = append(enums, TypeInfo{Name: c.TypeName, FullName: c.TypeName})
+		}
+		enums[i].EnumValues = append(enums[i].EnumValues, literal)
+		// Constants without a comment are labelled with their name when others have one
+		label := c.Label
+		if label == "" {
+			label = c.Name
+		} else {
+			labelled[c.TypeName] = true
+		}
+		enums[i].EnumLabels = append(enums[i].EnumLabels, label)
+		enums[i].EnumNames = append(enums[i].EnumNames, c.Name)
+	}
+
+	for i := range enums {
+		if !labelled[enums[i].Name] {
+			enums[i].EnumLabels = nil
+		}
+	}
+
+	return enums
+}
+
+// enumDeclaration declares an enum in the given enum_style: a union of its values, e.g.
+// `export type Role = "admin" | "user";`, a const array of its values the union is derived from, so
+// they can be iterated at runtime, or a TypeScript enum. Enums that can't be TypeScript enums are
+// warned about on w.
+func enumDeclaration(t TypeInfo, style string, w io.Writer) string {
+	name := strings.Split(t.Name, " ")[0]
+	switch style {
+	case "const-array":
+		return fmt.Sprintf("export const %sValues = [%s] as const;\nexport type %s = (typeof %sValues)[number];\n",
+			name, strings.Join(t.EnumValues, ", "), name, name)
+	case "enum":
+		members := enumMemberNames(t)
+		if members == nil {
+			fmt.Fprintf(w, "Warning: %s can't be declared as a TypeScript enum. Using a union instead.\n", name)
+			break
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "export enum %s {\n", name)
+		for i, value := range t.EnumValues {
+			fmt.Fprintf(&b, "  %s = %s,\n", members[i], value)
+		}
+		b.WriteString("}\n")
+		return b.String()
+	}
+	return fmt.Sprintf("export type %s = %s;\n", name, strings.Join(t.EnumValues, " | "))
+}
+
+// enumMemberNames returns the member names of an enum declared as a TypeScript enum, which are its
+// constant names less the type name, e.g. Admin for RoleAdmin of type Role. Constants named
+// otherwise keep their name. It returns nil for enums of booleans, which TypeScript enums can't hold.
+func enumMemberNames(t TypeInfo) []string {
+	if len(t.EnumNames) != len(t.EnumValues) {
+		return nil
+	}
+	name := strings.Split(t.Name, " ")[0]
+	seen := make(map[string]bool)
+	var members []string
+	for i, value := range t.EnumValues {
+		if value == "true" || value == "false" {
+			return nil
+		}
+		member := strings.TrimPrefix(t.EnumNames[i], name)
+		if !token.IsIdentifier(member) || seen[member] {
+			member = t.EnumNames[i]
+		}
+		seen[member] = true
+		members = append(members, member)
+	}
+	return members
+}
+
+// enumMeta renders the labels of an enum's constants as e.g.
+// `export const StatusMeta: Record<Status, { label: string }> = { "active": { label: "Active user" } };`,
+// or returns an empty string when none of its constants has a comment
+func enumMeta(t TypeInfo) string {
+	if len(t.EnumLabels) == 0 {
+		return ""
+	}
+	name := strings.Split(t.Name, " ")[0]
+	var entries []string
+	for i, value := range t.EnumValues {
+		entries = append(entries, fmt.Sprintf("  %s: { label: %s },", value, tsStringLiteral(t.EnumLabels[i])))
+	}
+	return fmt.Sprintf("export const %sMeta: Record<%s, { label: string }> = {\n%s\n};\n", name, name, strings.Join(entries, "\n"))
+}
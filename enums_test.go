@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEnumTypes(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusInactive Status = "inactive"
+	StatusPending         = Status("pending")
+	StatusQuoted   Status = ` + "`say \"hi\"\\n`" + `
+	StatusDefault         = StatusActive
+)
+
+type Level int
+
+const (
+	LevelLow Level = iota
+	LevelMedium
+	_
+	LevelHigh
+)
+
+type Flag uint8
+
+const (
+	FlagRead Flag = 1 << iota
+	FlagWrite
+)
+
+const MaxLevel = 10
+
+type Task struct {
+	Status Status ` + "`json:\"status\"`" + `
+	Level  Level  ` + "`json:\"level\"`" + `
+	Flags  []Flag ` + "`json:\"flags\"`" + `
+}
+
+// @Method GET
+// @Path /tasks/:id
+// @Output Task
+func GetTaskHandler() {}
+`,
+	})
+
+	types, handlers, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+
+	expected := []string{
+		`export type Status = "active" | "inactive" | "pending" | "say \"hi\"\\n";`,
+		"export type Level = 0 | 1 | 3;",
+		"export type Flag = 1 | 2;",
+		"status: Status;",
+		"flags: Array<Flag>;",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s\n%s", str, content)
+		}
+	}
+	if strings.Contains(content, "MaxLevel") {
+		t.Errorf("Untyped constants should not produce a type")
+	}
+}
+
+func TestEnumLabels(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type Status string
+
+const (
+	StatusActive   Status = "active"   // Active user
+	StatusDisabled Status = "disabled" // Disabled by an admin
+	StatusPending  Status = "pending"
+)
+
+type Level int
+
+const (
+	LevelLow Level = iota
+	LevelHigh
+)
+
+type Account struct {
+	Status Status ` + "`json:\"status\"`" + `
+	Level  Level  ` + "`json:\"level\"`" + `
+}
+
+// @Method GET
+// @Path /accounts/:id
+// @Output Account
+func GetAccountHandler() {}
+`,
+	})
+
+	types, handlers, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+
+	expected := []string{
+		"export const StatusMeta: Record<Status, { label: string }> = {",
+		`  "active": { label: "Active user" },`,
+		`  "disabled": { label: "Disabled by an admin" },`,
+		`  "pending": { label: "StatusPending" },`,
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s\n%s", str, content)
+		}
+	}
+	if strings.Contains(content, "LevelMeta") {
+		t.Errorf("Enums without comments should not get metadata")
+	}
+}
+
+func TestEnumStyle(t *testing.T) {
+	src := `package api
+
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	Guest      Role = "guest"
+)
+
+type Level int
+
+const (
+	LevelLow Level = iota + 1
+	LevelHigh
+)
+
+type User struct {
+	Role  Role  ` + "`json:\"role\"`" + `
+	Level Level ` + "`json:\"level\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`
+	types, handlers, err := parseSource([]byte(src), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	for style, expected := range map[string][]string{
+		"": {
+			`export type Role = "admin" | "member" | "guest";`,
+			"export type Level = 1 | 2;",
+		},
+		"const-array": {
+			`export const RoleValues = ["admin", "member", "guest"] as const;`,
+			"export type Role = (typeof RoleValues)[number];",
+			"export const LevelValues = [1, 2] as const;",
+		},
+		"enum": {
+			"export enum Role {\n  Admin = \"admin\",\n  Member = \"member\",\n  Guest = \"guest\",\n}",
+			"export enum Level {\n  Low = 1,\n  High = 2,\n}",
+		},
+	} {
+		content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, EnumStyle: style, EmitGuards: true})
+		for _, str := range expected {
+			if !strings.Contains(content, str) {
+				t.Errorf("Expected %q with enum style %q, got:\n%s", str, style, content)
+			}
+		}
+		if !strings.Contains(content, `return ["admin", "member", "guest"].some((member) => member === value);`) {
+			t.Errorf("Expected the Role guard with enum style %q, got:\n%s", style, content)
+		}
+	}
+}
+
+func TestEnumStyleBooleans(t *testing.T) {
+	toggle := TypeInfo{Name: "Toggle", EnumValues: []string{"true", "false"}, EnumNames: []string{"ToggleOn", "ToggleOff"}}
+	var output bytes.Buffer
+	if declaration := enumDeclaration(toggle, "enum", &output); declaration != "export type Toggle = true | false;\n" {
+		t.Errorf("Expected an enum of booleans to be a union, got %q", declaration)
+	}
+	if !strings.Contains(output.String(), "Warning: Toggle can't be declared as a TypeScript enum") {
+		t.Errorf("Expected a warning, got %q", output.String())
+	}
+}
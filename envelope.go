@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// validResponseEnvelope returns the response field the outputs of handlers are unwrapped from, or
+// "" when response_envelope isn't a valid field name
+func validResponseEnvelope(envelope string, w io.Writer) string {
+	if envelope != "" && !tsIdentifierRegex.MatchString(envelope) {
+		fmt.Fprintf(w, "Warning: response_envelope %s isn't a valid field name. Responses won't be unwrapped.\n", envelope)
+		return ""
+	}
+	return envelope
+}
+
+// parseEnvelopeDirective parses the field of an @Envelope directive, or none to opt a handler out of
+// response_envelope. Invalid fields are warned about on w.
+func parseEnvelopeDirective(directive string, w io.Writer) (string, bool) {
+	if directive != "none" && !tsIdentifierRegex.MatchString(directive) {
+		fmt.Fprintf(w, "Warning: Invalid @Envelope field %q\n", directive)
+		return "", false
+	}
+	return directive, true
+}
+
+// envelopeHandler unwraps the output of a handler from the envelope field of its @Envelope, or from
+// envelope, the response_envelope, when it has none. Handlers without an output aren't unwrapped,
+// which an @Envelope on them is warned about on w.
+func envelopeHandler(h HandlerInfo, envelope string, w io.Writer) HandlerInfo {
+	switch {
+	case h.Envelope == "none":
+		h.Envelope = ""
+	case h.OutputType == "":
+		if h.Envelope != "" {
+			fmt.Fprintf(w, "Warning: @Envelope on %s has no @Output to unwrap\n", h.Name)
+		}
+		h.Envelope = ""
+	case h.Envelope == "":
+		h.Envelope = envelope
+	}
+	return h
+}
+
+// envelopeType returns the type of the successful response a handler's output is unwrapped from,
+// e.g. { data: User }, or its success type when it has no envelope
+func envelopeType(h HandlerInfo) string {
+	if h.Envelope == "" {
+		return successType(h)
+	}
+	return fmt.Sprintf("{ %s: %s }", h.Envelope, successType(h))
+}
+
+// hasEnvelopes reports whether any of handlers unwraps its output from an envelope
+func hasEnvelopes(handlers []HandlerInfo) bool {
+	for _, h := range handlers {
+		if h.Envelope != "" {
+			return true
+		}
+	}
+	return false
+}
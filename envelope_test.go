@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResponseEnvelope(t *testing.T) {
+	src := `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type Health struct {
+	OK bool ` + "`json:\"ok\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+
+// @Method GET
+// @Path /users
+// @Output Array<User>
+// @Envelope result
+func ListUsersHandler() {}
+
+// @Method GET
+// @Path /health
+// @Output Health
+// @Envelope none
+func GetHealthHandler() {}
+
+// @Method DELETE
+// @Path /users/:id
+// @Envelope data
+func DeleteUserHandler() {}
+
+// @Method POST
+// @Path /users
+// @Envelope data-field
+// @Output User
+func CreateUserHandler() {}
+`
+	var warnings bytes.Buffer
+	types, handlers, err := parseSource([]byte(src), ParseOptions{ResponseEnvelope: "data", Warnings: &warnings})
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	envelopes := make(map[string]string)
+	for _, h := range handlers {
+		envelopes[h.Name] = h.Envelope
+	}
+	for name, expected := range map[string]string{
+		"GetUser":    "data",
+		"ListUsers":  "result",
+		"GetHealth":  "",
+		"DeleteUser": "",
+		"CreateUser": "data",
+	} {
+		if envelopes[name] != expected {
+			t.Errorf("Expected %s to be unwrapped from %q, got %q", name, expected, envelopes[name])
+		}
+	}
+	for _, expected := range []string{
+		"Warning: Invalid @Envelope field \"data-field\"\n",
+		"Warning: @Envelope on DeleteUser has no @Output to unwrap\n",
+	} {
+		if !strings.Contains(warnings.String(), expected) {
+			t.Errorf("Expected warning %q, got:\n%s", expected, warnings.String())
+		}
+	}
+
+	outputFile := filepath.Join(createTempFolder(t.Name()), "api.generated.ts")
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, OutputFile: outputFile, ValidateResponses: true, EmitGuards: true, EmitMocks: true})
+	for _, expected := range []string{
+		"export const GetUserQuery = async (id: string, onResponse?: (response: Response) => void): Promise<User> => {",
+		"const data = (await createQuery<void, { data: User }>('GET', url, undefined, headers, onResponse)).data;",
+		"const data = (await createQuery<void, { result: Array<User> }>('GET', url, undefined, headers, onResponse)).result;",
+		"const data = await createQuery<void, Health>('GET', url, undefined, headers, onResponse);",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, content)
+		}
+	}
+	mocks := readSplitFile(t, outputFile, mockFileName)
+	if expected := "  http.get('/users/:id', () => HttpResponse.json<{ data: User }>({ data: { id: 0 } })),\n"; !strings.Contains(mocks, expected) {
+		t.Errorf("Expected %q, got:\n%s", expected, mocks)
+	}
+
+	op := handlerOperation(handlers[0], map[string]TypeInfo{"User": types[0]})
+	if schema := op.Responses["200"].Content["application/json"].Schema; schema.Properties["data"] == nil || schema.Properties["data"].Ref != "#/components/schemas/User" {
+		t.Errorf("Expected the OpenAPI response to be the envelope of User, got %+v", schema)
+	}
+
+	angular := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseAngular: true})
+	for _, expected := range []string{
+		"import { Observable, map } from 'rxjs';",
+		"return this.http.request<{ data: User }>('GET', url, {",
+		"}).pipe(map((response) => response.data));",
+		"return this.http.request<Health>('GET', url, {",
+	} {
+		if !strings.Contains(angular, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, angular)
+		}
+	}
+
+	if envelope := validResponseEnvelope("data.items", &warnings); envelope != "" {
+		t.Errorf("Expected an invalid response_envelope to be dropped, got %q", envelope)
+	}
+}
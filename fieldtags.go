@@ -0,0 +1,168 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+)
+
+// defaultFieldTag is the struct tag fields are named by without field_tag
+const defaultFieldTag = "json"
+
+// fieldTagValue returns a field's whole fieldTag tag, or its json tag when fieldTag is empty
+func fieldTagValue(tag reflect.StructTag, fieldTag string) string {
+	if fieldTag == "" {
+		fieldTag = defaultFieldTag
+	}
+	return tag.Get(fieldTag)
+}
+
+// tsTagName returns the name from a field's ts tag, such as ts:"displayName", which names the field
+// in the generated types whatever tag it's sent with. A ts tag of - is ignored.
+func tsTagName(tag reflect.StructTag) string {
+	name := strings.Split(tag.Get("ts"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// wireName returns the name a field is sent and received with, which differs from its name in the
+// generated types when it's renamed by a ts tag or boolean_prefix
+func wireName(field FieldInfo) string {
+	if field.JSONName != "" {
+		return field.JSONName
+	}
+	return field.Name
+}
+
+// renamedField is a field the client renames between Wire, the name it's sent with, and Name, its
+// name in the generated types. Renames is how the fields of its value are renamed, as rendered by
+// fieldRenames, or empty when it holds no renamed type.
+type renamedField struct {
+	Wire    string
+	Name    string
+	Renames string
+}
+
+// renamedType is a type whose values the client renames fields of
+type renamedType struct {
+	Name   string
+	Fields []renamedField
+}
+
+// renamedTypes returns the types whose values the client renames fields of: those with fields named
+// differently in the generated types than on the wire, such as by a ts tag or boolean_prefix, and
+// those with fields holding such types. Only the declared fields of a type are renamed, so the keys
+// of a map are left alone. Types in a namespace are named with it, like Models.User.
+func renamedTypes(namespaces []NamespaceInfo) []renamedType {
+	types := make(map[string]TypeInfo)
+	typeNamespaces := make(map[string]string)
+	var names []string
+	for _, ns := range namespaces {
+		for _, t := range ns.Types {
+			name := namespacedTypeName(ns.Name, strings.Split(t.Name, " ")[0])
+			types[name], typeNamespaces[name] = t, ns.Name
+			names = append(names, name)
+		}
+	}
+
+	renamed := make(map[string]bool)
+	var fields func(name string) []renamedField
+	fields = func(name string) []renamedField {
+		t, ns := types[name], typeNamespaces[name]
+		// A derived type is renamed like the type it picks its fields from
+		if t.Derived != "" {
+			base := namespacedTypeName(ns, derivedBaseType(t.Derived))
+			if _, ok := types[base]; !ok || base == name {
+				return nil
+			}
+			return fields(base)
+		}
+		var result []renamedField
+		for _, field := range t.Fields {
+			renames := fieldRenames(field.Type, ns, renamed)
+			if (field.JSONName != "" && field.JSONName != field.Name) || renames != "" {
+				result = append(result, renamedField{Wire: wireName(field), Name: field.Name, Renames: renames})
+			}
+		}
+		return result
+	}
+
+	// Holding a renamed type makes a type renamed too, so types are checked until no more are
+	// found, as they may hold types declared after them
+	for found := true; found; {
+		found = false
+		for _, name := range names {
+			if !renamed[name] && len(fields(name)) > 0 {
+				renamed[name], found = true, true
+			}
+		}
+	}
+
+	var result []renamedType
+	for _, name := range names {
+		if renamed[name] {
+			result = append(result, renamedType{Name: name, Fields: fields(name)})
+		}
+	}
+	return result
+}
+
+// fieldRenames returns how the client renames the fields of a value of tsType: the quoted name of
+// a type in renamed, or { items: ... } for an array and { values: ... } for a map of them. It's
+// empty when the value holds no renamed type. Types in namespace ns are referenced without it.
+func fieldRenames(tsType, ns string, renamed map[string]bool) string {
+	var types []string
+	for _, part := range splitTopLevel(tsCommentRegex.ReplaceAllString(tsType, ""), " | ") {
+		if part = strings.TrimSpace(part); part != "null" && part != "undefined" {
+			types = append(types, part)
+		}
+	}
+	if len(types) != 1 {
+		return ""
+	}
+	tsType = types[0]
+
+	switch {
+	case strings.HasPrefix(tsType, "Array<") && strings.HasSuffix(tsType, ">"):
+		if items := fieldRenames(tsType[len("Array<"):len(tsType)-1], ns, renamed); items != "" {
+			return "{ items: " + items + " }"
+		}
+	case tsRecordRegex.MatchString(tsType):
+		matches := tsRecordRegex.FindStringSubmatch(tsType)
+		if values := fieldRenames(matches[1]+matches[2], ns, renamed); values != "" {
+			return "{ values: " + values + " }"
+		}
+	default:
+		// A generic type is renamed by its own fields, whatever its type arguments
+		name := namespacedTypeName(ns, strings.Split(tsType, "<")[0])
+		if renamed[name] {
+			return "'" + name + "'"
+		}
+	}
+	return ""
+}
+
+// namespacedTypeName returns name, a type referenced in namespace ns, with the namespace it's
+// declared in
+func namespacedTypeName(ns, name string) string {
+	if ns == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return ns + "." + name
+}
+
+// derivedBaseType returns the type a Pick or Omit expression picks its fields from
+func derivedBaseType(derived string) string {
+	_, args, _ := strings.Cut(derived, "<")
+	return strings.TrimSpace(splitTopLevel(args, ",")[0])
+}
+
+// wireFieldsExpr wraps expr, a value of the generated types, so the client sends it with the wire
+// names of its renamed fields. renames is how its fields are renamed, as rendered by fieldRenames.
+func wireFieldsExpr(expr, renames string) string {
+	if renames == "" {
+		return expr
+	}
+	return "renameFields(" + expr + ", " + renames + ", true)"
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFieldTag(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"models/owner.go": `package models
+
+type Owner struct {
+	FullName string ` + "`mapstructure:\"full_name\" ts:\"name\"`" + `
+	Email    string ` + "`mapstructure:\"email\" json:\"mail\"`" + `
+}
+`,
+		"api/api.go": `package api
+
+import "github.com/example/testmodule/models"
+
+type Settings struct {
+	Theme    string       ` + "`mapstructure:\"theme\" json:\"color_theme\"`" + `
+	Locale   string       ` + "`mapstructure:\"locale,omitempty\"`" + `
+	Secret   string       ` + "`mapstructure:\"-\"`" + `
+	PageSize int          ` + "`mapstructure:\"page_size\" ts:\"pageSize\"`" + `
+	Owner    models.Owner ` + "`mapstructure:\"owner\"`" + `
+}
+
+// @Method GET
+// @Path /settings
+// @Output Settings
+func GetSettingsHandler() {}
+`,
+	})
+
+	types, _, err := parseConfiguredPackage(&Config{FieldTag: "mapstructure"}, PackageConfig{Path: filepath.Join(dir, "api")}, io.Discard)
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+	fields := make(map[string]FieldInfo)
+	for _, ty := range types {
+		for _, field := range ty.Fields {
+			fields[ty.Name+"."+field.Name] = field
+		}
+	}
+
+	for name, jsonName := range map[string]string{
+		"Settings.theme":    "theme",
+		"Settings.locale":   "locale",
+		"Settings.pageSize": "page_size",
+		"Settings.owner":    "owner",
+		"ModelsOwner.name":  "full_name",
+		"ModelsOwner.email": "email",
+	} {
+		field, ok := fields[name]
+		if !ok {
+			t.Errorf("Expected field %s, got %v", name, fields)
+			continue
+		}
+		if field.JSONName != jsonName {
+			t.Errorf("Expected %s to be sent as %s, got %s", name, jsonName, field.JSONName)
+		}
+	}
+	if !fields["Settings.locale"].IsOptional {
+		t.Errorf("Expected the omitempty option of the field tag to make locale optional")
+	}
+	if _, ok := fields["Settings.Secret"]; ok {
+		t.Errorf("Expected the field tagged - to be left out")
+	}
+}
+
+func TestTSTagClient(t *testing.T) {
+	types := []TypeInfo{{Name: "Account", Fields: []FieldInfo{
+		{Name: "id", JSONName: "id", Type: "number"},
+		{Name: "label", JSONName: "display_name", Type: "string"},
+	}}}
+	handlers := []HandlerInfo{
+		{Name: "UpdateAccount", Method: "PUT", Path: "/accounts/:id", InputType: "Account", OutputType: "Account", URLParams: []string{"id"}},
+	}
+
+	for _, httpClient := range []string{"fetch", "axios"} {
+		content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, HTTPClient: httpClient})
+		for _, str := range []string{
+			"label: string;",
+			"'Account': [\n    ['display_name', 'label'],\n  ],",
+			"function renameFields<T>(value: T, renames: FieldRenames, toWire = false): T {",
+			"return renameFields(await createQuery<Account, Account>('PUT', url, renameFields(input, 'Account', true), headers, onResponse), 'Account');",
+		} {
+			if !strings.Contains(content, str) {
+				t.Errorf("%s: expected %q in generated file:\n%s", httpClient, str, content)
+			}
+		}
+	}
+
+	// Boolean fields renamed by boolean_prefix are renamed along with them
+	types[0].Fields = append(types[0].Fields, FieldInfo{Name: "enabled", JSONName: "enabled", Type: "boolean"})
+	types = prefixBooleanFields(types)
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, BooleanPrefix: true})
+	if !strings.Contains(content, "'Account': [\n    ['display_name', 'label'],\n    ['enabled', 'isEnabled'],\n  ],") {
+		t.Errorf("Expected both renamed fields of Account, got:\n%s", content)
+	}
+}
+
+func TestTSTagRenamesByType(t *testing.T) {
+	// Two types send a field as name, but only one names it label, and a map's keys are left alone
+	types := []TypeInfo{
+		{Name: "Team", Fields: []FieldInfo{
+			{Name: "label", JSONName: "name", Type: "string"},
+			{Name: "members", JSONName: "members", Type: "Array<Member>"},
+			{Name: "roles", JSONName: "roles", Type: "{ [key: string]: Member }"},
+			{Name: "scores", JSONName: "scores", Type: "{ [key: string]: number }"},
+		}},
+		{Name: "Member", Fields: []FieldInfo{
+			{Name: "fullName", JSONName: "name", Type: "string"},
+			{Name: "manager", JSONName: "manager", Type: "Member | null"},
+		}},
+		{Name: "Tag", Fields: []FieldInfo{
+			{Name: "name", JSONName: "name", Type: "string"},
+		}},
+	}
+	handlers := []HandlerInfo{
+		{Name: "GetTeam", Method: "GET", Path: "/team", OutputType: "Team"},
+		{Name: "ListMembers", Method: "GET", Path: "/members", OutputType: "Array<Member>"},
+		{Name: "GetTag", Method: "GET", Path: "/tag", OutputType: "Tag"},
+	}
+
+	renamed := renamedTypes([]NamespaceInfo{{Types: types}})
+	expected := []renamedType{
+		{Name: "Team", Fields: []renamedField{
+			{Wire: "name", Name: "label"},
+			{Wire: "members", Name: "members", Renames: "{ items: 'Member' }"},
+			{Wire: "roles", Name: "roles", Renames: "{ values: 'Member' }"},
+		}},
+		{Name: "Member", Fields: []renamedField{
+			{Wire: "name", Name: "fullName"},
+			{Wire: "manager", Name: "manager", Renames: "'Member'"},
+		}},
+	}
+	if !reflect.DeepEqual(renamed, expected) {
+		t.Errorf("Expected renames by type:\n%+v\ngot:\n%+v", expected, renamed)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+	for _, str := range []string{
+		"return renameFields(await createQuery<void, Team>('GET', url, undefined, headers, onResponse), 'Team');",
+		"return renameFields(await createQuery<void, Array<Member>>('GET', url, undefined, headers, onResponse), { items: 'Member' });",
+		"return createQuery<void, Tag>('GET', url, undefined, headers, onResponse);",
+	} {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected %q in generated file:\n%s", str, content)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// formatFile formats the TypeScript file at filePath with formatTypeScript
+func formatFile(filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, []byte(formatTypeScript(string(content))), 0644)
+}
+
+// formatTypeScript is the formatter used when neither Prettier nor clang-format is available. It
+// isn't a full formatter: like Prettier, it indents the lines inside brackets by two spaces more than
+// the line opening them, and the body of an arrow function that starts on the next line. It keeps at
+// most one blank line between lines and none at the start or end of a block or of the file, trims
+// trailing whitespace and ends the file with a single newline. Lines inside multi-line template
+// literals are left as they are.
+func formatTypeScript(src string) string {
+	var b strings.Builder
+	var s bracketScanner
+	// The indentation of the lines that opened the brackets that are still open
+	var open []int
+	// An arrow function whose body starts on the next line indents it until the expression ends
+	arrow, arrowIndent := -1, 0
+	blank := false
+	prev := ""
+	for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		if s.inTemplate {
+			// The line is part of a string, so it's kept as it is
+			b.WriteString(line + "\n")
+			open = applyBrackets(open, s.scan(line), 0)
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			blank = prev != ""
+			continue
+		}
+		closing := strings.IndexByte("}])", trimmed[0]) >= 0
+		if blank && !closing && !opensBlock(prev) {
+			b.WriteString("\n")
+		}
+		blank = false
+		prev = trimmed
+
+		indent := 0
+		switch {
+		case closing && len(open) > 0:
+			indent = open[len(open)-1]
+		case arrow == len(open):
+			indent = arrowIndent + 1
+		case len(open) > 0:
+			indent = open[len(open)-1] + 1
+		}
+		if s.inComment && strings.HasPrefix(trimmed, "*") {
+			// Continuation lines of block comments line up their asterisks with the opening one
+			b.WriteString(strings.Repeat("  ", indent) + " " + trimmed + "\n")
+		} else {
+			b.WriteString(strings.Repeat("  ", indent) + trimmed + "\n")
+		}
+
+		depth := len(open)
+		open = applyBrackets(open, s.scan(trimmed), indent)
+		if arrow >= 0 && (len(open) < arrow || len(open) == arrow && (strings.HasSuffix(trimmed, ";") || strings.HasSuffix(trimmed, ","))) {
+			arrow = -1
+		}
+		if arrow < 0 && strings.HasSuffix(trimmed, "=>") && len(open) <= depth {
+			arrow, arrowIndent = len(open), indent
+		}
+	}
+	return b.String()
+}
+
+// applyBrackets applies the brackets of a line to open in order: an opening bracket is pushed with
+// the line's indent and a closing one pops the bracket it closes
+func applyBrackets(open []int, brackets string, indent int) []int {
+	for _, c := range brackets {
+		if strings.ContainsRune("{[(", c) {
+			open = append(open, indent)
+		} else if len(open) > 0 {
+			open = open[:len(open)-1]
+		}
+	}
+	return open
+}
+
+// opensBlock reports whether a trimmed line ends with an opening bracket
+func opensBlock(line string) bool {
+	return line != "" && strings.IndexByte("{[(", line[len(line)-1]) >= 0
+}
+
+// bracketScanner finds the brackets of lines of TypeScript, skipping those in strings, template
+// literals and comments. Block comments and template literals may span lines.
+type bracketScanner struct {
+	inComment  bool
+	inTemplate bool
+}
+
+// scan returns the brackets of line in order, e.g. "({})"
+func (s *bracketScanner) scan(line string) string {
+	var brackets strings.Builder
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case s.inComment:
+			if strings.HasPrefix(line[i:], "*/") {
+				s.inComment = false
+				i++
+			}
+		case s.inTemplate:
+			if c == '\\' {
+				i++
+			} else if c == '`' {
+				s.inTemplate = false
+			}
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case strings.HasPrefix(line[i:], "//"):
+			return brackets.String()
+		case strings.HasPrefix(line[i:], "/*"):
+			s.inComment = true
+			i++
+		case c == '\\':
+			// Escapes outside strings are in regular expressions, e.g. /\(/
+			i++
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '`':
+			s.inTemplate = true
+		case strings.IndexByte("{[()]}", c) >= 0:
+			brackets.WriteByte(c)
+		}
+	}
+	return brackets.String()
+}
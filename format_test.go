@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatTypeScript(t *testing.T) {
+	src := "\r\n\r\n" + `export interface User {
+        id: number;
+
+
+    name: string;
+}
+
+
+
+/**
+* The user's greeting
+*/
+export const greet = (user: User): string => {
+
+  const message = ` + "`Hello\n    ${user.name} {`" + `;
+        if (user.id) { // }
+    return message.replace(/\{/g, '(');
+    }
+
+  return '}';
+};
+export const useUser = (
+id: number
+): User =>
+useQuery({
+queryKey: ['User', id],
+});
+export const value = 1;
+
+
+`
+	expected := `export interface User {
+  id: number;
+
+  name: string;
+}
+
+/**
+ * The user's greeting
+ */
+export const greet = (user: User): string => {
+  const message = ` + "`Hello\n    ${user.name} {`" + `;
+  if (user.id) { // }
+    return message.replace(/\{/g, '(');
+  }
+
+  return '}';
+};
+export const useUser = (
+  id: number
+): User =>
+  useQuery({
+    queryKey: ['User', id],
+  });
+export const value = 1;
+`
+	if formatted := formatTypeScript(src); formatted != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, formatted)
+	}
+	if formatted := formatTypeScript(expected); formatted != expected {
+		t.Errorf("Expected formatted code to be unchanged, got:\n%s", formatted)
+	}
+}
+
+func TestBuiltInFormatter(t *testing.T) {
+	dir := createTempFolder(t.Name())
+	// Without Prettier or clang-format on the PATH, the built-in formatter is used
+	t.Setenv("PATH", dir)
+
+	filePath := filepath.Join(dir, "api.generated.ts")
+	writeTestFiles(t, dir, map[string]string{"api.generated.ts": "export const a = {\nb: 1,\n};\n\n\n"})
+	var out bytes.Buffer
+	if err := formatCode(filePath, dir, "", false, &out, &out); err != nil {
+		t.Fatalf("Expected the built-in formatter to be used, got %v", err)
+	}
+	if !strings.Contains(out.String(), "with the built-in formatter") {
+		t.Errorf("Expected the built-in formatter to be reported, got %q", out.String())
+	}
+	if content, _ := os.ReadFile(filePath); string(content) != "export const a = {\n  b: 1,\n};\n" {
+		t.Errorf("Expected the file to be formatted, got:\n%s", content)
+	}
+}
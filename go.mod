@@ -4,6 +4,7 @@ go 1.23.0
 
 require (
 	github.com/Masterminds/semver/v3 v3.3.0
+	github.com/fsnotify/fsnotify v1.8.0
 	golang.org/x/text v0.18.0
 	golang.org/x/tools v0.25.0
 	gopkg.in/yaml.v3 v3.0.1
@@ -12,4 +13,5 @@ require (
 require (
 	golang.org/x/mod v0.21.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sys v0.25.0 // indirect
 )
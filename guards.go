@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tsCommentRegex matches inline comments in TypeScript types, such as the format in string /* uuid */
+var tsCommentRegex = regexp.MustCompile(`\s*/\*.*?\*/`)
+
+// tsRecordRegex matches index signatures, { [key: string]: V }, and records, Record<string, V>
+var tsRecordRegex = regexp.MustCompile(`^(?:\{ \[key: [^\]]+\]: (.+) \}|Record<[^,]+, (.+)>)$`)
+
+// tsLiteralRegex matches string and number literal types
+var tsLiteralRegex = regexp.MustCompile(`^(?:'[^']*'|"[^"]*"|-?\d+(?:\.\d+)?)$`)
+
+// guardedTypes returns the names of the types that get a type guard: enums and non-generic structs.
+// Unions, derived, generic and interface types can't be checked field by field and are accepted as-is.
+func guardedTypes(types []TypeInfo) map[string]bool {
+	guarded := make(map[string]bool)
+	for _, t := range types {
+		if t.Union || t.Derived != "" || len(t.TypeParams) > 0 || len(t.Extends) > 0 || t.TSType != "" {
+			continue
+		}
+		guarded[strings.Split(t.Name, " ")[0]] = true
+	}
+	return guarded
+}
+
+// typeGuard renders a type guard such as `export const isUser = (value: unknown): value is User`
+// that checks the shape of a value received at runtime, or returns an empty string for types that
+// can't be checked
+func typeGuard(t TypeInfo, types []TypeInfo) string {
+	guarded := guardedTypes(types)
+	name := strings.Split(t.Name, " ")[0]
+	if !guarded[name] {
+		return ""
+	}
+	return fmt.Sprintf("export const is%s = (value: unknown): value is %s => {\n%s};\n", name, name, guardBody(t, guarded))
+}
+
+// guardBody renders the body of a type guard for t, which reads the unknown value
+func guardBody(t TypeInfo, guarded map[string]bool) string {
+	if len(t.EnumValues) > 0 {
+		return fmt.Sprintf("  return [%s].some((member) => member === value);\n", strings.Join(t.EnumValues, ", "))
+	}
+
+	var checks []string
+	for _, field := range t.Fields {
+		expr := fmt.Sprintf("v[%s]", tsStringLiteral(field.Name))
+		check := typeCheck(expr, field.Type, guarded, 0)
+		if field.IsOptional && check != "true" {
+			check = fmt.Sprintf("(%s === undefined || %s)", expr, check)
+		}
+		if check != "true" {
+			checks = append(checks, check)
+		}
+	}
+
+	body := "  if (typeof value !== 'object' || value === null) {\n    return false;\n  }\n"
+	if len(checks) == 0 {
+		return body + "  return true;\n"
+	}
+	return body + "  const v = value as Record<string, unknown>;\n  return (\n    " + strings.Join(checks, " &&\n    ") + "\n  );\n"
+}
+
+// typeCheck returns a JavaScript expression checking that expr has the TypeScript type tsType, or
+// "true" when the type can't be checked, e.g. any or a type from another package. depth numbers the
+// parameters of nested callbacks.
+func typeCheck(expr, tsType string, guarded map[string]bool, depth int) string {
+	tsType = strings.TrimSpace(tsCommentRegex.ReplaceAllString(tsType, ""))
+
+	if parts := splitTopLevel(tsType, " | "); len(parts) > 1 {
+		var checks []string
+		for _, part := range parts {
+			check := typeCheck(expr, part, guarded, depth)
+			if check == "true" {
+				return "true"
+			}
+			checks = append(checks, check)
+		}
+		return "(" + strings.Join(checks, " || ") + ")"
+	}
+
+	switch {
+	case tsType == "string" || tsType == "number" || tsType == "boolean":
+		return fmt.Sprintf("typeof %s === '%s'", expr, tsType)
+	case tsType == "null":
+		return expr + " === null"
+	case tsType == "undefined":
+		return expr + " === undefined"
+	case tsType == "Date":
+		return expr + " instanceof Date"
+	case tsLiteralRegex.MatchString(tsType):
+		return fmt.Sprintf("%s === %s", expr, tsType)
+	case strings.HasPrefix(tsType, "Array<") && strings.HasSuffix(tsType, ">"):
+		item := fmt.Sprintf("item%d", depth)
+		elemCheck := typeCheck(item, tsType[len("Array<"):len(tsType)-1], guarded, depth+1)
+		if elemCheck == "true" {
+			return fmt.Sprintf("Array.isArray(%s)", expr)
+		}
+		return fmt.Sprintf("(Array.isArray(%s) && %s.every((%s) => %s))", expr, expr, item, elemCheck)
+	case tsRecordRegex.MatchString(tsType):
+		matches := tsRecordRegex.FindStringSubmatch(tsType)
+		valueType := matches[1] + matches[2]
+		item := fmt.Sprintf("item%d", depth)
+		isObject := fmt.Sprintf("typeof %s === 'object' && %s !== null", expr, expr)
+		valueCheck := typeCheck(item, valueType, guarded, depth+1)
+		if valueCheck == "true" {
+			return "(" + isObject + ")"
+		}
+		return fmt.Sprintf("(%s && Object.values(%s).every((%s) => %s))", isObject, expr, item, valueCheck)
+	case guarded[tsType]:
+		return fmt.Sprintf("is%s(%s)", tsType, expr)
+	}
+	return "true"
+}
+
+// responseCheck returns a JavaScript expression checking a handler's response, held in data,
+// against the type guards, or an empty string when the output type can't be checked
+func responseCheck(outputType string, types []TypeInfo) string {
+	if outputType == "" {
+		return ""
+	}
+	check := typeCheck("data", outputType, guardedTypes(types), 0)
+	if check == "true" {
+		return ""
+	}
+	return check
+}
+
+// splitTopLevel splits s on sep where it isn't nested in brackets or braces
+func splitTopLevel(s, sep string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<', '{', '[', '(':
+			depth++
+		case '>', '}', ']', ')':
+			depth--
+		}
+		if depth == 0 && strings.HasPrefix(s[i:], sep) {
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+var guardTestTypes = []TypeInfo{
+	{Name: "User", Fields: []FieldInfo{
+		{Name: "id", Type: "number"},
+		{Name: "status", Type: "Status"},
+		{Name: "tags", Type: "Array<string>"},
+		{Name: "scopes", Type: "{ [key: string]: Array<string> }"},
+		{Name: "manager", Type: "User | null", IsOptional: true},
+		{Name: "created_at", Type: "string /* date-time */"},
+		{Name: "extra", Type: "any"},
+	}},
+	{Name: "Status", EnumValues: []string{`"active"`, `"banned"`}},
+	{Name: "Page", TypeParams: []string{"T"}, Fields: []FieldInfo{{Name: "items", Type: "Array<T>"}}},
+}
+
+func TestTypeCheck(t *testing.T) {
+	guarded := guardedTypes(guardTestTypes)
+	tests := []struct {
+		tsType   string
+		expected string
+	}{
+		{"string", "typeof x === 'string'"},
+		{"number /* nanoseconds */", "typeof x === 'number'"},
+		{"string | null", "(typeof x === 'string' || x === null)"},
+		{"Array<User>", "(Array.isArray(x) && x.every((item0) => isUser(item0)))"},
+		{"Record<string, number>", "(typeof x === 'object' && x !== null && Object.values(x).every((item0) => typeof item0 === 'number'))"},
+		{"'a' | 'b'", "(x === 'a' || x === 'b')"},
+		{"Array<any>", "Array.isArray(x)"},
+		{"Page<User>", "true"},
+		{"User | any", "true"},
+		{"models.Profile", "true"},
+	}
+	for _, tt := range tests {
+		if got := typeCheck("x", tt.tsType, guarded, 0); got != tt.expected {
+			t.Errorf("typeCheck(%q) = %s, expected %s", tt.tsType, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateResponses(t *testing.T) {
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+		{Name: "ListUsers", Method: "GET", Path: "/users", OutputType: "Page<User>"},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: guardTestTypes, Handlers: handlers, EmitGuards: true, ValidateResponses: true})
+	for _, expected := range []string{
+		"export const isUser = (value: unknown): value is User => {",
+		"export const isStatus = (value: unknown): value is Status => {",
+		"const data = await createQuery<void, User>('GET', url, undefined, headers, onResponse);",
+		"if (!(isUser(data))) {",
+		"throw new APIError(0, 'Invalid response: expected User', data as unknown as Record<string, unknown>);",
+		"return createQuery<void, Page<User>>(",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected output to contain %q", expected)
+		}
+	}
+	if strings.Contains(content, "isPage") {
+		t.Errorf("Expected generic types not to get a guard")
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Types: guardTestTypes, Handlers: handlers, EmitGuards: true})
+	if !strings.Contains(content, "export const isUser") || strings.Contains(content, "isUser(data)") {
+		t.Errorf("Expected guards without response validation")
+	}
+
+	// Run the guards against valid and drifted responses
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found, skipping runtime check")
+	}
+	guarded := guardedTypes(guardTestTypes)
+	var script strings.Builder
+	for _, ty := range guardTestTypes {
+		if guarded[ty.Name] {
+			fmt.Fprintf(&script, "const is%s = (value) => {\n%s};\n", ty.Name, strings.ReplaceAll(guardBody(ty, guarded), " as Record<string, unknown>", ""))
+		}
+	}
+	fmt.Fprintf(&script, `const check = (data) => {
+  if (!(%s)) {
+    throw new Error('Invalid response: expected User');
+  }
+  return data;
+};
+const valid = { id: 1, status: 'active', tags: ['a'], scopes: { read: ['x'] }, created_at: '2024-01-01T00:00:00Z', extra: 5 };
+check(valid);
+check({ ...valid, manager: null });
+check({ ...valid, manager: { ...valid } });
+for (const drifted of [
+  { ...valid, id: '1' },
+  { ...valid, status: 'deleted' },
+  { ...valid, tags: [1] },
+  { ...valid, scopes: { read: 'x' } },
+  { ...valid, manager: { ...valid, id: null } },
+  null,
+]) {
+  let threw = false;
+  try {
+    check(drifted);
+  } catch (e) {
+    threw = true;
+  }
+  if (!threw) {
+    throw new Error('Expected a drifted response to throw: ' + JSON.stringify(drifted));
+  }
+}
+`, responseCheck("User", guardTestTypes))
+
+	cmd := exec.Command(node, "-e", script.String())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("Guard check failed: %v\n%s\n%s", err, output, script.String())
+	}
+}
@@ -1,19 +1,34 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/parser"
 	"go/token"
 	"go/types"
 	"golang.org/x/text/language"
+	"io"
+	"io/fs"
 	"log"
+	"math"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 	"unicode"
@@ -25,19 +40,65 @@ import (
 
 // Config represents the configuration yaml file
 type Config struct {
-	AuthToken        string          `yaml:"auth_token"`
-	AuthTokenStorage string          `yaml:"auth_token_storage"`
-	PrettierPath     string          `yaml:"prettier_path"`
-	Hooks            string          `yaml:"hooks"`
-	UseDateObject    bool            `yaml:"use_date_object"`
-	Packages         []PackageConfig `yaml:"packages"`
+	AuthToken           string          `yaml:"auth_token"`
+	AuthTokenStorage    string          `yaml:"auth_token_storage"`
+	PrettierPath        string          `yaml:"prettier_path"`
+	Hooks               string          `yaml:"hooks"`
+	UseDateObject       bool            `yaml:"use_date_object"`
+	ZeroTimeAsNull      bool            `yaml:"zero_time_as_null,omitempty"`
+	QueryKeyStyle       string          `yaml:"query_key_style,omitempty"`
+	DedupeRequests      bool            `yaml:"dedupe_requests,omitempty"`
+	EOL                 string          `yaml:"eol,omitempty"`
+	APIConfig           bool            `yaml:"api_config,omitempty"`
+	HTTPClient          string          `yaml:"http_client,omitempty"`
+	Bundle              bool            `yaml:"bundle,omitempty"`
+	OutputPath          string          `yaml:"output_path,omitempty"`
+	BrandIDs            bool            `yaml:"brand_ids,omitempty"`
+	BrandedIDs          bool            `yaml:"branded_ids,omitempty"`
+	IDTypes             []string        `yaml:"id_types,omitempty"`
+	EmitGuards          bool            `yaml:"emit_guards,omitempty"`
+	ValidateResponses   bool            `yaml:"validate_responses,omitempty"`
+	BooleanPrefix       bool            `yaml:"boolean_prefix,omitempty"`
+	DefaultExport       bool            `yaml:"default_export,omitempty"`
+	VersionPathTemplate string          `yaml:"version_path_template,omitempty"`
+	FullExportPackages  []string        `yaml:"full_export_packages,omitempty"`
+	Concurrency         int             `yaml:"concurrency,omitempty"`
+	ClientStyle         string          `yaml:"client_style,omitempty"`
+	Semicolons          *bool           `yaml:"semicolons,omitempty"`
+	BaseURL             string          `yaml:"base_url,omitempty"`
+	PaginationEnvelope  string          `yaml:"pagination_envelope,omitempty"`
+	TypeKeyword         string          `yaml:"type_keyword,omitempty"`
+	UseUnknownForAny    bool            `yaml:"use_unknown_for_any,omitempty"`
+	OnUnresolved        string          `yaml:"on_unresolved,omitempty"`
+	UintType            string          `yaml:"uint_type,omitempty"`
+	EnumStyle           string          `yaml:"enum_style,omitempty"`
+	EmitMocks           bool            `yaml:"emit_mocks,omitempty"`
+	TypePrefix          string          `yaml:"type_prefix,omitempty"`
+	TypeSuffix          string          `yaml:"type_suffix,omitempty"`
+	MaxResponseBytes    int64           `yaml:"max_response_bytes,omitempty"`
+	ArrayQueryFormat    string          `yaml:"array_query_format,omitempty"`
+	ResponseEnvelope    string          `yaml:"response_envelope,omitempty"`
+	FieldTag            string          `yaml:"field_tag,omitempty"`
+	FieldCasing         string          `yaml:"field_casing,omitempty"`
+	EmitMeta            bool            `yaml:"emit_meta,omitempty"`
+	EmitPaths           bool            `yaml:"emit_paths,omitempty"`
+	Packages            []PackageConfig `yaml:"packages"`
 }
 
 // PackageConfig represents the configuration for a Go package
 type PackageConfig struct {
-	Path         string            `yaml:"path"`
-	OutputPath   string            `yaml:"output_path"`
-	TypeMappings map[string]string `yaml:"type_mappings"`
+	Path              string            `yaml:"path"`
+	OutputPath        string            `yaml:"output_path"`
+	TypeMappings      map[string]string `yaml:"type_mappings"`
+	RouterFile        string            `yaml:"router_file,omitempty"`
+	Recursive         bool              `yaml:"recursive,omitempty"`
+	RouterPatterns    []string          `yaml:"router_patterns,omitempty"`
+	InterfaceFallback map[string]string `yaml:"interface_fallback,omitempty"`
+	AuthToken         string            `yaml:"auth_token,omitempty"`
+	AuthTokenStorage  string            `yaml:"auth_token_storage,omitempty"`
+	Split             string            `yaml:"split,omitempty"`
+	BaseURL           string            `yaml:"base_url,omitempty"`
+	PathPrefix        string            `yaml:"path_prefix,omitempty"`
 }
 
 type HeaderInfo struct {
@@ -45,6 +106,10 @@ type HeaderInfo struct {
 	SafeName   string
 	Source     string
 	StorageKey string
+	// Value is the fixed header value of a const header
+	Value string
+	// Func is the name of the configured function a fn header gets its value from
+	Func string
 }
 
 type HandlerInfo struct {
@@ -53,14 +118,82 @@ type HandlerInfo struct {
 	Path       string
 	InputType  string
 	OutputType string
-	URLParams  []string
-	Headers    []HeaderInfo
+	// Outputs are the outputs declared with a status, e.g. @Output 400 ValidationErrors, and the
+	// @Output without one. OutputType is their union.
+	Outputs   []OutputVariant
+	URLParams []string
+	Headers   []HeaderInfo
+	Statuses  []StatusInfo
+	// Batch is the name of a useQueries hook fetching several items at once, set by @Batch
+	Batch string
+	// Version is the API version from @Version, substituted into the version path template
+	Version string
+	// QueryParams are the query string parameters declared with @Query
+	QueryParams []QueryParamInfo
+	// Description is the text of the @Description directives, one line per directive, emitted as the
+	// JSDoc summary, the OpenAPI operation description and the handler's __meta description
+	Description string
+	// Deprecated is the reason from @Deprecated, emitted as a JSDoc @deprecated tag
+	Deprecated string
+	// IsDeprecated is set by @Deprecated, whose reason may be empty
+	IsDeprecated bool
+	// Paginated is set by @Paginated, or by an output of the pagination envelope such as Paginated[User]
+	Paginated bool
+	// Envelope is the field of the response the output is unwrapped from, set by @Envelope or
+	// response_envelope
+	Envelope string
+	// Position is where the handler is declared, e.g. api/users.go:12:1
+	Position string
+	// Poll refetches the handler's React Query hook at an interval, set by @Poll
+	Poll *PollInfo
+}
+
+// QueryParamInfo is a query string parameter declared with @Query
+type QueryParamInfo struct {
+	// Key is the parameter's name in the query string
+	Key string
+	// Name is the TypeScript argument name
+	Name     string
+	Type     string
+	Optional bool
+	// Struct is set for `@Query FilterInput`, whose fields are all sent as query parameters
+	Struct bool
+}
+
+// StatusInfo is an HTTP status code declared on a handler with @Error or @Status
+type StatusInfo struct {
+	Code        int
+	Description string
 }
 
 type TypeInfo struct {
 	Name     string
 	FullName string
 	Fields   []FieldInfo
+	// Derived is a Pick/Omit expression from a @TSDerive directive, emitted instead of the fields
+	Derived string
+	// TypeParams are the type parameter names of a generic struct, emitted as TypeScript generics
+	TypeParams []string
+	// Union renders a oneof-style struct from a @TSUnion directive as a union of its pointer fields
+	Union bool
+	// UnionDiscriminant is the field added to each union member holding the member's JSON name
+	UnionDiscriminant string
+	// EnumValues are the TypeScript literals of a Go enum's constants, emitted as a union instead of the fields
+	EnumValues []string
+	// EnumLabels are the display labels of EnumValues, taken from the constants' trailing comments
+	EnumLabels []string
+	// EnumNames are the names of the constants of EnumValues, which name the members of a TypeScript enum
+	EnumNames []string
+	// AlwaysExport keeps the type in the output even when no handler references it
+	AlwaysExport bool
+	// TSType is the @TSType override of a type from another package, emitted in its place. Types
+	// in the generated file with a TSType are emitted as `export type X = TSType`.
+	TSType string
+	// Extends are the interfaces embedded by a Go interface, emitted as `export interface X extends A, B {}`
+	Extends []string
+	// BrandBase is the type a branded type of a type mapping brands. The branded type also gets a
+	// function of its name converting a value of BrandBase to it, e.g. UUID(s).
+	BrandBase string
 }
 
 type FieldInfo struct {
@@ -70,6 +203,14 @@ type FieldInfo struct {
 	JSONName    string
 	IsArray     bool
 	IsOptional  bool
+	// Validation holds the rules of a go-playground validate tag, e.g. required, max=255
+	Validation []string
+	// Embedded marks an untagged embedded struct, whose fields are promoted into the parent
+	Embedded bool
+	// Default is the value of a default struct tag, coerced to the field's type when emitted
+	Default string
+	// Doc is the field's doc comment and trailing line comment, emitted as JSDoc
+	Doc string
 }
 
 func main() {
@@ -81,14 +222,74 @@ func main() {
 	command := os.Args[1]
 	switch command {
 	case "init":
-		if err := initConfig(); err != nil {
+		filename := defaultConfigFile
+		if len(os.Args) > 2 {
+			filename = os.Args[2]
+		}
+		if err := initConfig(filename); err != nil {
 			fmt.Printf("Error initializing config: %v\n", err)
 			os.Exit(1)
 		}
 	case "generate":
+		opts, err := parseGenerateFlags(os.Args[2:])
+		if err != nil {
+			fmt.Printf("Error parsing flags: %v\n", err)
+			os.Exit(1)
+		}
+		if opts.Stdin {
+			if err := generateStdin(opts, os.Stdin); err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating files: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		errOut := opts.errorOutput()
+		if !opts.Quiet {
+			printVersion()
+		}
+		if err := generate(opts); err != nil {
+			fmt.Fprintf(errOut, "Error generating files: %v\n", err)
+			os.Exit(1)
+		}
+	case "watch":
+		opts, err := parseWatchFlags(os.Args[2:])
+		if err != nil {
+			fmt.Printf("Error parsing flags: %v\n", err)
+			os.Exit(1)
+		}
 		printVersion()
-		if err := generate(true); err != nil {
-			fmt.Printf("Error generating files: %v\n", err)
+		if err := watch(opts, make(chan struct{})); err != nil {
+			fmt.Printf("Error watching files: %v\n", err)
+			os.Exit(1)
+		}
+	case "openapi":
+		opts, err := parseOpenAPIFlags(os.Args[2:])
+		if err != nil {
+			fmt.Printf("Error parsing flags: %v\n", err)
+			os.Exit(1)
+		}
+		if err := generateOpenAPI(opts); err != nil {
+			fmt.Printf("Error generating OpenAPI document: %v\n", err)
+			os.Exit(1)
+		}
+	case "clean":
+		opts, err := parseCleanFlags(os.Args[2:])
+		if err != nil {
+			fmt.Printf("Error parsing flags: %v\n", err)
+			os.Exit(1)
+		}
+		if err := clean(opts, os.Stdin); err != nil {
+			fmt.Printf("Error cleaning files: %v\n", err)
+			os.Exit(1)
+		}
+	case "validate":
+		opts, err := parseValidateFlags(os.Args[2:])
+		if err != nil {
+			fmt.Printf("Error parsing flags: %v\n", err)
+			os.Exit(1)
+		}
+		if err := validate(opts, os.Stdout); err != nil {
+			fmt.Printf("Error validating: %v\n", err)
 			os.Exit(1)
 		}
 	case "version":
@@ -104,15 +305,211 @@ func main() {
 }
 
 func printHelp() {
-	fmt.Println("Usage: go2type <command>")
+	fmt.Println("Usage: go2type <command> [flags]")
 	fmt.Println("Available commands:")
-	fmt.Println("  init      Initialize a new configuration file")
+	fmt.Println("  init      Initialize a new configuration file, go2type.yaml unless a filename is given")
 	fmt.Println("  generate  Generate TypeScript files based on the configuration")
+	fmt.Println("  watch     Regenerate TypeScript files when Go files change")
+	fmt.Println("  openapi   Generate an OpenAPI 3.0 document from the configured packages")
+	fmt.Println("  clean     Remove the generated files listed in the configuration")
+	fmt.Println("  validate  Check the configuration and handler annotations without generating anything")
 	fmt.Println("  version   Print the version of go2type")
 	fmt.Println("  help      Print this help message")
+	fmt.Println("Generate flags:")
+	fmt.Println("  -                 Read Go source from stdin and write the TypeScript to stdout, without go2type.yaml")
+	fmt.Println("  --skip-unchanged  Skip packages whose output is newer than their Go sources")
+	fmt.Println("  --dry-run         Print a diff of the changes instead of writing files")
+	fmt.Println("  --check           Exit with an error if generated files are out of date (implies --dry-run)")
+	fmt.Println("  --quiet           Print only errors, to stderr")
+	fmt.Println("  --strict          Fail when handlers share a method and path")
+	fmt.Println("  --config          Path of the configuration file (default go2type.yaml)")
+	fmt.Println("Watch flags:")
+	fmt.Println("  --debounce        How long changes must settle before regenerating (default 300ms)")
+	fmt.Println("  --serve           Serve the generated files over HTTP on an address, e.g. :8080")
+	fmt.Println("  --config          Path of the configuration file (default go2type.yaml)")
+	fmt.Println("OpenAPI flags:")
+	fmt.Println("  --output          Path of the generated document (default openapi.yaml)")
+	fmt.Println("  --title           Title of the API (default API)")
+	fmt.Println("  --config          Path of the configuration file (default go2type.yaml)")
+	fmt.Println("Clean flags:")
+	fmt.Println("  --force           Remove files without asking for confirmation")
+	fmt.Println("  --config          Path of the configuration file (default go2type.yaml)")
+	fmt.Println("Validate flags:")
+	fmt.Println("  --config          Path of the configuration file (default go2type.yaml)")
+}
+
+// GenerateOptions contains the command line options for the generate command
+type GenerateOptions struct {
+	ShouldFormat  bool
+	SkipUnchanged bool
+	// Packages limits generation to the outputs of these configured package paths, or all when empty
+	Packages []string
+	// DryRun prints a diff of each output against the file on disk instead of writing it
+	DryRun bool
+	// Check fails a dry run when any output would change
+	Check bool
+	// Stdin generates a client for Go source read from stdin, written to stdout, set by `generate -`
+	Stdin bool
+	// Quiet prints only errors, to stderr
+	Quiet bool
+	// Strict fails, instead of warning, when handlers share a method and path
+	Strict bool
+	// ConfigFile is the path of the configuration file, go2type.yaml when empty
+	ConfigFile string
+	// Stdout and Stderr are where messages are printed, os.Stdout and os.Stderr when nil
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// defaultConfigFile is the configuration file used unless --config names another
+const defaultConfigFile = "go2type.yaml"
+
+// configFile returns the path of the configuration file
+func (opts GenerateOptions) configFile() string {
+	return configFileOrDefault(opts.ConfigFile)
+}
+
+// configFileOrDefault returns configFile, the path given with --config, or go2type.yaml when it's
+// empty
+func configFileOrDefault(configFile string) string {
+	if configFile == "" {
+		return defaultConfigFile
+	}
+	return configFile
+}
+
+// infoOutput returns where informational and success messages are printed, which is nowhere when quiet
+func (opts GenerateOptions) infoOutput() io.Writer {
+	if opts.Quiet {
+		return io.Discard
+	}
+	return stdoutIfNil(opts.Stdout)
+}
+
+// errorOutput returns where errors and warnings are printed: stderr when quiet, otherwise stdout along
+// with the other messages
+func (opts GenerateOptions) errorOutput() io.Writer {
+	if opts.Quiet {
+		return stderrIfNil(opts.Stderr)
+	}
+	return stdoutIfNil(opts.Stdout)
+}
+
+// stdoutIfNil returns w, or stdout when w is nil, for the writers of options that may be left unset
+func stdoutIfNil(w io.Writer) io.Writer {
+	if w == nil {
+		return os.Stdout
+	}
+	return w
+}
+
+// stderrIfNil returns w, or stderr when w is nil
+func stderrIfNil(w io.Writer) io.Writer {
+	if w == nil {
+		return os.Stderr
+	}
+	return w
+}
+
+func parseGenerateFlags(args []string) (GenerateOptions, error) {
+	opts := GenerateOptions{ShouldFormat: true}
+
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.BoolVar(&opts.SkipUnchanged, "skip-unchanged", false, "skip packages whose output is newer than their Go sources")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "print a diff of the changes instead of writing files")
+	fs.BoolVar(&opts.Check, "check", false, "exit with an error if generated files are out of date; implies --dry-run")
+	fs.BoolVar(&opts.Quiet, "quiet", false, "print only errors, to stderr")
+	fs.BoolVar(&opts.Strict, "strict", false, "fail when handlers share a method and path")
+	fs.StringVar(&opts.ConfigFile, "config", defaultConfigFile, "path of the configuration file")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Check {
+		opts.DryRun = true
+	}
+	opts.Stdin = fs.Arg(0) == "-"
+
+	return opts, nil
+}
+
+// CleanOptions contains the command line options for the clean command
+type CleanOptions struct {
+	Force bool
+	// ConfigFile is the path of the configuration file, go2type.yaml when empty
+	ConfigFile string
+}
+
+func parseCleanFlags(args []string) (CleanOptions, error) {
+	var opts CleanOptions
+
+	fs := flag.NewFlagSet("clean", flag.ContinueOnError)
+	fs.BoolVar(&opts.Force, "force", false, "remove files without asking for confirmation")
+	fs.StringVar(&opts.ConfigFile, "config", defaultConfigFile, "path of the configuration file")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
 }
 
-func loadConfig(filename string) (*Config, error) {
+// clean removes the generated files of every configured package, including the files a split output
+// is written to, the MSW handlers and the bundle. Unless opts.Force is set, the files are listed and
+// removed only if the answer read from in is yes.
+func clean(opts CleanOptions, in io.Reader) error {
+	config, err := loadConfig(configFileOrDefault(opts.ConfigFile), os.Stdout)
+	if err != nil {
+		return fmt.Errorf("error loading config: %v", err)
+	}
+
+	var outputs []string
+	if config.Bundle {
+		outputs = append(outputs, config.OutputPath)
+	} else {
+		for _, group := range groupPackagesByOutput(config.Packages) {
+			outputs = append(outputs, groupOutputFiles(config, group, os.Stdout)...)
+		}
+	}
+	var files []string
+	listed := make(map[string]bool)
+	for _, output := range outputs {
+		if output == "" || listed[filepath.Clean(output)] {
+			continue
+		}
+		listed[filepath.Clean(output)] = true
+		if _, err := os.Stat(output); err == nil {
+			files = append(files, output)
+		}
+	}
+	if len(files) == 0 {
+		fmt.Println("No generated files to remove")
+		return nil
+	}
+
+	if !opts.Force {
+		fmt.Println("The following files will be removed:")
+		for _, file := range files {
+			fmt.Printf("  %s\n", file)
+		}
+		fmt.Print("Continue? [y/N] ")
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Println("Aborted")
+			return nil
+		}
+	}
+
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			return fmt.Errorf("error removing %s: %v", file, err)
+		}
+		fmt.Printf("Removed %s\n", file)
+	}
+	return nil
+}
+
+// loadConfig reads the configuration file, printing warnings about invalid values to w
+func loadConfig(filename string, w io.Writer) (*Config, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
@@ -126,14 +523,21 @@ func loadConfig(filename string) (*Config, error) {
 	if config.AuthTokenStorage != "localStorage" && config.AuthTokenStorage != "sessionStorage" {
 		config.AuthTokenStorage = "localStorage"
 	}
+	config.OnUnresolved = validOnUnresolved(config.OnUnresolved, w)
+	config.UintType = validUintType(config.UintType, w)
+	config.ArrayQueryFormat = validArrayQueryFormat(config.ArrayQueryFormat, w)
+	config.ResponseEnvelope = validResponseEnvelope(config.ResponseEnvelope, w)
+	config.FieldCasing = validFieldCasing(config.FieldCasing, w)
+	config.TypePrefix, config.TypeSuffix = validTypeAffixes(config.TypePrefix, config.TypeSuffix, w)
 
 	return &config, nil
 }
 
-func initConfig() error {
+// initConfig writes a configuration file named filename for the handlers found in the current directory
+func initConfig(filename string) error {
 	// Check if config file already exists
-	if _, err := os.Stat("go2type.yaml"); err == nil {
-		return fmt.Errorf("configuration file 'go2type.yaml' already exists. Please remove it or use a different name if you want to create a new configuration")
+	if _, err := os.Stat(filename); err == nil {
+		return fmt.Errorf("configuration file '%s' already exists. Please remove it or use a different name if you want to create a new configuration", filename)
 	}
 
 	config := Config{
@@ -215,11 +619,11 @@ func initConfig() error {
 		return fmt.Errorf("error marshaling config: %v", err)
 	}
 
-	if err := os.WriteFile("go2type.yaml", data, 0644); err != nil {
+	if err := os.WriteFile(filename, data, 0644); err != nil {
 		return fmt.Errorf("error writing config file: %v", err)
 	}
 
-	fmt.Println("Configuration file 'go2type.yaml' has been created.")
+	fmt.Printf("Configuration file '%s' has been created.\n", filename)
 	return nil
 }
 
@@ -301,250 +705,1974 @@ func findGoHandlers(root string) ([]PackageConfig, error) {
 	return goPackages, err
 }
 
-func generate(shouldFormat bool) error {
-	config, err := loadConfig("go2type.yaml")
+func generate(genOpts GenerateOptions) error {
+	// Warnings are printed with the errors, so --quiet still shows them
+	warnings := genOpts.errorOutput()
+	config, err := loadConfig(genOpts.configFile(), warnings)
 	if err != nil {
 		return fmt.Errorf("error loading config: %v", err)
 	}
 
-	for _, pkg := range config.Packages {
-		absPath, err := filepath.Abs(pkg.Path)
-		if err != nil {
-			fmt.Printf("Error resolving absolute path for %s: %v\n", pkg.Path, err)
-			continue
-		}
+	useHooks := config.Hooks == "true" || config.Hooks == "react-query" || config.Hooks == "swr"
+	useReactQuery := config.Hooks == "react-query"
+	useSWR := config.Hooks == "swr"
+	useAngular := config.Hooks == "angular"
 
-		pkgTypes, handlers, err := parsePackage(absPath, pkg.TypeMappings, config.UseDateObject)
-		if err != nil {
-			fmt.Printf("Error parsing package %s: %v\n", pkg.Path, err)
-			continue
-		}
+	authTokenStorage := validAuthTokenStorage(config.AuthTokenStorage, warnings)
 
-		useHooks := config.Hooks == "true" || config.Hooks == "react-query"
-		useReactQuery := config.Hooks == "react-query"
+	queryKeyStyle := "array"
+	if config.QueryKeyStyle == "object" {
+		queryKeyStyle = config.QueryKeyStyle
+	} else if config.QueryKeyStyle != "array" && config.QueryKeyStyle != "" {
+		fmt.Fprintf(warnings, "Warning: Unknown query key style %s. Using array instead.\n", config.QueryKeyStyle)
+	}
 
-		authTokenStorage := "localStorage"
-		if config.AuthTokenStorage == "sessionStorage" {
-			authTokenStorage = config.AuthTokenStorage
-		} else if config.AuthTokenStorage != "localStorage" && config.AuthTokenStorage != "" {
-			fmt.Printf("Warning: Unknown auth token storage type %s. Using localStorage instead.\n", config.AuthTokenStorage)
-		}
+	eol := "lf"
+	if config.EOL == "crlf" {
+		eol = config.EOL
+	} else if config.EOL != "lf" && config.EOL != "" {
+		fmt.Fprintf(warnings, "Warning: Unknown eol style %s. Using lf instead.\n", config.EOL)
+	}
 
-		opts := GenerateFileOptions{
-			Types:            pkgTypes,
-			Handlers:         handlers,
-			OutputFile:       pkg.OutputPath,
-			AuthToken:        config.AuthToken,
-			AuthTokenStorage: authTokenStorage,
-			PrettierPath:     config.PrettierPath,
-			UseHooks:         useHooks,
-			UseReactQuery:    useReactQuery,
-			ShouldFormat:     shouldFormat,
-			UseDateObject:    config.UseDateObject,
-		}
+	httpClient := "fetch"
+	if config.HTTPClient == "axios" {
+		httpClient = config.HTTPClient
+	} else if config.HTTPClient != "fetch" && config.HTTPClient != "" {
+		fmt.Fprintf(warnings, "Warning: Unknown http client %s. Using fetch instead.\n", config.HTTPClient)
+	}
 
-		if err := generateFile(opts); err != nil {
-			fmt.Printf("Error generating file for package %s: %v\n", pkg.Path, err)
-			continue
-		}
+	useInterfaces := config.TypeKeyword == "interface"
+	if config.TypeKeyword != "interface" && config.TypeKeyword != "type" && config.TypeKeyword != "" {
+		fmt.Fprintf(warnings, "Warning: Unknown type keyword %s. Using type instead.\n", config.TypeKeyword)
+	}
 
-		fmt.Printf("Generated file for package %s at %s\n", pkg.Path, pkg.OutputPath)
+	enumStyle := "union"
+	if config.EnumStyle == "const-array" || config.EnumStyle == "enum" {
+		enumStyle = config.EnumStyle
+	} else if config.EnumStyle != "union" && config.EnumStyle != "" {
+		fmt.Fprintf(warnings, "Warning: Unknown enum style %s. Using union instead.\n", config.EnumStyle)
 	}
 
-	return nil
-}
+	useBuilder := config.ClientStyle == "builder"
+	if config.ClientStyle != "builder" && config.ClientStyle != "functions" && config.ClientStyle != "" {
+		fmt.Fprintf(warnings, "Warning: Unknown client style %s. Using functions instead.\n", config.ClientStyle)
+	}
+	if useBuilder && useAngular {
+		fmt.Fprintln(warnings, "Warning: client_style builder isn't supported with angular hooks. No request builders will be generated.")
+		useBuilder = false
+	}
 
-type TemplatePiece struct {
-	Name   string
-	Tmpl   string
-	Render bool
-}
+	validateResponses := config.ValidateResponses
+	if validateResponses && !config.EmitGuards {
+		fmt.Fprintln(warnings, "Warning: validate_responses requires emit_guards. Responses won't be validated.")
+		validateResponses = false
+	}
+	if config.BooleanPrefix && useAngular {
+		fmt.Fprintln(warnings, "Warning: boolean_prefix isn't supported with angular hooks. Boolean fields won't be prefixed.")
+		config.BooleanPrefix = false
+	}
+	if config.FieldCasing != "" && useAngular {
+		fmt.Fprintln(warnings, "Warning: field_casing isn't supported with angular hooks. Field names won't be changed.")
+		config.FieldCasing = ""
+	}
+	maxResponseBytes := config.MaxResponseBytes
+	if maxResponseBytes < 0 {
+		fmt.Fprintf(warnings, "Warning: max_response_bytes %d is negative. Response sizes won't be limited.\n", maxResponseBytes)
+		maxResponseBytes = 0
+	} else if maxResponseBytes > 0 && useAngular {
+		fmt.Fprintln(warnings, "Warning: max_response_bytes isn't supported with angular hooks. Response sizes won't be limited.")
+		maxResponseBytes = 0
+	}
 
-// GenerateFileOptions contains all the options for generating a file
-type GenerateFileOptions struct {
-	Types            []TypeInfo
-	Handlers         []HandlerInfo
-	OutputFile       string
-	AuthToken        string
-	AuthTokenStorage string
-	PrettierPath     string
-	UseHooks         bool
-	UseReactQuery    bool
-	ShouldFormat     bool
-	UseDateObject    bool
-}
+	// The envelope is named like every other type, so its pagination helpers are still found
+	paginationEnvelope := config.PaginationEnvelope
+	if paginationEnvelope != "" {
+		paginationEnvelope = config.TypePrefix + paginationEnvelope + config.TypeSuffix
+	}
 
-func generateFile(opts GenerateFileOptions) error {
-	dir := filepath.Dir(opts.OutputFile)
-	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
-		return fmt.Errorf("error creating directory: %v", err)
+	baseOpts := GenerateFileOptions{
+		AuthToken:          config.AuthToken,
+		AuthTokenStorage:   authTokenStorage,
+		PrettierPath:       config.PrettierPath,
+		UseHooks:           useHooks,
+		UseReactQuery:      useReactQuery,
+		UseSWR:             useSWR,
+		UseAngular:         useAngular,
+		QueryKeyStyle:      queryKeyStyle,
+		ShouldFormat:       genOpts.ShouldFormat,
+		UseDateObject:      config.UseDateObject,
+		ZeroTimeAsNull:     config.ZeroTimeAsNull,
+		DedupeRequests:     config.DedupeRequests,
+		EOL:                eol,
+		APIConfig:          config.APIConfig,
+		HTTPClient:         httpClient,
+		BrandIDs:           config.usesBrand(),
+		EmitGuards:         config.EmitGuards,
+		ValidateResponses:  validateResponses,
+		BooleanPrefix:      config.BooleanPrefix,
+		DefaultExport:      config.DefaultExport,
+		UseBuilder:         useBuilder,
+		OmitSemicolons:     config.Semicolons != nil && !*config.Semicolons,
+		BaseURL:            validBaseURL(config.BaseURL, warnings),
+		PaginationEnvelope: paginationEnvelope,
+		UseInterfaces:      useInterfaces,
+		EnumStyle:          enumStyle,
+		EmitMocks:          config.EmitMocks,
+		MaxResponseBytes:   maxResponseBytes,
+		EmitMeta:           config.EmitMeta,
+		EmitPaths:          config.EmitPaths,
+		ArrayQueryFormat:   config.ArrayQueryFormat,
 	}
 
-	funcMap := template.FuncMap{
-		"last": func(x interface{}) interface{} {
-			v := reflect.ValueOf(x)
-			return v.Index(v.Len() - 1).Interface()
-		},
-		"sub": func(a, b int) int {
-			return a - b
-		},
-		"firstWord": func(s string) string {
-			return strings.Split(s, " ")[0]
-		},
-		"inputHeaders": func(headers []HeaderInfo) []HeaderInfo {
-			var result []HeaderInfo
-			for _, h := range headers {
-				if h.Source == "input" {
-					result = append(result, h)
-				}
+	if config.Bundle {
+		if config.DefaultExport {
+			fmt.Fprintln(warnings, "Warning: default_export isn't supported with bundle. No default export will be emitted.")
+		}
+		for _, pkg := range config.Packages {
+			if pkg.Split != "" {
+				fmt.Fprintln(warnings, "Warning: split isn't supported with bundle. The bundle will be a single file.")
+				break
 			}
-			return result
-		},
+		}
+		if config.EmitMocks {
+			fmt.Fprintln(warnings, "Warning: emit_mocks isn't supported with bundle. No mocks will be written.")
+		}
+		return generateBundle(config, genOpts, baseOpts)
 	}
 
-	file, err := os.Create(opts.OutputFile)
-	if err != nil {
-		return fmt.Errorf("error creating file: %v", err)
+	// Packages sharing an output path are generated together, so later ones don't truncate earlier ones
+	var groups [][]PackageConfig
+	for _, group := range groupPackagesByOutput(config.Packages) {
+		if len(genOpts.Packages) == 0 || groupHasPackage(group, genOpts.Packages) {
+			groups = append(groups, group)
+		}
 	}
-	defer func() {
-		err := file.Close()
-		if err != nil {
-			log.Printf("Error closing file: %v", err)
+	if config.EmitMocks {
+		mockDirs := make(map[string]string)
+		for _, group := range groups {
+			dir := filepath.Dir(group[0].OutputPath)
+			if other, ok := mockDirs[dir]; ok {
+				fmt.Fprintf(warnings, "Warning: %s and %s are in the same directory, so their mocks overwrite each other\n", other, group[0].OutputPath)
+				continue
+			}
+			mockDirs[dir] = group[0].OutputPath
+		}
+	}
+
+	// Groups are generated concurrently, each writing its messages to its own buffer, which is
+	// printed once it and every group before it are done so the output stays in config order
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	outputs := make([]bytes.Buffer, len(groups))
+	errOutputs := make([]bytes.Buffer, len(groups))
+	changes := make([]bool, len(groups))
+	errs := make([]error, len(groups))
+	done := make([]chan struct{}, len(groups))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	go func() {
+		sem := make(chan struct{}, concurrency)
+		for i, group := range groups {
+			sem <- struct{}{}
+			go func() {
+				defer func() { <-sem }()
+				defer close(done[i])
+				changes[i], errs[i] = generateGroup(config, genOpts, baseOpts, group, &outputs[i], &errOutputs[i])
+			}()
 		}
 	}()
 
-	data := TemplateData{
-		Version:          Version,
-		Timestamp:        time.Now().Format(time.RFC3339),
-		Types:            opts.Types,
-		Handlers:         opts.Handlers,
-		AuthToken:        opts.AuthToken,
-		AuthTokenStorage: opts.AuthTokenStorage,
-		UseHooks:         opts.UseHooks,
-		UseReactQuery:    opts.UseReactQuery,
-		UseDateObject:    opts.UseDateObject,
+	changed := false
+	for i := range groups {
+		<-done[i]
+		fmt.Fprint(genOpts.infoOutput(), outputs[i].String())
+		fmt.Fprint(genOpts.errorOutput(), errOutputs[i].String())
+		changed = changed || changes[i]
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
 	}
 
-	// Create a new template and add the helper functions
-	tmpl := template.New("typescript").Funcs(funcMap)
+	if genOpts.DryRun {
+		return dryRunResult(genOpts, changed)
+	}
+	return nil
+}
 
-	// Define the order of template pieces
-	templatePieces := []TemplatePiece{
-		{Name: "headerTemplate", Tmpl: headerTemplate, Render: true},
-		{Name: "typesTemplate", Tmpl: typesTemplate, Render: true},
-		{Name: "queryFunctionTemplate", Tmpl: queryFunctionTemplate, Render: true},
-		{Name: "reactQueryHookTemplate", Tmpl: reactQueryHookTemplate, Render: opts.UseReactQuery},
-		{Name: "reactHookTemplate", Tmpl: reactHookTemplate, Render: opts.UseHooks && !opts.UseReactQuery},
-		{Name: "queryDictionaryTemplate", Tmpl: queryDictionaryTemplate, Render: true},
+// generateGroup generates the output file of a group of packages sharing an output path, writing
+// its messages to out and its errors to errOut. It reports whether a dry run found the output would
+// change, and returns an error when --strict rejects it or a dry run fails.
+func generateGroup(config *Config, genOpts GenerateOptions, baseOpts GenerateFileOptions, group []PackageConfig, out, errOut io.Writer) (bool, error) {
+	outputPath := group[0].OutputPath
+	var pkgPaths []string
+	for _, pkg := range group {
+		pkgPaths = append(pkgPaths, pkg.Path)
 	}
+	pkgNames := strings.Join(pkgPaths, ", ")
 
-	// Parse and execute each template piece
-	for _, piece := range templatePieces {
-		if !piece.Render {
-			continue
-		}
-		t, err := tmpl.Parse(piece.Tmpl)
+	if genOpts.SkipUnchanged {
+		upToDate, err := packagesUpToDate(group, outputPath, genOpts.configFile())
 		if err != nil {
-			return fmt.Errorf("error parsing template piece %s: %v", piece.Name, err)
+			fmt.Fprintf(errOut, "Warning: Could not check modification times for %s: %v\n", pkgNames, err)
+		} else if upToDate {
+			fmt.Fprintf(out, "Skipping package %s: %s is up to date\n", pkgNames, outputPath)
+			return false, nil
 		}
+	}
 
-		if err := t.Execute(file, data); err != nil {
-			log.Printf("Error executing template: %s: %v", piece.Name, err)
-			return fmt.Errorf("error executing template piece: %s: %v", piece.Name, err)
+	var allTypes []TypeInfo
+	var allHandlers []HandlerInfo
+	for _, pkg := range group {
+		pkgTypes, handlers, err := parseGeneratedPackage(config, pkg, errOut)
+		if err != nil {
+			// A dry run can't tell whether the output is up to date, so it fails
+			if genOpts.DryRun {
+				return false, fmt.Errorf("error parsing package %s: %v", pkg.Path, err)
+			}
+			// Don't overwrite the output with only some of its packages
+			fmt.Fprintf(errOut, "Error parsing package %s: %v\n", pkg.Path, err)
+			return false, nil
+		}
+		allTypes, err = mergeTypes(allTypes, pkgTypes, outputPath)
+		if err != nil {
+			return false, err
 		}
+		allHandlers = mergeHandlers(allHandlers, handlers, outputPath, errOut)
+	}
+	affixTypeNames(allTypes, allHandlers, config.TypePrefix, config.TypeSuffix)
+	if err := reportRouteCollisions(genOpts, allHandlers, out, errOut); err != nil {
+		return false, fmt.Errorf("%s: %v", outputPath, err)
 	}
 
-	if opts.ShouldFormat {
-		// Format the generated code
-		if err := formatCode(opts.OutputFile, opts.PrettierPath); err != nil {
-			fmt.Printf("Warning: Failed to format %s: %v\n", opts.OutputFile, err)
+	opts := baseOpts
+	opts.AuthToken, opts.AuthTokenStorage = groupAuthToken(group, baseOpts.AuthToken, baseOpts.AuthTokenStorage, errOut)
+	opts.Split = groupSplit(group, errOut)
+	opts.BaseURL = groupBaseURL(group, baseOpts.BaseURL, errOut)
+	opts.Output, opts.Warnings = out, errOut
+	opts.Types = allTypes
+	opts.Handlers = allHandlers
+	opts.OutputFile = outputPath
+
+	if genOpts.DryRun {
+		diff, err := previewFile(opts)
+		if err != nil {
+			return false, fmt.Errorf("error generating file for package %s: %v", pkgNames, err)
 		}
+		fmt.Fprint(out, diff)
+		return diff != "", nil
 	}
 
-	return nil
-}
-
-type TypeRegistry struct {
-	Types map[string]TypeInfo
-}
+	written, err := generateFile(opts)
+	if err != nil {
+		fmt.Fprintf(errOut, "Error generating file for package %s: %v\n", pkgNames, err)
+		return false, nil
+	}
 
-func (tr *TypeRegistry) AddType(t TypeInfo) {
-	tr.Types[t.Name] = t
+	if !written {
+		fmt.Fprintf(out, "Package %s: %s unchanged\n", pkgNames, outputPath)
+		return false, nil
+	}
+	fmt.Fprintf(out, "Generated file for package %s at %s\n", pkgNames, outputPath)
+	return false, nil
 }
 
-func (tr *TypeRegistry) GetType(name string) (TypeInfo, bool) {
-	t, ok := tr.Types[name]
-	return t, ok
+// dryRunResult prints "no changes" when a dry run found no output that would change, and fails in
+// check mode when one would
+func dryRunResult(genOpts GenerateOptions, changed bool) error {
+	if !changed {
+		fmt.Fprintln(genOpts.infoOutput(), "no changes")
+		return nil
+	}
+	if genOpts.Check {
+		return fmt.Errorf("generated files are out of date")
+	}
+	return nil
 }
 
-func parsePackage(packagePath string, customTypeMappings map[string]string, useDateObject bool) ([]TypeInfo, []HandlerInfo, error) {
-	// Merge default and custom type mappings
-	typeMappings := make(map[string]string)
-	for k, v := range defaultTypeMappings {
-		typeMappings[k] = v
+// generateStdin generates a client for the Go source read from in and writes it to genOpts.Stdout,
+// bypassing go2type.yaml. The client uses fetch without hooks, reading the auth token from localStorage.
+func generateStdin(genOpts GenerateOptions, in io.Reader) error {
+	// The TypeScript is written to stdout, so messages are printed to stderr to keep it clean
+	out, messages := stdoutIfNil(genOpts.Stdout), stderrIfNil(genOpts.Stderr)
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("error reading stdin: %v", err)
 	}
-	for k, v := range customTypeMappings {
-		typeMappings[k] = v
+	types, handlers, err := parseSource(src, ParseOptions{Warnings: messages})
+	if err != nil {
+		return err
 	}
-	if useDateObject {
-		typeMappings["time.Time"] = "Date"
-		typeMappings["pgtype.Timestamptz"] = "Date"
-	} else {
-		typeMappings["time.Time"] = "string /* date-time */"
+
+	tmpDir, err := os.MkdirTemp("", "go2type-")
+	if err != nil {
+		return fmt.Errorf("error creating temporary directory: %v", err)
 	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Printf("Error removing temporary directory: %v", err)
+		}
+	}()
 
-	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, packagePath, nil, parser.ParseComments)
+	opts := GenerateFileOptions{
+		Types:            types,
+		Handlers:         handlers,
+		OutputFile:       filepath.Join(tmpDir, "stdin.generated.ts"),
+		AuthToken:        "session_token",
+		AuthTokenStorage: "localStorage",
+		QueryKeyStyle:    "array",
+		ShouldFormat:     genOpts.ShouldFormat,
+		EOL:              "lf",
+		HTTPClient:       "fetch",
+		FormatConfigDir:  ".",
+		Output:           messages,
+		Warnings:         messages,
+	}
+	if _, err := generateFile(opts); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(opts.OutputFile)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error parsing directory: %v", err)
+		return fmt.Errorf("error reading generated file: %v", err)
 	}
+	if _, err := out.Write(content); err != nil {
+		return fmt.Errorf("error writing output: %v", err)
+	}
+	return nil
+}
 
-	registry := &TypeRegistry{
-		Types: make(map[string]TypeInfo),
+// generateBundle generates every configured package into the single output_path, wrapping each
+// package in its own namespace. Packages whose directories share a name are merged into one namespace.
+func generateBundle(config *Config, genOpts GenerateOptions, baseOpts GenerateFileOptions) error {
+	if config.OutputPath == "" {
+		return fmt.Errorf("bundle requires an output_path")
 	}
-	var handlers []HandlerInfo
-	importMap := make(map[string]string)
 
-	// Get the module name and path
-	moduleName, modulePath, err := getModuleInfo(packagePath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("error getting module info: %v", err)
+	if genOpts.SkipUnchanged {
+		upToDate, err := packagesUpToDate(config.Packages, config.OutputPath, genOpts.configFile())
+		if err != nil {
+			fmt.Fprintf(genOpts.errorOutput(), "Warning: Could not check modification times for %s: %v\n", config.OutputPath, err)
+		} else if upToDate {
+			fmt.Fprintf(genOpts.infoOutput(), "Skipping bundle: %s is up to date\n", config.OutputPath)
+			return nil
+		}
 	}
 
-	for _, pkg := range pkgs {
-		for _, file := range pkg.Files {
-			// Parse imports
-			for _, imp := range file.Imports {
-				if imp.Name != nil {
-					importMap[imp.Name.Name] = strings.Trim(imp.Path.Value, "\"")
-				} else {
-					parts := strings.Split(strings.Trim(imp.Path.Value, "\""), "/")
-					importMap[parts[len(parts)-1]] = strings.Trim(imp.Path.Value, "\"")
-				}
+	var namespaces []NamespaceInfo
+	index := make(map[string]int)
+	for _, pkg := range config.Packages {
+		// Don't overwrite the bundle with only some of its packages
+		pkgTypes, handlers, err := parseGeneratedPackage(config, pkg, genOpts.errorOutput())
+		if err != nil {
+			return fmt.Errorf("error parsing package %s: %v", pkg.Path, err)
+		}
+		name := namespaceName(pkg.Path)
+		if i, ok := index[name]; ok {
+			namespaces[i].Types, err = mergeTypes(namespaces[i].Types, pkgTypes, config.OutputPath)
+			if err != nil {
+				return err
 			}
-
-			ast.Inspect(file, func(n ast.Node) bool {
-				switch node := n.(type) {
-				case *ast.TypeSpec:
-					if structType, ok := node.Type.(*ast.StructType); ok {
-						typeInfo := parseType(node.Name.Name, structType, typeMappings)
-						registry.AddType(typeInfo)
-					}
-				case *ast.FuncDecl:
-					if node.Doc != nil {
-						if handler := parseHandlerComments(node); handler != nil {
-							handlers = append(handlers, *handler)
-						}
-					}
-				}
-				return true
-			})
+			namespaces[i].Handlers = mergeHandlers(namespaces[i].Handlers, handlers, config.OutputPath, genOpts.errorOutput())
+			continue
 		}
+		index[name] = len(namespaces)
+		namespaces = append(namespaces, NamespaceInfo{Name: name, Types: pkgTypes, Handlers: handlers})
 	}
+	qualifyNamespaceTypes(namespaces)
+	affixNamespaceTypes(namespaces, config.TypePrefix, config.TypeSuffix)
 
-	// Resolve nested types and external package types
-	for _, t := range registry.Types {
-		resolveNestedAndExternalTypes(&t, registry, packagePath, modulePath, typeMappings, importMap, moduleName)
+	var allHandlers []HandlerInfo
+	for _, ns := range namespaces {
+		allHandlers = append(allHandlers, ns.Handlers...)
+	}
+	if err := reportRouteCollisions(genOpts, allHandlers, genOpts.infoOutput(), genOpts.errorOutput()); err != nil {
+		return fmt.Errorf("%s: %v", config.OutputPath, err)
+	}
+
+	opts := baseOpts
+	opts.OutputFile = config.OutputPath
+	opts.Namespaces = namespaces
+	if genOpts.DryRun {
+		diff, err := previewFile(opts)
+		if err != nil {
+			return fmt.Errorf("error generating bundle: %v", err)
+		}
+		fmt.Fprint(genOpts.infoOutput(), diff)
+		return dryRunResult(genOpts, diff != "")
+	}
+	written, err := generateFile(opts)
+	if err != nil {
+		return fmt.Errorf("error generating bundle: %v", err)
+	}
+
+	if !written {
+		fmt.Fprintf(genOpts.infoOutput(), "Bundle: %s unchanged\n", config.OutputPath)
+		return nil
+	}
+	fmt.Fprintf(genOpts.infoOutput(), "Generated bundle at %s\n", config.OutputPath)
+	return nil
+}
+
+// namespaceName returns the TypeScript namespace for a package path: its last element, title cased
+// with anything that isn't valid in an identifier removed, so ./api/users becomes Users
+func namespaceName(pkgPath string) string {
+	base := path.Base(filepath.ToSlash(filepath.Clean(pkgPath)))
+	words := strings.FieldsFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	caser := cases.Title(language.Und, cases.NoLower)
+	var name strings.Builder
+	for _, word := range words {
+		name.WriteString(caser.String(word))
+	}
+	if name.Len() == 0 || unicode.IsDigit(rune(name.String()[0])) {
+		return "_" + name.String()
+	}
+	return name.String()
+}
+
+// qualifyNamespaceTypes replaces the copies of types from other bundled packages, which are named
+// after their package like ModelsUser, with references into that package's namespace like Models.User
+func qualifyNamespaceTypes(namespaces []NamespaceInfo) {
+	declared := make(map[string]bool)
+	for _, ns := range namespaces {
+		for _, t := range ns.Types {
+			declared[ns.Name+"."+strings.Split(t.Name, " ")[0]] = true
+		}
+	}
+
+	for i := range namespaces {
+		ns := &namespaces[i]
+		qualified := make(map[string]string)
+		var kept []TypeInfo
+		for _, t := range ns.Types {
+			name := strings.Split(t.Name, " ")[0]
+			for _, other := range namespaces {
+				ref := other.Name + "." + strings.TrimPrefix(name, other.Name)
+				if other.Name != ns.Name && strings.HasPrefix(name, other.Name) && name != other.Name && declared[ref] {
+					qualified[name] = ref
+					break
+				}
+			}
+			if _, ok := qualified[name]; !ok {
+				kept = append(kept, t)
+			}
+		}
+		renameTypeReferences(kept, ns.Handlers, qualified)
+		ns.Types = kept
+	}
+}
+
+// renameTypeReferences replaces references to the types in renames, keyed by their current name,
+// in the fields of types and the inputs, outputs and query parameters of handlers
+func renameTypeReferences(types []TypeInfo, handlers []HandlerInfo, renames map[string]string) {
+	if len(renames) == 0 {
+		return
+	}
+	rename := func(typeName string) string {
+		for name, replacement := range renames {
+			typeName = replaceTypeName(typeName, name, replacement)
+		}
+		return typeName
+	}
+	for t := range types {
+		for f := range types[t].Fields {
+			types[t].Fields[f].Type = rename(types[t].Fields[f].Type)
+		}
+	}
+	for h := range handlers {
+		handlers[h].InputType = rename(handlers[h].InputType)
+		handlers[h].OutputType = rename(handlers[h].OutputType)
+		for o := range handlers[h].Outputs {
+			handlers[h].Outputs[o].Type = rename(handlers[h].Outputs[o].Type)
+		}
+		for q := range handlers[h].QueryParams {
+			handlers[h].QueryParams[q].Type = rename(handlers[h].QueryParams[q].Type)
+		}
+	}
+}
+
+// parseConfiguredPackage parses a package from the configuration file, including its router file
+// and, for recursive packages, its sub-packages. Warnings are printed to w.
+func parseConfiguredPackage(config *Config, pkg PackageConfig, w io.Writer) ([]TypeInfo, []HandlerInfo, error) {
+	dirs, err := packageDirs(pkg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error resolving package path: %v", err)
+	}
+
+	var routes map[string]RouteInfo
+	if pkg.RouterFile != "" {
+		routes, err = parseRouterFile(pkg.RouterFile, pkg.RouterPatterns)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing router file %s: %v", pkg.RouterFile, err)
+		}
+	}
+
+	// Branded mappings are parsed as the type they brand, and branded by parseGeneratedPackage
+	typeMappings := unbrandedMappings(pkg.TypeMappings, w)
+	if config.BrandIDs || config.BrandedIDs {
+		unbranded := typeMappings
+		typeMappings = make(map[string]string)
+		for k, v := range idTypeMappings(config.brandedIDTypes(), unbranded) {
+			typeMappings[k] = v
+		}
+		for k, v := range unbranded {
+			typeMappings[k] = v
+		}
+	}
+
+	parseOpts := ParseOptions{
+		TypeMappings:        typeMappings,
+		UseDateObject:       config.UseDateObject,
+		ZeroTimeAsNull:      config.ZeroTimeAsNull,
+		Routes:              routes,
+		InterfaceFallback:   pkg.InterfaceFallback,
+		FullExportPackages:  config.FullExportPackages,
+		VersionPathTemplate: config.VersionPathTemplate,
+		PaginationEnvelope:  config.PaginationEnvelope,
+		ResponseEnvelope:    config.ResponseEnvelope,
+		UseUnknownForAny:    config.UseUnknownForAny,
+		OnUnresolved:        config.OnUnresolved,
+		FieldTag:            config.FieldTag,
+		Warnings:            w,
+	}
+
+	var types []TypeInfo
+	var handlers []HandlerInfo
+	if !pkg.Recursive {
+		types, handlers, err = parsePackage(dirs[0], parseOpts)
+	} else {
+		types, handlers, err = parsePackageTree(dirs, parseOpts)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range handlers {
+		handlers[i].Path = prefixPath(pkg.PathPrefix, handlers[i].Path)
+	}
+	return types, handlers, nil
+}
+
+// prefixPath prepends the path_prefix of a package, such as the path its router is mounted at, to
+// a handler path, e.g. api/ and /users to /api/users
+func prefixPath(prefix, handlerPath string) string {
+	prefix = path.Clean("/" + prefix)
+	if prefix == "/" {
+		return handlerPath
+	}
+	if strings.Trim(handlerPath, "/") == "" {
+		return prefix
+	}
+	return prefix + "/" + strings.TrimLeft(handlerPath, "/")
+}
+
+// packageDirs returns the directory of a configured package, followed by the directories of its
+// sub-packages if it's recursive
+func packageDirs(pkg PackageConfig) ([]string, error) {
+	root, err := resolvePackageDir(pkg.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !pkg.Recursive {
+		return []string{root}, nil
+	}
+
+	dirs := []string{root}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || path == root {
+			return nil
+		}
+		// Skip the directories the go tool ignores
+		name := d.Name()
+		if name == "vendor" || name == "testdata" || name == "node_modules" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			return filepath.SkipDir
+		}
+		if files, _ := filepath.Glob(filepath.Join(path, "*.go")); len(files) > 0 {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+// parsePackageTree parses a package and its sub-packages and merges their types and handlers. A type
+// one sub-package uses from another is copied under a name prefixed with its package, like ModelsUser.
+// When the other sub-package emits the type itself, the copy is dropped in favour of its declaration.
+func parsePackageTree(dirs []string, opts ParseOptions) ([]TypeInfo, []HandlerInfo, error) {
+	var allTypes []TypeInfo
+	var allHandlers []HandlerInfo
+	declared := make(map[string]map[string]bool)
+	declaredCount := make(map[string]int)
+	for _, dir := range dirs {
+		pkgTypes, handlers, err := parsePackage(dir, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing %s: %v", dir, err)
+		}
+		pkgName := filepath.Base(dir)
+		declared[pkgName] = make(map[string]bool)
+		for _, t := range pkgTypes {
+			name := strings.Split(t.Name, " ")[0]
+			declared[pkgName][name] = true
+			declaredCount[name]++
+		}
+		allTypes, err = mergeTypes(allTypes, pkgTypes, dirs[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		allHandlers = mergeHandlers(allHandlers, handlers, dirs[0], stdoutIfNil(opts.Warnings))
+	}
+
+	renames := make(map[string]string)
+	var kept []TypeInfo
+	for _, t := range allTypes {
+		name := strings.Split(t.Name, " ")[0]
+		for pkgName, names := range declared {
+			prefix := cases.Title(language.Und, cases.NoLower).String(pkgName)
+			original := strings.TrimPrefix(name, prefix)
+			// Keep the copy when another sub-package declares a type of the same name
+			if original != name && names[original] && declaredCount[original] == 1 {
+				renames[name] = original
+				break
+			}
+		}
+		if _, ok := renames[name]; !ok {
+			kept = append(kept, t)
+		}
+	}
+	renameTypeReferences(kept, allHandlers, renames)
+	return kept, allHandlers, nil
+}
+
+// parseGeneratedPackage parses a package for a TypeScript client, applying the options that only
+// affect the generated TypeScript, such as brand_ids and uint_type
+func parseGeneratedPackage(config *Config, pkg PackageConfig, w io.Writer) ([]TypeInfo, []HandlerInfo, error) {
+	pkgTypes, handlers, err := parseConfiguredPackage(config, pkg, w)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config.BrandIDs {
+		pkgTypes = brandIDFields(pkgTypes, idTypeMappings(config.brandedIDTypes(), unbrandedMappings(pkg.TypeMappings, w)))
+	}
+	var brandedIDTypes []string
+	if config.BrandedIDs {
+		brandedIDTypes = config.brandedIDTypes()
+	}
+	pkgTypes = brandMappedFields(pkgTypes, brandedMappings(pkg.TypeMappings, brandedIDTypes, w))
+	// Fields are cased before they're prefixed, so the prefix follows the new casing
+	pkgTypes = caseFields(pkgTypes, config.FieldCasing)
+	if config.BooleanPrefix {
+		pkgTypes = prefixBooleanFields(pkgTypes)
+	}
+	pkgTypes = uintFields(pkgTypes, config.UintType, pkg.TypeMappings)
+	return pkgTypes, handlers, nil
+}
+
+// brandedIDTypes returns the Go types branded with brand_ids and branded_ids
+func (c *Config) brandedIDTypes() []string {
+	if len(c.IDTypes) > 0 {
+		return c.IDTypes
+	}
+	return defaultIDTypes
+}
+
+// usesBrand reports whether the generated types use the Brand utility type, which declares the
+// branded ID types of brand_ids and the branded mappings of branded_ids and brand: hints
+func (c *Config) usesBrand() bool {
+	if c.BrandIDs || c.BrandedIDs {
+		return true
+	}
+	for _, pkg := range c.Packages {
+		for _, tsType := range pkg.TypeMappings {
+			if _, brand := splitBrandHint(tsType, io.Discard); brand != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolvePackageDir returns the directory of a configured package path. Paths naming a local
+// directory are used as-is, and anything else that looks like an import path, such as
+// github.com/org/repo/api, is looked up with packages.Load so packages in the module cache work too.
+func resolvePackageDir(pkgPath string) (string, error) {
+	if info, err := os.Stat(pkgPath); err == nil && info.IsDir() {
+		return filepath.Abs(pkgPath)
+	}
+	if filepath.IsAbs(pkgPath) || strings.HasPrefix(pkgPath, ".") || !strings.ContainsAny(pkgPath, "./") {
+		return filepath.Abs(pkgPath)
+	}
+
+	cfg := &packages.Config{Mode: packages.NeedName | packages.NeedFiles}
+	pkgs, err := packages.Load(cfg, pkgPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load package %s: %v", pkgPath, err)
+	}
+	if len(pkgs) == 0 {
+		return "", fmt.Errorf("no packages found for %s", pkgPath)
+	}
+	if len(pkgs[0].Errors) > 0 {
+		return "", fmt.Errorf("failed to load package %s: %v", pkgPath, pkgs[0].Errors[0])
+	}
+	if len(pkgs[0].GoFiles) == 0 {
+		return "", fmt.Errorf("no Go files found for package %s", pkgPath)
+	}
+	return filepath.Dir(pkgs[0].GoFiles[0]), nil
+}
+
+// groupPackagesByOutput groups packages by output path, in the order each output path first appears
+func groupPackagesByOutput(pkgs []PackageConfig) [][]PackageConfig {
+	var groups [][]PackageConfig
+	index := make(map[string]int)
+	for _, pkg := range pkgs {
+		key := filepath.Clean(pkg.OutputPath)
+		if i, ok := index[key]; ok {
+			groups[i] = append(groups[i], pkg)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, []PackageConfig{pkg})
+	}
+	return groups
+}
+
+// validAuthTokenStorage returns storage if it's a known auth token storage, and localStorage
+// otherwise, warning on w about unknown ones
+func validAuthTokenStorage(storage string, w io.Writer) string {
+	if storage == "sessionStorage" {
+		return storage
+	} else if storage != "localStorage" && storage != "" {
+		fmt.Fprintf(w, "Warning: Unknown auth token storage type %s. Using localStorage instead.\n", storage)
+	}
+	return "localStorage"
+}
+
+// groupAuthToken returns the auth token and storage for an output group. The first package that sets
+// auth_token or auth_token_storage overrides the global value, so each output can use its own token.
+func groupAuthToken(group []PackageConfig, authToken, authTokenStorage string, w io.Writer) (string, string) {
+	tokenSet, storageSet := false, false
+	for _, pkg := range group {
+		if pkg.AuthToken != "" && !tokenSet {
+			authToken, tokenSet = pkg.AuthToken, true
+		}
+		if pkg.AuthTokenStorage != "" && !storageSet {
+			authTokenStorage, storageSet = validAuthTokenStorage(pkg.AuthTokenStorage, w), true
+		}
+	}
+	return authToken, authTokenStorage
+}
+
+// baseURLEnvRegex matches a base URL read from an environment variable, e.g. env:API_BASE_URL
+var baseURLEnvRegex = regexp.MustCompile(`^env:([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// validBaseURL returns baseURL if it's a URL or path, or an env: prefixed environment variable name,
+// and "" otherwise, warning on w about invalid environment variable names
+func validBaseURL(baseURL string, w io.Writer) string {
+	if strings.HasPrefix(baseURL, "env:") && !baseURLEnvRegex.MatchString(baseURL) {
+		fmt.Fprintf(w, "Warning: Invalid base URL environment variable %s. Paths won't be prefixed.\n", baseURL)
+		return ""
+	}
+	return baseURL
+}
+
+// baseURLEnv returns the name of the environment variable an env: prefixed base URL is read from,
+// or "" for a base URL that's known when generating
+func baseURLEnv(baseURL string) string {
+	if m := baseURLEnvRegex.FindStringSubmatch(baseURL); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// groupBaseURL returns the base URL of an output group. The first package that sets base_url
+// overrides the global value.
+func groupBaseURL(group []PackageConfig, baseURL string, w io.Writer) string {
+	for _, pkg := range group {
+		if pkg.BaseURL != "" {
+			return validBaseURL(pkg.BaseURL, w)
+		}
+	}
+	return baseURL
+}
+
+// requestPath renders the TypeScript expression of a handler's request path prefixed with the base
+// URL. A base URL read from the environment is prefixed at runtime by the baseURL constant.
+func requestPath(baseURL, path string) string {
+	if baseURLEnv(baseURL) != "" {
+		return "baseURL + '/" + strings.TrimLeft(path, "/") + "'"
+	}
+	if baseURL == "" {
+		return "'" + path + "'"
+	}
+	return "'" + joinURL(baseURL, path) + "'"
+}
+
+// joinURL joins a base URL and a path with a single slash, e.g. /api/v1/ and /users to /api/v1/users
+func joinURL(baseURL, path string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// groupHasPackage reports whether one of the packages in group has one of the given paths
+func groupHasPackage(group []PackageConfig, pkgPaths []string) bool {
+	for _, pkg := range group {
+		for _, pkgPath := range pkgPaths {
+			if pkg.Path == pkgPath {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// packagesUpToDate reports whether outputPath is up to date with every package in pkgs
+func packagesUpToDate(pkgs []PackageConfig, outputPath string, extraInputs ...string) (bool, error) {
+	for _, pkg := range pkgs {
+		dirs, err := packageDirs(pkg)
+		if err != nil {
+			return false, err
+		}
+		for _, dir := range dirs {
+			upToDate, err := isUpToDate(dir, outputPath, extraInputs...)
+			if err != nil || !upToDate {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// mergeTypes appends the types in incoming that aren't already in types. Types are matched by
+// name, and two packages declaring different types with the same name is an error, since only one
+// of them could be emitted under it.
+func mergeTypes(types, incoming []TypeInfo, outputPath string) ([]TypeInfo, error) {
+	for _, t := range incoming {
+		duplicate := false
+		for _, existing := range types {
+			if existing.Name != t.Name {
+				continue
+			}
+			duplicate = true
+			if !reflect.DeepEqual(existing.Fields, t.Fields) {
+				return nil, fmt.Errorf("type %s is declared differently by packages writing to %s", t.Name, outputPath)
+			}
+			break
+		}
+		if !duplicate {
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+// mergeHandlers appends the handlers in incoming, skipping any whose name is already taken
+func mergeHandlers(handlers, incoming []HandlerInfo, outputPath string, w io.Writer) []HandlerInfo {
+	for _, h := range incoming {
+		duplicate := false
+		for _, existing := range handlers {
+			if existing.Name == h.Name {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			fmt.Fprintf(w, "Warning: Handler %s is declared by more than one package writing to %s. Using the first declaration.\n", h.Name, outputPath)
+			continue
+		}
+		handlers = append(handlers, h)
+	}
+	return handlers
+}
+
+// isUpToDate reports whether outputPath is newer than every .go file in packagePath,
+// in the module-local packages it (transitively) imports, and in any extra input files.
+func isUpToDate(packagePath, outputPath string, extraInputs ...string) (bool, error) {
+	outputInfo, err := os.Stat(outputPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	outputTime := outputInfo.ModTime()
+
+	for _, input := range extraInputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(outputTime) {
+			return false, nil
+		}
+	}
+
+	moduleName, modulePath, err := getModuleInfo(packagePath)
+	if err != nil {
+		return false, fmt.Errorf("error getting module info: %v", err)
+	}
+
+	visited := make(map[string]bool)
+	queue := []string{packagePath}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		if visited[dir] {
+			continue
+		}
+		visited[dir] = true
+
+		newest, imports, err := scanGoFiles(dir)
+		if err != nil {
+			return false, err
+		}
+		if newest.After(outputTime) {
+			return false, nil
+		}
+
+		// Follow imports of packages in the same module, since their types end up in the output
+		for _, imp := range imports {
+			if imp == moduleName || strings.HasPrefix(imp, moduleName+"/") {
+				queue = append(queue, filepath.Join(modulePath, strings.TrimPrefix(imp, moduleName)))
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// scanGoFiles returns the newest modification time among the .go files in dir,
+// along with the import paths they reference.
+func scanGoFiles(dir string) (time.Time, []string, error) {
+	var newest time.Time
+	var imports []string
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return newest, nil, err
+	}
+
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return newest, nil, err
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, parser.ImportsOnly)
+		if err != nil {
+			continue // Skip files that can't be parsed
+		}
+		for _, imp := range f.Imports {
+			imports = append(imports, strings.Trim(imp.Path.Value, "\""))
+		}
+	}
+
+	return newest, imports, nil
+}
+
+type TemplatePiece struct {
+	Name   string
+	Tmpl   string
+	Render bool
+}
+
+// GenerateFileOptions contains all the options for generating a file
+type GenerateFileOptions struct {
+	Types            []TypeInfo
+	Handlers         []HandlerInfo
+	OutputFile       string
+	AuthToken        string
+	AuthTokenStorage string
+	PrettierPath     string
+	UseHooks         bool
+	UseReactQuery    bool
+	UseSWR           bool
+	QueryKeyStyle    string
+	ShouldFormat     bool
+	UseDateObject    bool
+	ZeroTimeAsNull   bool
+	DedupeRequests   bool
+	EOL              string
+	APIConfig        bool
+	HTTPClient       string
+	// BrandIDs emits the Brand utility type used by branded ID types and branded mappings
+	BrandIDs bool
+	// EmitGuards emits a type guard for each type, and ValidateResponses checks responses with them
+	EmitGuards        bool
+	ValidateResponses bool
+	// UseAngular renders an Angular service instead of the query functions and hooks
+	UseAngular bool
+	// BooleanPrefix renames the boolean fields prefixed by prefixBooleanFields on the wire
+	BooleanPrefix bool
+	// DefaultExport adds a default export of the client, or of the only type of a file without handlers
+	DefaultExport bool
+	// UseBuilder adds an api object of request builders, e.g. api.getUser(id).withSignal(signal).execute()
+	UseBuilder bool
+	// OmitSemicolons has Prettier remove semicolons when formatting. The unformatted output always has
+	// them, so it's valid without relying on automatic semicolon insertion.
+	OmitSemicolons bool
+	// FormatConfigDir is where the Prettier config is looked up from, defaulting to the output's directory
+	FormatConfigDir string
+	// UseInterfaces declares object types as interfaces, e.g. export interface User { ... }
+	UseInterfaces bool
+	// EnumStyle declares enums as a union of their values, a const array of them, or a TypeScript enum
+	EnumStyle string
+	// EmitMocks writes MSW request handlers for the handlers next to the output file
+	EmitMocks bool
+	// MaxResponseBytes rejects responses larger than it with an APIError, or is 0 for no limit
+	MaxResponseBytes int64
+	// EmitMeta exports __meta, a summary of the file's handlers and types
+	EmitMeta bool
+	// EmitPaths exports Paths, a function per handler returning its request path
+	EmitPaths bool
+	// ArrayQueryFormat is how arrays are sent as @Query parameters: repeated keys when empty,
+	// "bracket" for ids[]=1&ids[]=2 or "comma" for ids=1,2
+	ArrayQueryFormat string
+	// PaginationEnvelope is the type that gets the hasNextPage and getPage helpers
+	PaginationEnvelope string
+	// BaseURL is prefixed to every request path. A value of env:NAME is read from process.env.NAME
+	// at runtime.
+	BaseURL string
+	// Split writes the types and the client to their own files next to OutputFile, which re-exports
+	// them: "types-and-client" or "per-type". It's ignored when Namespaces is set.
+	Split string
+	// Namespaces bundles packages into one file, each in its own TypeScript namespace. Types and
+	// Handlers are ignored when it is set.
+	Namespaces []NamespaceInfo
+	// Output and Warnings are where the formatter's messages and warnings are printed, stdout when nil
+	Output   io.Writer
+	Warnings io.Writer
+}
+
+// hookStyle returns the kind of hooks to render, as TemplateData.HookStyle
+func (opts GenerateFileOptions) hookStyle() string {
+	switch {
+	case opts.UseReactQuery:
+		return "react-query"
+	case opts.UseSWR:
+		return "swr"
+	case opts.UseHooks:
+		return "react"
+	}
+	return ""
+}
+
+// NamespaceInfo is a package's types and handlers, emitted as `export namespace Name { ... }`
+type NamespaceInfo struct {
+	Name     string
+	Types    []TypeInfo
+	Handlers []HandlerInfo
+}
+
+// generateFile renders opts.OutputFile, and the files it's split into and its mocks when those are
+// enabled. Files whose content hasn't changed since they were last generated aren't rewritten. It
+// reports whether any file was written.
+func generateFile(opts GenerateFileOptions) (bool, error) {
+	dir := filepath.Dir(opts.OutputFile)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return false, fmt.Errorf("error creating directory: %v", err)
+	}
+	if opts.Split != "" && len(opts.Namespaces) == 0 {
+		splitWritten, err := generateSplitFiles(opts)
+		if err != nil {
+			return false, err
+		}
+		mocksWritten, err := generateMockFile(opts)
+		return splitWritten || mocksWritten, err
+	}
+
+	tmpl, data := newFileTemplate(opts)
+	var buf bytes.Buffer
+
+	// Define the order of template pieces
+	headerPiece, typesPiece, clientPiece, handlerPieces := filePieces(opts, data)
+
+	if len(opts.Namespaces) == 0 {
+		templatePieces := append([]TemplatePiece{headerPiece, typesPiece, clientPiece}, handlerPieces...)
+		templatePieces = append(templatePieces, TemplatePiece{Name: "defaultExportTemplate", Tmpl: defaultExportTemplate, Render: opts.DefaultExport})
+		if err := executeTemplatePieces(&buf, tmpl, templatePieces, data); err != nil {
+			return false, err
+		}
+	} else {
+		// The imports and request helpers are shared by every namespace
+		if err := executeTemplatePieces(&buf, tmpl, []TemplatePiece{headerPiece, clientPiece}, data); err != nil {
+			return false, err
+		}
+		for _, ns := range opts.Namespaces {
+			nsData := data
+			nsData.Types, nsData.AllTypes, nsData.Handlers, nsData.Namespace = ns.Types, ns.Types, ns.Handlers, ns.Name
+			fmt.Fprintf(&buf, "\nexport namespace %s {\n", ns.Name)
+			if err := executeTemplatePieces(&buf, tmpl, append([]TemplatePiece{typesPiece}, handlerPieces...), nsData); err != nil {
+				return false, err
+			}
+			buf.WriteString("}\n")
+		}
+	}
+
+	written, err := writeGeneratedFile(opts, opts.OutputFile, buf.String())
+	if err != nil {
+		return false, err
+	}
+	mocksWritten, err := generateMockFile(opts)
+	return written || mocksWritten, err
+}
+
+// newFileTemplate returns the template, with its helper functions, and the data a file is rendered
+// with
+func newFileTemplate(opts GenerateFileOptions) (*template.Template, TemplateData) {
+	allTypes, allHandlers := opts.Types, opts.Handlers
+	if len(opts.Namespaces) > 0 {
+		allTypes, allHandlers = nil, nil
+		for _, ns := range opts.Namespaces {
+			allTypes = append(allTypes, ns.Types...)
+			allHandlers = append(allHandlers, ns.Handlers...)
+		}
+	}
+	namespaces := opts.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []NamespaceInfo{{Types: opts.Types}}
+	}
+	renamed := renamedTypes(namespaces)
+	renamedNames := make(map[string]bool)
+	for _, t := range renamed {
+		renamedNames[t.Name] = true
+	}
+
+	storageKeys := storageKeyConsts(opts.AuthToken, allHandlers)
+	storageKeyNames := make(map[string]string)
+	for _, c := range storageKeys {
+		storageKeyNames[c.Key] = c.Name
+	}
+	// storageKey renders a storage key as its constant, or as a string literal if it has none
+	storageKey := func(key string) string {
+		if name, ok := storageKeyNames[key]; ok {
+			return name
+		}
+		return "'" + template.JSEscapeString(key) + "'"
+	}
+
+	funcMap := template.FuncMap{
+		"last": func(x interface{}) interface{} {
+			v := reflect.ValueOf(x)
+			return v.Index(v.Len() - 1).Interface()
+		},
+		"sub": func(a, b int) int {
+			return a - b
+		},
+		"firstWord": func(s string) string {
+			return strings.Split(s, " ")[0]
+		},
+		"join":          strings.Join,
+		"handlerDoc":    handlerDoc,
+		"jsDoc":         jsDoc,
+		"deprecated":    deprecatedDoc,
+		"unionType":     unionType,
+		"validation":    validationObject,
+		"enumMeta":      enumMeta,
+		"pollInterval":  pollInterval,
+		"storageKey":    storageKey,
+		"storageReadOf": storageReadOf,
+		"queryArgs":     queryArgs,
+		"paramList":     paramList,
+		"argList":       argList,
+		"mutationArgs":  mutationArgs,
+		"hookArgs":      hookArgs,
+		"pluralize":     pluralize,
+		"methodName":    methodName,
+		"guard":         typeGuard,
+		"guardOutput":   responseCheck,
+		"inputHeaders":  inputHeaders,
+		"defaults": func(t TypeInfo) string {
+			return defaultsObject(t, stdoutIfNil(opts.Warnings))
+		},
+		"enum": func(t TypeInfo, style string) string {
+			return enumDeclaration(t, style, stdoutIfNil(opts.Warnings))
+		},
+		"pagination": func(t TypeInfo) string {
+			return paginationHelpers(t, opts.PaginationEnvelope, stdoutIfNil(opts.Warnings))
+		},
+		"requestPath": func(path string) string {
+			return requestPath(opts.BaseURL, path)
+		},
+		"fieldRenames": func(tsType, ns string) string {
+			return fieldRenames(tsType, ns, renamedNames)
+		},
+		"wireFields":   wireFieldsExpr,
+		"envelopeType": envelopeType,
+		"pathParams":   pathParams,
+		"successType":  successType,
+		"errorOutputs": errorOutputs,
+		"queryKey": func(h HandlerInfo) string {
+			return queryKey(h, opts.QueryKeyStyle)
+		},
+		"queryKeyType": func(h HandlerInfo) string {
+			return queryKeyType(h, opts.QueryKeyStyle)
+		},
+	}
+
+	data := TemplateData{
+		Version:           Version,
+		Timestamp:         time.Now().Format(time.RFC3339),
+		Types:             opts.Types,
+		AllTypes:          opts.Types,
+		Handlers:          opts.Handlers,
+		AuthToken:         opts.AuthToken,
+		AuthTokenStorage:  opts.AuthTokenStorage,
+		HookStyle:         opts.hookStyle(),
+		UseDateObject:     opts.UseDateObject,
+		ZeroTimeAsNull:    opts.ZeroTimeAsNull,
+		DedupeRequests:    opts.DedupeRequests,
+		APIConfig:         opts.APIConfig,
+		StorageKeys:       storageKeys,
+		HeaderFunctions:   headerFunctions(allHandlers),
+		MaxResponseBytes:  opts.MaxResponseBytes,
+		QueryParams:       hasQueryParams(allHandlers),
+		ErrorOutputs:      hasErrorOutputs(allHandlers),
+		Envelopes:         hasEnvelopes(allHandlers),
+		EmitPaths:         opts.EmitPaths,
+		ArrayQueryFormat:  opts.ArrayQueryFormat,
+		UseAxios:          opts.HTTPClient == "axios",
+		UseAngular:        opts.UseAngular,
+		BrandIDs:          opts.BrandIDs,
+		EmitGuards:        opts.EmitGuards,
+		ValidateResponses: opts.ValidateResponses,
+		RenamedTypes:      renamed,
+		UseBuilder:        opts.UseBuilder && !opts.UseAngular,
+		BaseURLEnv:        baseURLEnv(opts.BaseURL),
+		UseInterfaces:     opts.UseInterfaces,
+		EnumStyle:         opts.EnumStyle,
+	}
+
+	// Create a new template and add the helper functions
+	return template.New("typescript").Funcs(funcMap), data
+}
+
+// filePieces returns the template pieces of a file: the header, the types, the request helpers and
+// the pieces rendered for the handlers
+func filePieces(opts GenerateFileOptions, data TemplateData) (TemplatePiece, TemplatePiece, TemplatePiece, []TemplatePiece) {
+	headerPiece := TemplatePiece{Name: "headerTemplate", Tmpl: headerTemplate, Render: true}
+	typesPiece := TemplatePiece{Name: "typesTemplate", Tmpl: typesTemplate, Render: true}
+	clientPiece := TemplatePiece{Name: "queryClientTemplate", Tmpl: queryClientTemplate, Render: !opts.UseAngular}
+	handlerPieces := []TemplatePiece{
+		{Name: "queryFunctionTemplate", Tmpl: queryFunctionTemplate, Render: !opts.UseAngular},
+		{Name: "requestBuilderTemplate", Tmpl: requestBuilderTemplate, Render: data.UseBuilder},
+		{Name: "angularServiceTemplate", Tmpl: angularServiceTemplate, Render: opts.UseAngular},
+		{Name: "reactQueryHookTemplate", Tmpl: reactQueryHookTemplate, Render: data.HookStyle == "react-query"},
+		{Name: "swrHookTemplate", Tmpl: swrHookTemplate, Render: data.HookStyle == "swr"},
+		{Name: "reactHookTemplate", Tmpl: reactHookTemplate, Render: data.HookStyle == "react"},
+		{Name: "queryDictionaryTemplate", Tmpl: queryDictionaryTemplate, Render: !opts.UseAngular},
+		{Name: "pathBuilderTemplate", Tmpl: pathBuilderTemplate, Render: opts.EmitPaths},
+		{Name: "metaTemplate", Tmpl: metaTemplate, Render: opts.EmitMeta},
+	}
+	return headerPiece, typesPiece, clientPiece, handlerPieces
+}
+
+// finishFile formats a generated file, if formatting is enabled, and applies the line endings. It
+// reports whether the file is formatted as requested, which it isn't when the formatter failed.
+func finishFile(opts GenerateFileOptions, filePath string) (bool, error) {
+	formatted := true
+	if opts.ShouldFormat {
+		// Format the generated code
+		configDir := opts.FormatConfigDir
+		if configDir == "" {
+			configDir = filepath.Dir(filePath)
+		}
+		if err := formatCode(filePath, configDir, opts.PrettierPath, opts.OmitSemicolons, stdoutIfNil(opts.Output), stdoutIfNil(opts.Warnings)); err != nil {
+			fmt.Fprintf(stdoutIfNil(opts.Warnings), "Warning: Failed to format %s: %v\n", filePath, err)
+			formatted = false
+		}
+	}
+
+	// Normalise line endings last, since formatters may apply their own
+	if err := applyLineEndings(filePath, opts.EOL); err != nil {
+		return false, fmt.Errorf("error applying line endings: %v", err)
+	}
+
+	return formatted, nil
+}
+
+// generatedLineRegex matches the header line recording the version and time a file was generated
+var generatedLineRegex = regexp.MustCompile(`(?m)^// Generated by go2type .*$`)
+
+// metaGeneratedAtRegex matches the time a file was generated in its __meta export, which is left
+// out of its content hash like the generated-by line
+var metaGeneratedAtRegex = regexp.MustCompile(`(?m)^  generatedAt: ['"][^'"]*['"],\r?$`)
+
+// contentHashRegex matches the hash of a file's content recorded at the end of its generated-by line
+var contentHashRegex = regexp.MustCompile(`\(content hash ([0-9a-f]+)\)`)
+
+// writeGeneratedFile writes and formats the rendered content of a generated file, unless the file
+// on disk was generated from the same content with the same formatting, so unchanged files keep
+// their modification time and aren't formatted again. As the file on disk has been formatted since
+// it was rendered, it's compared by a hash of the rendered content, without the timestamp, that's
+// recorded in the generated-by line once the file is formatted, so a file the formatter failed on is
+// written and formatted again on the next run. It reports whether the file was written.
+func writeGeneratedFile(opts GenerateFileOptions, filePath, content string) (bool, error) {
+	generatedLine := generatedLineRegex.FindStringIndex(content)
+	if generatedLine == nil {
+		return false, fmt.Errorf("%s has no generated-by line", filePath)
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%t %s %t %s\n", content[:generatedLine[0]], metaGeneratedAtRegex.ReplaceAllLiteralString(content[generatedLine[1]:], ""), opts.ShouldFormat, opts.PrettierPath, opts.OmitSemicolons, opts.EOL)
+	contentHash := hex.EncodeToString(hash.Sum(nil))[:16]
+
+	if existing, err := os.ReadFile(filePath); err == nil {
+		if m := contentHashRegex.FindStringSubmatch(generatedLineRegex.FindString(string(existing))); m != nil && m[1] == contentHash {
+			return false, nil
+		}
+	}
+
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("error writing %s: %v", filePath, err)
+	}
+	formatted, err := finishFile(opts, filePath)
+	if err != nil || !formatted {
+		return true, err
+	}
+	return true, recordContentHash(filePath, contentHash)
+}
+
+// recordContentHash appends the content hash to the generated-by line of a finished file, before
+// the line ending the formatter or eol gave it
+func recordContentHash(filePath, contentHash string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", filePath, err)
+	}
+	generatedLine := generatedLineRegex.FindIndex(content)
+	if generatedLine == nil {
+		return nil
+	}
+	end := generatedLine[1]
+	if end > generatedLine[0] && content[end-1] == '\r' {
+		end--
+	}
+	hashed := string(content[:end]) + " (content hash " + contentHash + ")" + string(content[end:])
+	if err := os.WriteFile(filePath, []byte(hashed), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", filePath, err)
+	}
+	return nil
+}
+
+// previewFile renders opts.OutputFile to a temporary file and returns a unified diff against the
+// file on disk, or "" when it's unchanged. A split output is diffed file by file. The existing
+// file's generated-by line is kept so the timestamp alone doesn't count as a change.
+func previewFile(opts GenerateFileOptions) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "go2type-")
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary directory: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Printf("Error removing temporary directory: %v", err)
+		}
+	}()
+
+	preview := opts
+	preview.OutputFile = filepath.Join(tmpDir, filepath.Base(opts.OutputFile))
+	preview.FormatConfigDir = filepath.Dir(opts.OutputFile)
+	// The formatter's messages name the temporary file, so they'd only clutter the diff
+	preview.Output = io.Discard
+	if _, err := generateFile(preview); err != nil {
+		return "", err
+	}
+
+	var diff strings.Builder
+	for _, outputFile := range outputFiles(opts) {
+		content, err := os.ReadFile(filepath.Join(tmpDir, filepath.Base(outputFile)))
+		if err != nil {
+			return "", fmt.Errorf("error reading generated file: %v", err)
+		}
+
+		existing, err := os.ReadFile(outputFile)
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("error reading %s: %v", outputFile, err)
+		}
+		newContent := string(content)
+		if generatedLine := generatedLineRegex.FindString(string(existing)); generatedLine != "" {
+			newContent = generatedLineRegex.ReplaceAllLiteralString(newContent, generatedLine)
+		}
+		if generatedAt := metaGeneratedAtRegex.FindString(string(existing)); generatedAt != "" {
+			newContent = metaGeneratedAtRegex.ReplaceAllLiteralString(newContent, generatedAt)
+		}
+		diff.WriteString(unifiedDiff("a/"+filepath.ToSlash(outputFile), "b/"+filepath.ToSlash(outputFile), string(existing), newContent))
+	}
+	return diff.String(), nil
+}
+
+// executeTemplatePieces parses and executes each template piece that should be rendered, in order
+func executeTemplatePieces(w io.Writer, tmpl *template.Template, pieces []TemplatePiece, data TemplateData) error {
+	for _, piece := range pieces {
+		if !piece.Render {
+			continue
+		}
+		t, err := tmpl.Parse(piece.Tmpl)
+		if err != nil {
+			return fmt.Errorf("error parsing template piece %s: %v", piece.Name, err)
+		}
+
+		if err := t.Execute(w, data); err != nil {
+			log.Printf("Error executing template: %s: %v", piece.Name, err)
+			return fmt.Errorf("error executing template piece: %s: %v", piece.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyLineEndings rewrites the file with LF line endings, or CRLF when eol is "crlf"
+func applyLineEndings(filePath, eol string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	normalized := strings.ReplaceAll(string(content), "\r\n", "\n")
+	if eol == "crlf" {
+		normalized = strings.ReplaceAll(normalized, "\n", "\r\n")
+	}
+	if normalized == string(content) {
+		return nil
+	}
+
+	return os.WriteFile(filePath, []byte(normalized), 0644)
+}
+
+// storageKeyConst is a storage key emitted as an exported constant, e.g. AUTH_TOKEN_KEY = 'auth_token'
+type storageKeyConst struct {
+	Name string
+	Key  string
+}
+
+var storageKeyNameRegex = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// storageKeyConsts returns a constant for the auth token key and for each storage key read by a
+// handler header, in order of first use. Constants are named after the key, so session_id is
+// SESSION_ID_KEY.
+func storageKeyConsts(authToken string, handlers []HandlerInfo) []storageKeyConst {
+	keys := []string{authToken}
+	for _, h := range handlers {
+		for _, header := range h.Headers {
+			if header.Source == "localStorage" || header.Source == "sessionStorage" {
+				keys = append(keys, header.StorageKey)
+			}
+		}
+	}
+
+	var consts []storageKeyConst
+	seenKeys := make(map[string]bool)
+	seenNames := make(map[string]bool)
+	for _, key := range keys {
+		if key == "" || seenKeys[key] {
+			continue
+		}
+		seenKeys[key] = true
+
+		base := strings.Trim(storageKeyNameRegex.ReplaceAllString(strings.ToUpper(key), "_"), "_")
+		if base == "" || unicode.IsDigit(rune(base[0])) {
+			base = "_" + base
+		}
+		name := base + "_KEY"
+		// Keys that only differ in case or punctuation get a numbered name
+		for i := 2; seenNames[name]; i++ {
+			name = fmt.Sprintf("%s_KEY_%d", base, i)
+		}
+		seenNames[name] = true
+
+		consts = append(consts, storageKeyConst{Name: name, Key: key})
+	}
+	return consts
+}
+
+// storageReadOf returns the SafeName of the header before headers[i] that reads the same key from
+// the same storage, so the value it read is reused instead of reading the key again, or "" if
+// headers[i] is the first to read it
+func storageReadOf(headers []HeaderInfo, i int) string {
+	header := headers[i]
+	if header.Source != "localStorage" && header.Source != "sessionStorage" {
+		return ""
+	}
+	for _, earlier := range headers[:i] {
+		if earlier.Source == header.Source && earlier.StorageKey == header.StorageKey {
+			return earlier.SafeName
+		}
+	}
+	return ""
+}
+
+// headerFunctions returns the functions read by fn headers, in order of first use
+func headerFunctions(handlers []HandlerInfo) []string {
+	var funcs []string
+	seen := make(map[string]bool)
+	for _, h := range handlers {
+		for _, header := range h.Headers {
+			if header.Source == "fn" && !seen[header.Func] {
+				seen[header.Func] = true
+				funcs = append(funcs, header.Func)
+			}
+		}
+	}
+	return funcs
+}
+
+func inputHeaders(headers []HeaderInfo) []HeaderInfo {
+	var result []HeaderInfo
+	for _, h := range headers {
+		if h.Source == "input" {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+// queryArg is one argument of a generated query function, which is also part of its query key
+type queryArg struct {
+	Name     string
+	Type     string
+	Optional bool
+}
+
+// queryArgs returns the arguments of a handler's query function in order: URL params, input,
+// input headers, then query params with the optional ones last
+func queryArgs(h HandlerInfo) []queryArg {
+	var args []queryArg
+	for _, param := range h.URLParams {
+		args = append(args, queryArg{Name: param, Type: "string"})
+	}
+	if h.InputType != "" {
+		args = append(args, queryArg{Name: "input", Type: h.InputType})
+	}
+	for _, header := range inputHeaders(h.Headers) {
+		args = append(args, queryArg{Name: header.SafeName, Type: "string"})
+	}
+	for _, optional := range []bool{false, true} {
+		for _, param := range h.QueryParams {
+			if param.Optional == optional {
+				args = append(args, queryArg{Name: param.Name, Type: param.Type, Optional: param.Optional})
+			}
+		}
+	}
+	return args
+}
+
+// mutationArgs returns the arguments of a mutation hook, which are the query function's
+// arguments except the input, since that's passed when the mutation is called
+func mutationArgs(h HandlerInfo) []queryArg {
+	var args []queryArg
+	for _, arg := range queryArgs(h) {
+		if arg.Name != "input" {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// hookArgs returns the arguments of a plain React hook. GET hooks take the input first, and
+// mutation hooks receive it when called.
+func hookArgs(h HandlerInfo) []queryArg {
+	args := mutationArgs(h)
+	if h.Method == "GET" && h.InputType != "" {
+		args = append([]queryArg{{Name: "input", Type: h.InputType}}, args...)
+	}
+	return args
+}
+
+// paramList renders args as a parameter list, e.g. "id: string, input: GetUserInput, page?: number"
+func paramList(args []queryArg) string {
+	var params []string
+	for _, arg := range args {
+		if arg.Optional {
+			params = append(params, fmt.Sprintf("%s?: %s", arg.Name, arg.Type))
+		} else {
+			params = append(params, fmt.Sprintf("%s: %s", arg.Name, arg.Type))
+		}
+	}
+	return strings.Join(params, ", ")
+}
+
+// argList renders args as call arguments, e.g. "id, input"
+func argList(args []queryArg) string {
+	var names []string
+	for _, arg := range args {
+		names = append(names, arg.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// pluralize returns a naive plural of name for batch parameters (id -> ids, status -> statuses)
+func pluralize(name string) string {
+	if strings.HasSuffix(name, "s") {
+		return name + "es"
+	}
+	return name + "s"
+}
+
+// methodName returns the name of a handler's service method, e.g. GetUser -> getUser
+func methodName(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// queryKey returns the React Query key expression for a handler, either as an array
+// (['GetUser', id]) or as a single object ([{ scope: 'GetUser', id }])
+func queryKey(h HandlerInfo, style string) string {
+	parts := queryArgs(h)
+	if style == "object" {
+		elems := []string{fmt.Sprintf("scope: '%s'", h.Name)}
+		for _, part := range parts {
+			elems = append(elems, part.Name)
+		}
+		return fmt.Sprintf("[{ %s }]", strings.Join(elems, ", "))
+	}
+
+	elems := []string{fmt.Sprintf("'%s'", h.Name)}
+	for _, part := range parts {
+		elems = append(elems, part.Name)
+	}
+	return fmt.Sprintf("[%s]", strings.Join(elems, ", "))
+}
+
+// queryKeyType returns the TypeScript type of the key returned by queryKey
+func queryKeyType(h HandlerInfo, style string) string {
+	parts := queryArgs(h)
+	if style == "object" {
+		elems := []string{"scope: string"}
+		for _, part := range parts {
+			if part.Optional {
+				elems = append(elems, fmt.Sprintf("%s?: %s", part.Name, part.Type))
+			} else {
+				elems = append(elems, fmt.Sprintf("%s: %s", part.Name, part.Type))
+			}
+		}
+		return fmt.Sprintf("[{ %s }]", strings.Join(elems, "; "))
+	}
+
+	elems := []string{"string"}
+	for _, part := range parts {
+		if part.Optional {
+			elems = append(elems, part.Type+" | undefined")
+		} else {
+			elems = append(elems, part.Type)
+		}
+	}
+	return fmt.Sprintf("[%s]", strings.Join(elems, ", "))
+}
+
+type TypeRegistry struct {
+	Types map[string]TypeInfo
+}
+
+func (tr *TypeRegistry) AddType(t TypeInfo) {
+	tr.Types[t.Name] = t
+}
+
+func (tr *TypeRegistry) GetType(name string) (TypeInfo, bool) {
+	t, ok := tr.Types[name]
+	return t, ok
+}
+
+// ParseOptions contains all the options for parsing a package
+type ParseOptions struct {
+	TypeMappings  map[string]string
+	UseDateObject bool
+	// ZeroTimeAsNull makes time.Time fields nullable, as the client converts the zero time to null
+	ZeroTimeAsNull bool
+	// Routes supplements handler directives with routes found in a router file, keyed by function name
+	Routes map[string]RouteInfo
+	// InterfaceFallback maps interface names to the concrete type used for fields of that interface
+	InterfaceFallback map[string]string
+	// FullExportPackages are import paths whose types bypass filtering by handler usage
+	FullExportPackages []string
+	// VersionPathTemplate is the path prefix of handlers with a @Version directive, e.g. /api/:version
+	VersionPathTemplate string
+	// PaginationEnvelope is the generic type the outputs of @Paginated handlers are wrapped in
+	PaginationEnvelope string
+	// ResponseEnvelope is the response field the outputs of handlers without an @Envelope are
+	// unwrapped from
+	ResponseEnvelope string
+	// UseUnknownForAny emits Go's any, and interfaces without a @TSType, as unknown
+	UseUnknownForAny bool
+	// OnUnresolved is how fields typed with a type from another package that can't be resolved are
+	// handled: "any" (the default) emits unknown, "skip" omits the field and "error" fails
+	OnUnresolved string
+	// FieldTag is the struct tag fields are named by, json when empty. A ts tag takes precedence in
+	// the generated types, while the field keeps the FieldTag name on the wire.
+	FieldTag string
+	// Warnings is where warnings are printed, stdout when nil
+	Warnings io.Writer
+}
+
+func parsePackage(packagePath string, opts ParseOptions) ([]TypeInfo, []HandlerInfo, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, packagePath, nil, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing directory: %v", err)
+	}
+
+	// Get the module name and path
+	moduleName, modulePath, err := getModuleInfo(packagePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting module info: %v", err)
+	}
+
+	// Files are parsed in name order, so the first of two clashing declarations is stable
+	var files []*ast.File
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return fset.Position(files[i].Pos()).Filename < fset.Position(files[j].Pos()).Filename
+	})
+	return parseFiles(fset, files, &moduleInfo{Name: moduleName, Path: modulePath}, packagePath, opts)
+}
+
+// parseSource parses the types and handlers of a single Go source file, such as one read from
+// stdin. Without a package directory or module, types from other packages aren't resolved and are
+// emitted as unknown unless they have a type mapping.
+func parseSource(src []byte, opts ParseOptions) ([]TypeInfo, []HandlerInfo, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "stdin.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing source: %v", err)
+	}
+	return parseFiles(fset, []*ast.File{file}, nil, "", opts)
+}
+
+// moduleInfo is the module a parsed package belongs to, used to resolve types from other packages
+type moduleInfo struct {
+	Name string
+	Path string
+}
+
+// parseFiles parses the types and handlers of the files of a package. Types from other packages
+// are resolved through module, or left unresolved when it is nil.
+func parseFiles(fset *token.FileSet, files []*ast.File, module *moduleInfo, packagePath string, opts ParseOptions) ([]TypeInfo, []HandlerInfo, error) {
+	// Merge default and custom type mappings
+	typeMappings := make(map[string]string)
+	for k, v := range defaultTypeMappings {
+		typeMappings[k] = v
+	}
+	for k, v := range opts.TypeMappings {
+		typeMappings[k] = v
+	}
+	if opts.UseDateObject {
+		typeMappings["time.Time"] = "Date"
+		typeMappings["pgtype.Timestamptz"] = "Date"
+	} else {
+		typeMappings["time.Time"] = "string /* date-time */"
+	}
+	if opts.ZeroTimeAsNull {
+		typeMappings["time.Time"] += " | null"
+	}
+	if _, ok := opts.TypeMappings["sql.NullTime"]; !ok {
+		typeMappings["sql.NullTime"] = strings.TrimSuffix(typeMappings["time.Time"], " | null") + " | null"
+	}
+	// Go's any is emitted as unknown, unless it has a type mapping of its own
+	if _, ok := opts.TypeMappings["any"]; opts.UseUnknownForAny && !ok {
+		typeMappings["any"] = "unknown"
+	}
+	warnings := stdoutIfNil(opts.Warnings)
+
+	registry := &TypeRegistry{
+		Types: make(map[string]TypeInfo),
+	}
+	var handlers []HandlerInfo
+	// handlerDecls maps handler names to the function or method that declared them
+	handlerDecls := make(map[string]string)
+	importMap := make(map[string]string)
+	// interfaces maps the package's interface declarations, and other types with a @TSType
+	// directive, to the TypeScript type emitted in their place
+	interfaces := make(map[string]string)
+	// interfaceBases maps the package's interface declarations to the interfaces they embed
+	interfaceBases := make(map[string][]string)
+	// typeDefs are the package's non-struct type declarations, such as type ID = string or type IDs []ID
+	typeDefs := make(map[string]ast.Expr)
+	// Named basic types and typed constants, which are combined into enums once every file is parsed
+	namedTypes := make(map[string]bool)
+	constValues := make(map[string]constant.Value)
+	var enumConsts []enumConst
+
+	fullExport := make(map[string]bool)
+	for _, importPath := range opts.FullExportPackages {
+		fullExport[importPath] = true
+	}
+	exportAll := false
+	if module != nil {
+		if rel, err := filepath.Rel(module.Path, packagePath); err == nil {
+			exportAll = fullExport[path.Join(module.Name, filepath.ToSlash(rel))]
+		}
+	}
+
+	for _, file := range files {
+		// Parse imports
+		for _, imp := range file.Imports {
+			if imp.Name != nil {
+				importMap[imp.Name.Name] = strings.Trim(imp.Path.Value, "\"")
+			} else {
+				parts := strings.Split(strings.Trim(imp.Path.Value, "\""), "/")
+				importMap[parts[len(parts)-1]] = strings.Trim(imp.Path.Value, "\"")
+			}
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.GenDecl:
+				if node.Tok == token.CONST {
+					enumConsts = append(enumConsts, parseConstDecl(node, constValues)...)
+				}
+				// Doc comments on ungrouped type declarations are attached to the GenDecl, not the TypeSpec
+				if node.Tok == token.TYPE && len(node.Specs) == 1 && node.Doc != nil {
+					if spec, ok := node.Specs[0].(*ast.TypeSpec); ok && spec.Doc == nil {
+						spec.Doc = node.Doc
+					}
+				}
+			case *ast.TypeSpec:
+				// Types with a custom encoding, such as a MarshalJSON producing a string, are emitted
+				// as their @TSType wherever they're referenced
+				if _, ok := node.Type.(*ast.InterfaceType); !ok {
+					if directive, ok := findDirective(node.Doc, "@TSType"); ok && directive != "" {
+						interfaces[node.Name.Name] = directive
+						return true
+					}
+				}
+				if structType, ok := node.Type.(*ast.StructType); ok {
+					typeInfo := parseType(node.Name.Name, structType, typeMappings, typeParamNames(node.TypeParams), opts.FieldTag)
+					if directive, ok := findDirective(node.Doc, "@TSDerive"); ok {
+						typeInfo.Derived = parseDeriveDirective(node.Name.Name, directive, warnings)
+					}
+					if directive, ok := findDirective(node.Doc, "@TSUnion"); ok {
+						typeInfo.Union, typeInfo.UnionDiscriminant = parseUnionDirective(typeInfo, directive, warnings)
+					}
+					typeInfo.AlwaysExport = exportAll && node.Name.IsExported()
+					registry.AddType(typeInfo)
+				} else if iface, ok := node.Type.(*ast.InterfaceType); ok {
+					interfaces[node.Name.Name] = "any"
+					if directive, ok := findDirective(node.Doc, "@TSType"); ok && directive != "" {
+						interfaces[node.Name.Name] = directive
+					}
+					interfaceBases[node.Name.Name] = embeddedInterfaces(iface)
+				} else if node.TypeParams == nil {
+					typeDefs[node.Name.Name] = node.Type
+					if _, ok := node.Type.(*ast.Ident); ok && !node.Assign.IsValid() {
+						namedTypes[node.Name.Name] = true
+					}
+				}
+			case *ast.FuncDecl:
+				if _, routed := opts.Routes[node.Name.Name]; node.Doc != nil || routed {
+					if handler := parseHandlerComments(node, opts.Routes, warnings); handler != nil {
+						// Handlers may be methods, so receivers can declare handlers of the same name
+						if declared, ok := handlerDecls[handler.Name]; ok {
+							fmt.Fprintf(warnings, "Warning: Handler %s is declared by both %s and %s. Using the first declaration.\n", handler.Name, declared, funcDeclName(node))
+							return true
+						}
+						handlerDecls[handler.Name] = funcDeclName(node)
+						handler.Position = displayPosition(fset.Position(node.Pos()))
+						handlers = append(handlers, *handler)
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	for _, enum := range enumTypes(enumConsts, namedTypes) {
+		registry.AddType(enum)
+	}
+	for _, t := range composedInterfaces(interfaceBases, interfaces) {
+		t.AlwaysExport = exportAll && token.IsExported(t.Name)
+		registry.AddType(t)
+		if len(t.Extends) > 0 {
+			interfaces[t.Name] = t.Name
+		}
+	}
+	// encoding/json treats an embedded interface as a field named after the interface
+	for _, t := range registry.Types {
+		for i, field := range t.Fields {
+			if _, ok := interfaceBases[field.PackageName]; ok && field.Embedded {
+				t.Fields[i].Name, t.Fields[i].Embedded = field.PackageName, false
+			}
+		}
+	}
+
+	// Promote the fields of embedded structs, looking up embedded types from other packages of
+	// the module the same way nested fields are resolved
+	lookupEmbedded := func(name string) (TypeInfo, bool) {
+		if t, ok := registry.GetType(name); ok {
+			return t, true
+		}
+		parts := strings.SplitN(name, ".", 2)
+		if len(parts) != 2 || module == nil {
+			return TypeInfo{}, false
+		}
+		importPath, ok := importMap[parts[0]]
+		if !ok || !strings.HasPrefix(importPath, module.Name) {
+			return TypeInfo{}, false
+		}
+		t, nested, err := parseInternalType(packagePath, module.Path, importPath, parts[1], typeMappings, module.Name, opts.FieldTag, warnings)
+		if err != nil {
+			fmt.Fprintf(warnings, "Warning: Failed to resolve embedded type %s: %v\n", name, err)
+			return TypeInfo{}, false
+		}
+		addReferencedTypes(registry, nested, fullExport[importPath])
+		return t, true
+	}
+	for _, t := range registry.Types {
+		t.Fields = flattenEmbeddedFields(t, lookupEmbedded, map[string]bool{t.Name: true})
+		registry.AddType(t)
+	}
+
+	// Replace aliases and other non-struct types, then interfaces, before resolution so they
+	// aren't looked up as structs
+	for _, t := range registry.Types {
+		resolveTypeDefs(&t, typeDefs, registry, typeMappings)
+		substituteInterfaceFields(&t, interfaces, opts.InterfaceFallback, typeMappings)
+	}
+
+	for i, handler := range handlers {
+		if handler.Version != "" {
+			handlers[i].Path = versionedPath(opts.VersionPathTemplate, handler.Version, handler.Path)
+		}
+		handlers[i] = paginateHandler(handlers[i], opts.PaginationEnvelope, warnings)
+		handlers[i] = envelopeHandler(handlers[i], opts.ResponseEnvelope, warnings)
+		checkPoll(handlers[i], registry, warnings)
+	}
+
+	// Resolve nested types and external package types. Types are resolved once, so recursive types
+	// such as type Node struct { Children []Node } reference themselves by name.
+	resolved := make(map[string]string)
+	for _, t := range registry.Types {
+		if module == nil {
+			dropUnresolvedTypes(&t, typeMappings)
+			continue
+		}
+		if err := resolveNestedAndExternalTypes(&t, registry, packagePath, module.Path, typeMappings, importMap, module.Name, fullExport, opts.OnUnresolved, opts.FieldTag, resolved, warnings); err != nil {
+			return nil, nil, err
+		}
+		registry.AddType(t)
+	}
+
+	// Validate derived types now that every type in the package is known
+	for _, t := range registry.Types {
+		if t.Derived == "" {
+			continue
+		}
+		if err := validateDerivedType(t.Derived, registry); err != nil {
+			fmt.Fprintf(warnings, "Warning: Ignoring @TSDerive on %s: %v\n", t.Name, err)
+			t.Derived = ""
+			registry.AddType(t)
+		}
 	}
 
 	// Convert registry to slice
@@ -559,17 +2687,246 @@ func parsePackage(packagePath string, customTypeMappings map[string]string, useD
 	return usedTypes, handlers, nil
 }
 
-func resolveNestedAndExternalTypes(t *TypeInfo, registry *TypeRegistry, currentPackagePath, modulePath string, typeMappings map[string]string, importMap map[string]string, moduleName string) {
+// flattenEmbeddedFields returns the fields of t with each embedded struct replaced by the fields
+// it promotes, as encoding/json does. Fields declared closer to t take precedence over promoted
+// ones of the same name, and embedded types that can't be looked up are dropped.
+func flattenEmbeddedFields(t TypeInfo, lookup func(name string) (TypeInfo, bool), visiting map[string]bool) []FieldInfo {
+	declared := make(map[string]bool)
+	for _, field := range t.Fields {
+		if !field.Embedded {
+			declared[field.Name] = true
+		}
+	}
+
+	var fields []FieldInfo
+	for _, field := range t.Fields {
+		if !field.Embedded {
+			fields = append(fields, field)
+			continue
+		}
+		embedded, ok := lookup(field.PackageName)
+		if !ok || visiting[field.PackageName] {
+			continue
+		}
+
+		visiting[field.PackageName] = true
+		for _, promoted := range flattenEmbeddedFields(embedded, lookup, visiting) {
+			if declared[promoted.Name] {
+				continue
+			}
+			declared[promoted.Name] = true
+			// Fields of a nil embedded pointer are left out of the JSON
+			promoted.IsOptional = promoted.IsOptional || field.IsOptional
+			fields = append(fields, promoted)
+		}
+		delete(visiting, field.PackageName)
+	}
+	return fields
+}
+
+// embeddedInterfaces returns the names of the package's interfaces embedded in iface
+func embeddedInterfaces(iface *ast.InterfaceType) []string {
+	var names []string
+	for _, method := range iface.Methods.List {
+		if ident, ok := method.Type.(*ast.Ident); ok && len(method.Names) == 0 {
+			names = append(names, ident.Name)
+		}
+	}
+	return names
+}
+
+// composedInterfaces returns a type for each interface composed of other interfaces, emitted as
+// `export interface X extends A, B {}`, along with a type alias for each @TSType interface they
+// extend. An interface is only composed when every interface it embeds has an object type, from
+// a @TSType directive or its own composition, since TypeScript interfaces can't extend any.
+func composedInterfaces(bases map[string][]string, interfaces map[string]string) []TypeInfo {
+	var hasShape func(name string, visiting map[string]bool) bool
+	hasShape = func(name string, visiting map[string]bool) bool {
+		embedded, ok := bases[name]
+		if !ok || visiting[name] {
+			return false
+		}
+		if tsType := interfaces[name]; tsType != "any" {
+			return strings.HasPrefix(strings.TrimSpace(tsType), "{")
+		}
+		if len(embedded) == 0 {
+			return false
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+		for _, base := range embedded {
+			if !hasShape(base, visiting) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var names []string
+	for name := range bases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var types []TypeInfo
+	aliased := make(map[string]bool)
+	for _, name := range names {
+		if interfaces[name] != "any" || !hasShape(name, make(map[string]bool)) {
+			continue
+		}
+		types = append(types, TypeInfo{Name: name, FullName: name, Extends: bases[name]})
+		for _, base := range bases[name] {
+			if tsType := interfaces[base]; tsType != "any" && !aliased[base] {
+				aliased[base] = true
+				types = append(types, TypeInfo{Name: base, FullName: base, TSType: tsType})
+			}
+		}
+	}
+	return types
+}
+
+// substituteInterfaceFields replaces fields typed as an interface with the configured fallback
+// for that interface, falling back to the interface's @TSType override or any, which may itself
+// have a type mapping
+func substituteInterfaceFields(t *TypeInfo, interfaces, fallbacks, typeMappings map[string]string) {
+	for i, field := range t.Fields {
+		tsType, ok := fallbacks[field.PackageName]
+		if !ok {
+			tsType, ok = interfaces[field.PackageName]
+			if mappedType, mapped := typeMappings["any"]; ok && tsType == "any" && mapped {
+				tsType = mappedType
+			}
+		}
+		if !ok {
+			continue
+		}
+		t.Fields[i].Type = replaceTypeName(field.Type, field.PackageName, tsType)
+		t.Fields[i].PackageName = tsType
+	}
+}
+
+// resolveTypeDefs replaces fields typed with a non-struct type declaration by the type it
+// declares, following chains such as type A = B; type B = C; type C int down to number. Names
+// with a type mapping or their own generated type (structs and enums) are kept.
+func resolveTypeDefs(t *TypeInfo, typeDefs map[string]ast.Expr, registry *TypeRegistry, typeMappings map[string]string) {
+	for i, field := range t.Fields {
+		seen := make(map[string]bool)
+		for {
+			name := field.PackageName
+			expr, ok := typeDefs[name]
+			if !ok || seen[name] {
+				break
+			}
+			if _, mapped := typeMappings[name]; mapped {
+				break
+			}
+			if _, declared := registry.GetType(name); declared {
+				break
+			}
+			seen[name] = true
+
+			tsType, trueType, _, _ := parseFieldType(expr, typeMappings, nil)
+			field.Type = replaceTypeName(field.Type, name, tsType)
+			field.PackageName = trueType
+		}
+		t.Fields[i] = field
+	}
+}
+
+// replaceTypeName replaces whole-word occurrences of name in the TypeScript type tsType
+func replaceTypeName(tsType, name, replacement string) string {
+	nameRegex := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+	return nameRegex.ReplaceAllLiteralString(tsType, replacement)
+}
+
+// dropUnresolvedTypes replaces fields typed with an unmapped type from another package, which
+// can't be resolved without a module, with unknown
+func dropUnresolvedTypes(t *TypeInfo, typeMappings map[string]string) {
+	for i, field := range t.Fields {
+		if _, mapped := typeMappings[field.PackageName]; mapped || !strings.Contains(field.PackageName, ".") {
+			continue
+		}
+		t.Fields[i].Type = replaceTypeName(field.Type, field.PackageName, "unknown")
+	}
+}
+
+// validOnUnresolved returns how fields with an unresolvable type are handled, which is any unless
+// on_unresolved is skip or error
+func validOnUnresolved(onUnresolved string, w io.Writer) string {
+	if onUnresolved == "skip" || onUnresolved == "error" {
+		return onUnresolved
+	} else if onUnresolved != "any" && onUnresolved != "" {
+		fmt.Fprintf(w, "Warning: Unknown on_unresolved %s. Using any instead.\n", onUnresolved)
+	}
+	return "any"
+}
+
+// validArrayQueryFormat returns how arrays are sent as query parameters, which is "" for repeated
+// keys unless array_query_format is bracket or comma
+func validArrayQueryFormat(format string, w io.Writer) string {
+	if format == "bracket" || format == "comma" {
+		return format
+	} else if format != "repeat" && format != "" {
+		fmt.Fprintf(w, "Warning: Unknown array_query_format %s. Using repeat instead.\n", format)
+	}
+	return ""
+}
+
+// unresolvedFieldType replaces name, a type from another package that couldn't be resolved, in the
+// type of a field with unknown, keeping the name in a comment so the output still compiles
+func unresolvedFieldType(fieldType, name string) string {
+	return replaceTypeName(fieldType, name, fmt.Sprintf("unknown /* unresolved: %s */", name))
+}
+
+// resolveNestedAndExternalTypes resolves the types from other packages that t's fields reference,
+// and those of the package's types it references in turn. Types are recorded in resolved as
+// they're visited, so each is resolved once and cycles such as A { B *B } and B { A *A } end.
+// resolved maps the package's own types to themselves, and the types from other packages of the
+// module, by import path and name like github.com/org/repo/models.User, to the name they're
+// emitted with, like ModelsUser. Warnings are printed to w.
+func resolveNestedAndExternalTypes(t *TypeInfo, registry *TypeRegistry, currentPackagePath, modulePath string, typeMappings map[string]string, importMap map[string]string, moduleName string, fullExport map[string]bool, onUnresolved, fieldTag string, resolved map[string]string, w io.Writer) error {
+	name := strings.Split(t.Name, " ")[0]
+	if _, ok := resolved[name]; ok {
+		return nil
+	}
+	resolved[name] = name
+
+	skipped := make(map[int]bool)
+	// unresolved handles field i, whose type couldn't be resolved for reason, as set by
+	// on_unresolved. The field's package name becomes unknown so it isn't looked up again.
+	unresolved := func(i int, reason string) error {
+		field := t.Fields[i]
+		switch onUnresolved {
+		case "error":
+			return fmt.Errorf("field %s of %s: %s", field.Name, t.Name, reason)
+		case "skip":
+			fmt.Fprintf(w, "Warning: Skipping field %s of %s: %s\n", field.Name, t.Name, reason)
+			skipped[i] = true
+		default:
+			fmt.Fprintf(w, "Warning: Using unknown for field %s of %s: %s\n", field.Name, t.Name, reason)
+			t.Fields[i].Type = unresolvedFieldType(field.Type, field.PackageName)
+			t.Fields[i].PackageName = "unknown"
+		}
+		return nil
+	}
+
 	for i, field := range t.Fields {
 		//nestedType, ok := registry.GetType(strings.Split(field.Type, " ")[0])
 
+		// Mapped types such as uuid.UUID were already converted by parseFieldType
+		if _, mapped := typeMappings[field.PackageName]; mapped {
+			continue
+		}
+
 		if strings.Contains(field.PackageName, ".") {
 			parts := strings.Split(field.PackageName, ".")
 			packageName, typeName := parts[0], parts[1]
 
 			fullPackagePath, ok := importMap[packageName]
 			if !ok {
-				fmt.Printf("Warning: Could not find import for package %s\n", packageName)
+				if err := unresolved(i, fmt.Sprintf("could not find import for package %s", packageName)); err != nil {
+					return err
+				}
 				continue
 			}
 
@@ -580,44 +2937,102 @@ func resolveNestedAndExternalTypes(t *TypeInfo, registry *TypeRegistry, currentP
 
 			if isExternalPackage {
 				// For external packages, use parseExternalType
-				resolvedType, err = parseExternalType(currentPackagePath, fullPackagePath, typeName, typeMappings, moduleName, field.PackageName)
+				resolvedType, err = parseExternalType(currentPackagePath, fullPackagePath, typeName, typeMappings, moduleName, field.PackageName, w)
 				if err != nil {
-					fmt.Printf("Warning: Failed to resolve external type %s: %v\n", field.PackageName, err)
+					if err := unresolved(i, fmt.Sprintf("failed to resolve external type %s: %v", field.PackageName, err)); err != nil {
+						return err
+					}
 					continue
 				}
 
 				// Use the mapped type directly for external types
-				t.Fields[i].Type = resolvedType.Fields[0].Type
+				t.Fields[i].Type = replaceTypeName(field.Type, field.PackageName, resolvedType.Fields[0].Type)
 			} else {
+				// Types from other packages of the module are resolved once
+				key := fullPackagePath + "." + typeName
+				if tsType, ok := resolved[key]; ok {
+					t.Fields[i].Type = replaceTypeName(field.Type, field.PackageName, tsType)
+					continue
+				}
+
 				// For internal packages, parse the type structure
-				resolvedType, err = parseInternalType(currentPackagePath, modulePath, fullPackagePath, typeName, typeMappings, moduleName)
+				var nested []TypeInfo
+				resolvedType, nested, err = parseInternalType(currentPackagePath, modulePath, fullPackagePath, typeName, typeMappings, moduleName, fieldTag, w)
 				if err != nil {
-					fmt.Printf("Warning: Failed to resolve internal type %s: %v\n", field.PackageName, err)
+					if err := unresolved(i, fmt.Sprintf("failed to resolve internal type %s: %v", field.PackageName, err)); err != nil {
+						return err
+					}
+					continue
+				}
+				if resolvedType.TSType != "" {
+					resolved[key] = resolvedType.TSType
+					t.Fields[i].Type = replaceTypeName(field.Type, field.PackageName, resolvedType.TSType)
 					continue
 				}
 
 				// Rename the type if there's a clash
-				newTypeName := fmt.Sprintf("%s%s", cases.Title(language.Und, cases.NoLower).String(packageName), typeName)
-				if t.Fields[i].IsArray {
-					newTypeName = fmt.Sprintf("Array<%s>", newTypeName)
+				tName := fmt.Sprintf("%s%s", cases.Title(language.Und, cases.NoLower).String(packageName), typeName)
+				resolved[key] = tName
+				t.Fields[i].Type = replaceTypeName(field.Type, field.PackageName, tName)
+
+				// The structs it references are named after their packages too, and references
+				// between them renamed, so types that reference each other, such as models.User
+				// { Team *Team } and models.Team { Lead *User }, are emitted once under those names
+				renames := map[string]string{typeName: tName}
+				var referenced []TypeInfo
+				for _, n := range nested {
+					if tsType, ok := resolved[n.FullName]; ok {
+						renames[n.Name] = tsType
+						continue
+					}
+					pkgPath := n.FullName[:strings.LastIndex(n.FullName, ".")]
+					renames[n.Name] = cases.Title(language.Und, cases.NoLower).String(path.Base(pkgPath)) + n.Name
+					resolved[n.FullName] = renames[n.Name]
+					n.Name = renames[n.Name]
+					referenced = append(referenced, n)
 				}
+				resolvedType = TypeInfo{Name: tName, FullName: packageName, Fields: resolvedType.Fields, AlwaysExport: fullExport[fullPackagePath]}
+				referenced = append(referenced, resolvedType)
+				renameTypeReferences(referenced, nil, renames)
 
-				t.Fields[i].Type = newTypeName
-
-				tName := strings.Split(strings.TrimSuffix(strings.TrimPrefix(newTypeName, "Array<"), ">"), " ")[0]
-
-				// Add the internal type to the registry
-				registry.AddType(TypeInfo{Name: tName, FullName: packageName, Fields: resolvedType.Fields})
+				// Add the internal type, and the structs it references, to the registry
+				registry.AddType(referenced[len(referenced)-1])
+				addReferencedTypes(registry, referenced[:len(referenced)-1], fullExport[fullPackagePath])
 			}
-		} else if nestedType, ok := registry.GetType(strings.Split(field.PackageName, " ")[0]); ok {
+		} else if nestedType, ok := registry.GetType(strings.Split(field.PackageName, " ")[0]); ok && resolved[strings.Split(field.PackageName, " ")[0]] == "" {
 			// This is a nested type, resolve it recursively
-			resolveNestedAndExternalTypes(&nestedType, registry, currentPackagePath, modulePath, typeMappings, importMap, moduleName)
+			if err := resolveNestedAndExternalTypes(&nestedType, registry, currentPackagePath, modulePath, typeMappings, importMap, moduleName, fullExport, onUnresolved, fieldTag, resolved, w); err != nil {
+				return err
+			}
 			registry.AddType(nestedType)
 		}
 	}
+
+	if len(skipped) > 0 {
+		var fields []FieldInfo
+		for i, field := range t.Fields {
+			if !skipped[i] {
+				fields = append(fields, field)
+			}
+		}
+		t.Fields = fields
+	}
+	return nil
+}
+
+// addReferencedTypes adds the structs referenced by a type from another package to the registry,
+// keeping types of the same name that are already registered
+func addReferencedTypes(registry *TypeRegistry, referenced []TypeInfo, alwaysExport bool) {
+	for _, t := range referenced {
+		if _, exists := registry.GetType(t.Name); exists {
+			continue
+		}
+		t.AlwaysExport = alwaysExport
+		registry.AddType(t)
+	}
 }
 
-func parseExternalType(currentPackagePath, importPath, typeName string, typeMappings map[string]string, moduleName string, fullTypeName string) (TypeInfo, error) {
+func parseExternalType(currentPackagePath, importPath, typeName string, typeMappings map[string]string, moduleName string, fullTypeName string, w io.Writer) (TypeInfo, error) {
 	cfg := &packages.Config{
 		Mode: packages.NeedTypes | packages.NeedSyntax,
 		Dir:  filepath.Dir(currentPackagePath),
@@ -634,8 +3049,9 @@ func parseExternalType(currentPackagePath, importPath, typeName string, typeMapp
 
 	pkg := pkgs[0]
 	if pkg.Types == nil {
-		return TypeInfo{}, fmt.Errorf("types information not available for package %s", importPath)
+		return TypeInfo{}, fmt.Errorf("types information not available for package %s%s", importPath, packageErrorsNote(pkg))
 	}
+	warnPackageErrors(pkg, w)
 
 	typeName = strings.TrimPrefix(typeName, "*")
 	if strings.Contains(typeName, " ") {
@@ -644,7 +3060,7 @@ func parseExternalType(currentPackagePath, importPath, typeName string, typeMapp
 
 	obj := pkg.Types.Scope().Lookup(typeName)
 	if obj == nil {
-		return TypeInfo{}, fmt.Errorf("type %s not found in package %s", typeName, importPath)
+		return TypeInfo{}, fmt.Errorf("type %s not found in package %s%s", typeName, importPath, packageErrorsNote(pkg))
 	}
 
 	isExternalPackage := !strings.HasPrefix(pkg.PkgPath, moduleName)
@@ -690,7 +3106,7 @@ func parseExternalType(currentPackagePath, importPath, typeName string, typeMapp
 		return TypeInfo{}, fmt.Errorf("no type mapping for external type")
 	} else {
 		// For internal packages, parse the type structure
-		return parseTypeObject(obj, typeMappings)
+		return parseTypeObject(obj, typeMappings, "")
 	}
 }
 
@@ -708,7 +3124,9 @@ func getModuleInfo(packagePath string) (string, string, error) {
 	return parts[0], parts[1], nil
 }
 
-func parseInternalType(currentPackagePath, modulePath, importPath, typeName string, typeMappings map[string]string, moduleName string) (TypeInfo, error) {
+// parseInternalType parses a type from another package of the module, along with the named struct
+// types it references
+func parseInternalType(currentPackagePath, modulePath, importPath, typeName string, typeMappings map[string]string, moduleName, fieldTag string, w io.Writer) (TypeInfo, []TypeInfo, error) {
 	pkgPath := filepath.Join(modulePath, strings.TrimPrefix(importPath, moduleName))
 
 	cfg := &packages.Config{
@@ -718,17 +3136,18 @@ func parseInternalType(currentPackagePath, modulePath, importPath, typeName stri
 
 	pkgs, err := packages.Load(cfg, pkgPath)
 	if err != nil {
-		return TypeInfo{}, fmt.Errorf("failed to load package %s: %v", pkgPath, err)
+		return TypeInfo{}, nil, fmt.Errorf("failed to load package %s: %v", pkgPath, err)
 	}
 
 	if len(pkgs) == 0 {
-		return TypeInfo{}, fmt.Errorf("no packages found for %s", pkgPath)
+		return TypeInfo{}, nil, fmt.Errorf("no packages found for %s", pkgPath)
 	}
 
 	pkg := pkgs[0]
 	if pkg.Types == nil {
-		return TypeInfo{}, fmt.Errorf("types information not available for package %s", pkgPath)
+		return TypeInfo{}, nil, fmt.Errorf("types information not available for package %s%s", pkgPath, packageErrorsNote(pkg))
 	}
+	warnPackageErrors(pkg, w)
 
 	typeName = strings.TrimPrefix(typeName, "*")
 	if strings.Contains(typeName, " ") {
@@ -736,34 +3155,76 @@ func parseInternalType(currentPackagePath, modulePath, importPath, typeName stri
 	}
 	obj := pkg.Types.Scope().Lookup(typeName)
 	if obj == nil {
-		return TypeInfo{}, fmt.Errorf("type %s not found in package %s", typeName, pkgPath)
+		return TypeInfo{}, nil, fmt.Errorf("type %s not found in package %s%s", typeName, pkgPath, packageErrorsNote(pkg))
+	}
+	if tsType, ok := typeSpecDirective(pkg.Syntax, typeName, "@TSType"); ok && tsType != "" {
+		return TypeInfo{Name: typeName, FullName: typeName, TSType: tsType}, nil, nil
+	}
+
+	t, err := parseTypeObject(obj, typeMappings, fieldTag)
+	if err != nil {
+		return TypeInfo{}, nil, err
+	}
+	return t, referencedStructTypes(obj, typeMappings, fieldTag), nil
+}
+
+// packageErrorsWarned holds the import paths of the packages whose errors have been warned about
+var packageErrorsWarned sync.Map
+
+// warnPackageErrors warns, once per package, that a package was loaded with errors. The types that
+// could be checked are still used, so a file that doesn't compile, such as one being worked on,
+// doesn't stop the rest of the package's types from being resolved. The warning is printed to w.
+func warnPackageErrors(pkg *packages.Package, w io.Writer) {
+	if len(pkg.Errors) == 0 {
+		return
+	}
+	if _, warned := packageErrorsWarned.LoadOrStore(pkg.Types.Path(), true); warned {
+		return
+	}
+	fmt.Fprintf(w, "Warning: Package %s has errors, so only the types that could be resolved are used: %v\n", pkg.Types.Path(), pkg.Errors[0])
+}
+
+// packageErrorsNote returns a note on the first error of a package that was loaded with errors,
+// which may be why a type is missing, or "" when it has none
+func packageErrorsNote(pkg *packages.Package) string {
+	if len(pkg.Errors) == 0 {
+		return ""
 	}
+	return fmt.Sprintf(", which has errors: %v", pkg.Errors[0])
+}
 
-	return parseTypeObject(obj, typeMappings)
+// typeSpecDirective returns the value of directive in the doc comment of the type declaration
+// named typeName in files
+func typeSpecDirective(files []*ast.File, typeName, directive string) (string, bool) {
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if typeSpec.Name.Name != typeName {
+					continue
+				}
+				doc := typeSpec.Doc
+				// Doc comments on ungrouped type declarations are attached to the GenDecl
+				if doc == nil && len(genDecl.Specs) == 1 {
+					doc = genDecl.Doc
+				}
+				return findDirective(doc, directive)
+			}
+		}
+	}
+	return "", false
 }
 
-func parseTypeObject(obj types.Object, typeMappings map[string]string) (TypeInfo, error) {
+func parseTypeObject(obj types.Object, typeMappings map[string]string, fieldTag string) (TypeInfo, error) {
 	typeInfo := TypeInfo{Name: obj.Name(), FullName: obj.Name()}
 
 	switch t := obj.Type().Underlying().(type) {
 	case *types.Struct:
-		for i := 0; i < t.NumFields(); i++ {
-			field := t.Field(i)
-			fieldType, packageName, isOptional := parseFieldTypeFromTypes(field.Type(), typeMappings)
-			jsonTag := reflect.StructTag(t.Tag(i)).Get("json")
-			jsonName := strings.Split(jsonTag, ",")[0]
-			if jsonName == "" {
-				jsonName = field.Name()
-			}
-
-			typeInfo.Fields = append(typeInfo.Fields, FieldInfo{
-				PackageName: packageName,
-				Name:        jsonName,
-				Type:        fieldType,
-				JSONName:    jsonName,
-				IsOptional:  isOptional,
-			})
-		}
+		typeInfo.Fields = structFieldsFromTypes(t, typeMappings, fieldTag, map[*types.Struct]bool{t: true})
 	case *types.Basic, *types.Slice, *types.Map, *types.Interface:
 		fieldType, packageName, isOptional := parseFieldTypeFromTypes(obj.Type(), typeMappings)
 
@@ -781,17 +3242,161 @@ func parseTypeObject(obj types.Object, typeMappings map[string]string) (TypeInfo
 	return typeInfo, nil
 }
 
+// referencedStructTypes returns the named struct types referenced by the fields of obj, directly or
+// through pointers, slices and maps, and those they reference in turn. Mapped and generic types
+// are left out.
+func referencedStructTypes(obj types.Object, typeMappings map[string]string, fieldTag string) []TypeInfo {
+	var nested []TypeInfo
+	seen := map[types.Object]bool{obj: true}
+
+	var visit func(t types.Type)
+	visitStruct := func(s *types.Struct) {
+		for i := 0; i < s.NumFields(); i++ {
+			visit(s.Field(i).Type())
+		}
+	}
+	visit = func(t types.Type) {
+		switch t := t.(type) {
+		case *types.Pointer:
+			visit(t.Elem())
+		case *types.Slice:
+			visit(t.Elem())
+		case *types.Array:
+			visit(t.Elem())
+		case *types.Map:
+			visit(t.Key())
+			visit(t.Elem())
+		case *types.Named:
+			if _, mapped := typeMappings[ExtractAfterLastSlash(t.String())]; mapped || seen[t.Obj()] {
+				return
+			}
+			seen[t.Obj()] = true
+			switch underlying := t.Underlying().(type) {
+			case *types.Struct:
+				if t.TypeArgs().Len() > 0 || t.TypeParams().Len() > 0 {
+					return
+				}
+				if info, err := parseTypeObject(t.Obj(), typeMappings, fieldTag); err == nil {
+					// Types from different packages may share a name, so they're told apart by path
+					info.FullName = t.Obj().Pkg().Path() + "." + info.Name
+					nested = append(nested, info)
+				}
+				visitStruct(underlying)
+			case *types.Map, *types.Slice, *types.Pointer:
+				visit(underlying)
+			}
+		}
+	}
+
+	if s, ok := obj.Type().Underlying().(*types.Struct); ok {
+		visitStruct(s)
+	} else {
+		visit(obj.Type().Underlying())
+	}
+	return nested
+}
+
+// structFieldsFromTypes returns the fields of a struct, promoting the fields of untagged embedded
+// structs like flattenEmbeddedFields does for structs parsed from source
+func structFieldsFromTypes(s *types.Struct, typeMappings map[string]string, fieldTag string, visiting map[*types.Struct]bool) []FieldInfo {
+	type promotedFields struct {
+		at     int
+		fields []FieldInfo
+		ptr    bool
+	}
+	var fields []FieldInfo
+	var promoted []promotedFields
+	declared := make(map[string]bool)
+
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		jsonTag := fieldTagValue(reflect.StructTag(s.Tag(i)), fieldTag)
+		jsonName := strings.Split(jsonTag, ",")[0]
+		if jsonTag == "-" {
+			continue
+		}
+
+		if field.Embedded() && jsonName == "" {
+			embeddedType, isPointer := field.Type(), false
+			if ptr, ok := embeddedType.(*types.Pointer); ok {
+				embeddedType, isPointer = ptr.Elem(), true
+			}
+			if embedded, ok := embeddedType.Underlying().(*types.Struct); ok {
+				if !visiting[embedded] {
+					visiting[embedded] = true
+					promoted = append(promoted, promotedFields{at: len(fields), fields: structFieldsFromTypes(embedded, typeMappings, fieldTag, visiting), ptr: isPointer})
+					delete(visiting, embedded)
+				}
+				continue
+			}
+		}
+
+		fieldType, packageName, isOptional := parseFieldTypeFromTypes(field.Type(), typeMappings)
+		if jsonTagHasOption(jsonTag, "string") {
+			fieldType = stringOptionType(fieldType)
+		}
+		var doc string
+		if jsonTagOmitEmpty(jsonTag) {
+			fieldType, doc = omitEmptyType(fieldType)
+			isOptional = true
+		}
+		if jsonName == "" {
+			jsonName = field.Name()
+		}
+		name := jsonName
+		if tsName := tsTagName(reflect.StructTag(s.Tag(i))); tsName != "" {
+			name = tsName
+		}
+		declared[name] = true
+
+		fields = append(fields, FieldInfo{
+			PackageName: packageName,
+			Name:        name,
+			Type:        fieldType,
+			JSONName:    jsonName,
+			IsOptional:  isOptional,
+			Validation:  splitValidateTag(reflect.StructTag(s.Tag(i)).Get("validate")),
+			Default:     reflect.StructTag(s.Tag(i)).Get("default"),
+			Doc:         doc,
+		})
+	}
+
+	// Insert promoted fields where their struct was embedded, skipping those shadowed by declared ones
+	var result []FieldInfo
+	next := 0
+	for _, p := range promoted {
+		result = append(result, fields[next:p.at]...)
+		next = p.at
+		for _, field := range p.fields {
+			if declared[field.Name] {
+				continue
+			}
+			declared[field.Name] = true
+			field.IsOptional = field.IsOptional || p.ptr
+			result = append(result, field)
+		}
+	}
+	return append(result, fields[next:]...)
+}
+
+var typeIdentifierRegex = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+
 func filterUsedTypes(allTypes []TypeInfo, handlers []HandlerInfo) []TypeInfo {
 	usedTypeSet := make(map[string]bool)
 	var queue []string
 
-	// Initialize the queue with types directly used in handlers
-	for _, handler := range handlers {
-		if handler.InputType != "" {
-			queue = append(queue, handler.InputType)
+	// Initialize the queue with types directly used in handlers, and those that are always exported
+	for _, t := range allTypes {
+		if t.AlwaysExport {
+			queue = append(queue, t.Name)
 		}
-		if handler.OutputType != "" {
-			queue = append(queue, handler.OutputType)
+	}
+	for _, handler := range handlers {
+		// Queue every identifier so the type arguments of generic inputs and outputs are kept too
+		queue = append(queue, typeIdentifierRegex.FindAllString(handler.InputType, -1)...)
+		queue = append(queue, typeIdentifierRegex.FindAllString(handler.OutputType, -1)...)
+		for _, param := range handler.QueryParams {
+			queue = append(queue, typeIdentifierRegex.FindAllString(param.Type, -1)...)
 		}
 	}
 
@@ -808,10 +3413,17 @@ func filterUsedTypes(allTypes []TypeInfo, handlers []HandlerInfo) []TypeInfo {
 				tName := strings.Split(strings.TrimSuffix(strings.TrimPrefix(t.Name, "Array<"), ">"), " ")[0]
 
 				if tName == typeName {
+					if base, _, err := parseDerivedExpr(t.Derived); err == nil && !usedTypeSet[base] {
+						queue = append(queue, base)
+					}
+					queue = append(queue, t.Extends...)
+					queue = append(queue, typeIdentifierRegex.FindAllString(t.TSType, -1)...)
 					for _, field := range t.Fields {
-						fieldType := strings.Split(strings.TrimSuffix(strings.TrimPrefix(field.Type, "Array<"), ">"), " ")[0]
-						if !usedTypeSet[fieldType] {
-							queue = append(queue, fieldType)
+						// Queue every identifier in the field type so type arguments and map values are kept too
+						for _, fieldType := range typeIdentifierRegex.FindAllString(field.Type, -1) {
+							if !usedTypeSet[fieldType] {
+								queue = append(queue, fieldType)
+							}
 						}
 					}
 					break
@@ -831,40 +3443,94 @@ func filterUsedTypes(allTypes []TypeInfo, handlers []HandlerInfo) []TypeInfo {
 
 	return usedTypes
 }
-func parseType(name string, structType *ast.StructType, typeMappings map[string]string) TypeInfo {
+
+// typeParamNames returns the names declared in a generic type's type parameter list
+func typeParamNames(params *ast.FieldList) []string {
+	if params == nil {
+		return nil
+	}
+	var names []string
+	for _, field := range params.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+func parseType(name string, structType *ast.StructType, typeMappings map[string]string, typeParams []string, fieldTag string) TypeInfo {
+	typeParamSet := make(map[string]bool)
+	for _, param := range typeParams {
+		typeParamSet[param] = true
+	}
+
 	var fields []FieldInfo
 	for _, field := range structType.Fields.List {
-		if len(field.Names) > 0 {
-			fieldName := field.Names[0].Name
-			fieldType, trueType, isOptional, isArray := parseFieldType(field.Type, typeMappings)
-			jsonName := getJSONTag(field.Tag)
+		// encoding/json never marshals fields tagged json:"-", while json:"-," names a field -
+		if jsonTag(field.Tag, fieldTag) == "-" {
+			continue
+		}
+		fieldType, trueType, isOptional, isArray := parseFieldType(field.Type, typeMappings, typeParamSet)
+		jsonName := getJSONTag(field.Tag, fieldTag)
+		// Embedded structs without a json tag name have their fields promoted into the parent, while
+		// those with one are nested under it like any other field, as encoding/json does
+		embedded := len(field.Names) == 0 && jsonName == ""
+		if !embedded && jsonTagHasOption(jsonTag(field.Tag, fieldTag), "string") {
+			fieldType = stringOptionType(fieldType)
+		}
 
-			typescriptFieldName := fieldName
-			if jsonName != "" {
-				typescriptFieldName = jsonName
+		typescriptFieldName := jsonName
+		if typescriptFieldName == "" && len(field.Names) > 0 {
+			typescriptFieldName = field.Names[0].Name
+		}
+		// A ts tag renames the field in the generated types only, so JSONName keeps its wire name
+		if tsName := getTSTag(field.Tag); tsName != "" && !embedded {
+			if jsonName == "" {
+				jsonName = typescriptFieldName
 			}
+			typescriptFieldName = tsName
+		}
 
-			// Add "| null" only if the field is optional
-			if isOptional {
-				fieldType += " | null"
+		// Add "| null" only if the field is optional
+		if isOptional && !strings.HasSuffix(fieldType, " | null") {
+			fieldType += " | null"
+		}
+		// Fields with omitempty may be left out whether or not they're pointers
+		doc := fieldDoc(field)
+		if !embedded && hasOmitEmpty(field.Tag, fieldTag) {
+			var note string
+			fieldType, note = omitEmptyType(fieldType)
+			isOptional = true
+			if note != "" && doc != "" {
+				doc += "\n" + note
+			} else if note != "" {
+				doc = note
 			}
-
-			fields = append(fields, FieldInfo{
-				PackageName: trueType,
-				Name:        typescriptFieldName,
-				Type:        fieldType,
-				JSONName:    jsonName,
-				IsOptional:  isOptional,
-				IsArray:     isArray,
-			})
 		}
+
+		fields = append(fields, FieldInfo{
+			PackageName: trueType,
+			Name:        typescriptFieldName,
+			Type:        fieldType,
+			JSONName:    jsonName,
+			IsOptional:  isOptional,
+			IsArray:     isArray,
+			Validation:  getValidateTag(field.Tag),
+			Default:     getDefaultTag(field.Tag),
+			Embedded:    embedded,
+			Doc:         doc,
+		})
 	}
-	return TypeInfo{FullName: name, Name: name, Fields: fields}
+	return TypeInfo{FullName: name, Name: name, Fields: fields, TypeParams: typeParams}
 }
 
-func parseFieldType(expr ast.Expr, typeMappings map[string]string) (string, string, bool, bool) {
+func parseFieldType(expr ast.Expr, typeMappings map[string]string, typeParams map[string]bool) (string, string, bool, bool) {
 	switch t := expr.(type) {
 	case *ast.Ident:
+		// Type parameters of the enclosing generic type are emitted as TypeScript generics
+		if typeParams[t.Name] {
+			return t.Name, t.Name, false, false
+		}
 		if mappedType, ok := typeMappings[t.Name]; ok {
 			return mappedType, t.Name, false, false
 		}
@@ -876,15 +3542,47 @@ func parseFieldType(expr ast.Expr, typeMappings map[string]string) (string, stri
 		}
 		return fullType, fullType, false, false
 	case *ast.StarExpr:
-		innerType, in2type, _, _ := parseFieldType(t.X, typeMappings)
-		return innerType, in2type, true, false
+		// A pointer to a slice, e.g. *[]User, is still an array, which may be null
+		innerType, in2type, _, isArray := parseFieldType(t.X, typeMappings, typeParams)
+		return innerType, in2type, true, isArray
 	case *ast.ArrayType:
-		elemType, elemType2, _, _ := parseFieldType(t.Elt, typeMappings)
+		// Elements that are pointers, e.g. []*User, may be null
+		elemType, elemType2, isPointer, _ := parseFieldType(t.Elt, typeMappings, typeParams)
+		if isPointer {
+			elemType = nullableType(elemType)
+		}
 		return fmt.Sprintf("Array<%s>", elemType), elemType2, false, true
 	case *ast.MapType:
-		keyType, _, _, _ := parseFieldType(t.Key, typeMappings)
-		valueType, _, _, _ := parseFieldType(t.Value, typeMappings)
-		return fmt.Sprintf("{ [key: %s]: %s }", keyType, valueType), "map", false, false
+		keyType, _, _, _ := parseFieldType(t.Key, typeMappings, typeParams)
+		valueType, actualValueType, isPointer, _ := parseFieldType(t.Value, typeMappings, typeParams)
+		if isPointer {
+			valueType = nullableType(valueType)
+		}
+		return fmt.Sprintf("{ [key: %s]: %s }", keyType, valueType), actualValueType, false, false
+	case *ast.IndexExpr:
+		// Go 1.22's generic sql.Null[T] is emitted like the other sql null types, as T | null
+		if sel, ok := t.X.(*ast.SelectorExpr); ok && fmt.Sprintf("%s.%s", sel.X, sel.Sel) == "sql.Null" {
+			argType, argTrueType, _, _ := parseFieldType(t.Index, typeMappings, typeParams)
+			return nullableType(argType), argTrueType, false, false
+		}
+		// Instantiated generic type, e.g. Box[User]
+		baseType, trueType, _, _ := parseFieldType(t.X, typeMappings, typeParams)
+		argType, _, _, _ := parseFieldType(t.Index, typeMappings, typeParams)
+		return fmt.Sprintf("%s<%s>", baseType, argType), trueType, false, false
+	case *ast.IndexListExpr:
+		baseType, trueType, _, _ := parseFieldType(t.X, typeMappings, typeParams)
+		var argTypes []string
+		for _, index := range t.Indices {
+			argType, _, _, _ := parseFieldType(index, typeMappings, typeParams)
+			argTypes = append(argTypes, argType)
+		}
+		return fmt.Sprintf("%s<%s>", baseType, strings.Join(argTypes, ", ")), trueType, false, false
+	case *ast.InterfaceType:
+		// An inline interface{} is Go's any, while one with methods can hold any value that has them
+		if mappedType, ok := typeMappings["any"]; ok && len(t.Methods.List) == 0 {
+			return mappedType, "any", false, false
+		}
+		return "unknown", "unknown", false, false
 	default:
 		return "unknown", "unknown", false, false
 	}
@@ -892,6 +3590,18 @@ func parseFieldType(expr ast.Expr, typeMappings map[string]string) (string, stri
 
 // Update parseFieldTypeFromTypes to handle more complex types
 func parseFieldTypeFromTypes(t types.Type, typeMappings map[string]string) (string, string, bool) {
+	// Follow alias chains (type A = B; type B = C) to the type they name, unless an alias is mapped
+	for {
+		alias, ok := t.(*types.Alias)
+		if !ok {
+			break
+		}
+		typeName := ExtractAfterLastSlash(alias.String())
+		if mappedType, ok := typeMappings[typeName]; ok {
+			return mappedType, typeName, false
+		}
+		t = alias.Rhs()
+	}
 
 	switch t := t.(type) {
 	case *types.Basic:
@@ -901,31 +3611,99 @@ func parseFieldTypeFromTypes(t types.Type, typeMappings map[string]string) (stri
 		return t.Name(), t.Name(), false
 	case *types.Pointer:
 		elemType, actualElemType, _ := parseFieldTypeFromTypes(t.Elem(), typeMappings)
+		if strings.HasSuffix(elemType, " | null") {
+			return elemType, actualElemType, true
+		}
 		return elemType + " | null", actualElemType, true
 	case *types.Slice:
 		elemType, actualElemType, _ := parseFieldTypeFromTypes(t.Elem(), typeMappings)
 		return fmt.Sprintf("Array<%s>", elemType), actualElemType, true
 	case *types.Map:
 		keyType, _, _ := parseFieldTypeFromTypes(t.Key(), typeMappings)
-		valueType, _, _ := parseFieldTypeFromTypes(t.Elem(), typeMappings)
-		return fmt.Sprintf("{ [key: %s]: %s }", keyType, valueType), "map", false
+		valueType, actualValueType, _ := parseFieldTypeFromTypes(t.Elem(), typeMappings)
+		return fmt.Sprintf("{ [key: %s]: %s }", keyType, valueType), actualValueType, false
 	case *types.Interface:
+		if mappedType, ok := typeMappings["any"]; ok {
+			return mappedType, "any", false
+		}
 		return "any", "any", false
 	default:
 		typeName := ExtractAfterLastSlash(t.String())
 		if mappedType, ok := typeMappings[typeName]; ok {
 			return mappedType, typeName, false
 		}
+		// Named basic, map and slice types such as type C int or type Values map[string][]string
+		// are emitted as their underlying type
+		if named, ok := t.(*types.Named); ok {
+			if isSQLNull(named) {
+				argType, argTrueType, _ := parseFieldTypeFromTypes(named.TypeArgs().At(0), typeMappings)
+				return nullableType(argType), argTrueType, false
+			}
+			switch named.Underlying().(type) {
+			case *types.Basic, *types.Map, *types.Slice:
+				return parseFieldTypeFromTypes(named.Underlying(), typeMappings)
+			case *types.Struct:
+				// Named structs are emitted by name, see referencedStructTypes
+				if named.TypeArgs().Len() == 0 && named.TypeParams().Len() == 0 {
+					return named.Obj().Name(), named.Obj().Name(), false
+				}
+			}
+		}
 		return "unknown", "unknown", false
 	}
 }
 
-func getJSONTag(tag *ast.BasicLit) string {
+// isSQLNull reports whether named is an instantiation of database/sql's generic Null[T]
+func isSQLNull(named *types.Named) bool {
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "database/sql" && obj.Name() == "Null" && named.TypeArgs().Len() == 1
+}
+
+// nullableType adds null to tsType unless it already includes it
+func nullableType(tsType string) string {
+	if strings.HasSuffix(tsType, " | null") {
+		return tsType
+	}
+	return tsType + " | null"
+}
+
+// fieldDoc returns the doc comment above a struct field followed by its trailing line comment
+func fieldDoc(field *ast.Field) string {
+	var parts []string
+	for _, group := range []*ast.CommentGroup{field.Doc, field.Comment} {
+		if text := strings.TrimSpace(group.Text()); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// jsDoc renders doc as a JSDoc comment indented by indent, on one line when it fits on one, or an
+// empty string when doc is empty
+func jsDoc(doc, indent string) string {
+	if doc == "" {
+		return ""
+	}
+	doc = strings.ReplaceAll(doc, "*/", "*\\/")
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		return "/** " + doc + " */\n" + indent
+	}
+	var b strings.Builder
+	b.WriteString("/**\n")
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	b.WriteString(indent + " */\n" + indent)
+	return b.String()
+}
+
+// getJSONTag returns the name part of a field's fieldTag tag, its json tag when fieldTag is empty
+func getJSONTag(tag *ast.BasicLit, fieldTag string) string {
 	if tag == nil {
 		return ""
 	}
-	fullTag := reflect.StructTag(strings.Trim(tag.Value, "`"))
-	jsonTag := fullTag.Get("json")
+	jsonTag := fieldTagValue(reflect.StructTag(strings.Trim(tag.Value, "`")), fieldTag)
 	if jsonTag == "" {
 		return ""
 	}
@@ -933,49 +3711,633 @@ func getJSONTag(tag *ast.BasicLit) string {
 	return parts[0] // Return only the name part of the JSON tag
 }
 
-func parseHandlerComments(fn *ast.FuncDecl) *HandlerInfo {
+// jsonTag returns a field's whole fieldTag tag, including options
+func jsonTag(tag *ast.BasicLit, fieldTag string) string {
+	if tag == nil {
+		return ""
+	}
+	return fieldTagValue(reflect.StructTag(strings.Trim(tag.Value, "`")), fieldTag)
+}
+
+// getTSTag returns the name from a field's ts tag
+func getTSTag(tag *ast.BasicLit) string {
+	if tag == nil {
+		return ""
+	}
+	return tsTagName(reflect.StructTag(strings.Trim(tag.Value, "`")))
+}
+
+// hasOmitEmpty reports whether a field's fieldTag tag has the omitempty option
+func hasOmitEmpty(tag *ast.BasicLit, fieldTag string) bool {
+	return jsonTagOmitEmpty(jsonTag(tag, fieldTag))
+}
+
+func jsonTagOmitEmpty(jsonTag string) bool {
+	return jsonTagHasOption(jsonTag, "omitempty")
+}
+
+// jsonTagHasOption reports whether a json tag has option, e.g. omitempty or string
+func jsonTagHasOption(jsonTag, option string) bool {
+	_, options, _ := strings.Cut(jsonTag, ",")
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// stringOptionType returns the type of a field with the json string option, which encoding/json
+// marshals numbers and booleans as strings with, e.g. "42" for an int64 tagged json:",string".
+// The option has no effect on other types, such as slices and structs.
+func stringOptionType(fieldType string) string {
+	parts := splitTopLevel(fieldType, " | ")
+	for i, part := range parts {
+		for _, scalar := range []string{"number", "boolean"} {
+			if part == scalar || strings.HasPrefix(part, scalar+" /*") {
+				parts[i] = "string" + strings.TrimPrefix(part, scalar)
+			}
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// omitEmptyType returns the type of an omitempty field and a note for its doc. encoding/json leaves
+// out nil and empty slices and maps alike, so a collection is only ever absent, never null or
+// empty, and its type stays the bare collection; other fields may also be undefined.
+func omitEmptyType(fieldType string) (string, string) {
+	if isCollectionType(fieldType) {
+		return fieldType, "Left out when nil or empty, never null."
+	}
+	return fieldType + " | undefined", ""
+}
+
+// isCollectionType reports whether tsType is an array or map type without null or undefined
+func isCollectionType(tsType string) bool {
+	if len(splitTopLevel(tsType, " | ")) != 1 {
+		return false
+	}
+	return strings.HasPrefix(tsType, "Array<") || strings.HasPrefix(tsType, "{ [key: ")
+}
+
+// isPointerField reports whether a field was declared as a pointer, whose type includes null
+func isPointerField(field FieldInfo) bool {
+	return strings.HasSuffix(strings.TrimSuffix(field.Type, " | undefined"), " | null")
+}
+
+// nonNullType strips the null and undefined added to the type of pointer and omitempty fields
+func nonNullType(tsType string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(tsType, " | undefined"), " | null")
+}
+
+// getValidateTag returns the rules of a field's validate tag
+func getValidateTag(tag *ast.BasicLit) []string {
+	if tag == nil {
+		return nil
+	}
+	return splitValidateTag(reflect.StructTag(strings.Trim(tag.Value, "`")).Get("validate"))
+}
+
+// getDefaultTag returns the value of a field's default tag
+func getDefaultTag(tag *ast.BasicLit) string {
+	if tag == nil {
+		return ""
+	}
+	return reflect.StructTag(strings.Trim(tag.Value, "`")).Get("default")
+}
+
+func splitValidateTag(validateTag string) []string {
+	if validateTag == "" || validateTag == "-" {
+		return nil
+	}
+	return strings.Split(validateTag, ",")
+}
+
+// directiveValue returns the value following directive in a comment line. Both `@Method GET`
+// and `@Method=GET` forms are accepted.
+func directiveValue(text, directive string) string {
+	value := strings.TrimSpace(strings.SplitN(text, directive, 2)[1])
+	return strings.TrimSpace(strings.TrimPrefix(value, "="))
+}
+
+// outputDirectiveType returns the TypeScript type of an @Output directive. A handler returning an
+// interface can name the concrete type it responds with, as in @Output Shape as Circle, which the
+// response is then typed as.
+func outputDirectiveType(directive string) string {
+	if _, concrete, ok := strings.Cut(directive, " as "); ok {
+		directive = strings.TrimSpace(concrete)
+	}
+	return tsGenericType(directive)
+}
+
+// findDirective returns the value of the first comment in doc containing directive
+func findDirective(doc *ast.CommentGroup, directive string) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+	for _, comment := range doc.List {
+		if strings.Contains(comment.Text, directive) {
+			return directiveValue(comment.Text, directive), true
+		}
+	}
+	return "", false
+}
+
+var deriveDirectiveRegex = regexp.MustCompile(`^([A-Za-z_]\w*)\s*=\s*(.+)$`)
+var derivedExprRegex = regexp.MustCompile(`^(Pick|Omit)<\s*([A-Za-z_]\w*)\s*,\s*(.+?)\s*>$`)
+
+// parseDeriveDirective returns the type expression of a `@TSDerive Name = Omit<Base, "a" | "b">` directive on typeName,
+// warning on w about malformed ones
+func parseDeriveDirective(typeName, directive string, w io.Writer) string {
+	matches := deriveDirectiveRegex.FindStringSubmatch(directive)
+	if matches == nil {
+		fmt.Fprintf(w, "Warning: Malformed @TSDerive directive on %s: %s\n", typeName, directive)
+		return ""
+	}
+	if matches[1] != typeName {
+		fmt.Fprintf(w, "Warning: @TSDerive directive names %s but is declared on %s\n", matches[1], typeName)
+		return ""
+	}
+	return matches[2]
+}
+
+// parseUnionDirective validates a `@TSUnion [discriminant]` directive on t. Without an explicit
+// discriminant, the struct's only non-pointer string field is used if it has one. Warnings are
+// printed to w.
+func parseUnionDirective(t TypeInfo, directive string, w io.Writer) (bool, string) {
+	var members, stringFields []string
+	for _, field := range t.Fields {
+		if isPointerField(field) {
+			members = append(members, field.Name)
+		} else if field.Type == "string" {
+			stringFields = append(stringFields, field.Name)
+		}
+	}
+	if len(members) == 0 {
+		fmt.Fprintf(w, "Warning: Ignoring @TSUnion on %s: it has no pointer fields\n", t.Name)
+		return false, ""
+	}
+
+	discriminant := strings.TrimSpace(directive)
+	if discriminant == "" && len(stringFields) == 1 {
+		discriminant = stringFields[0]
+	}
+	return true, discriminant
+}
+
+// unionType renders a @TSUnion struct as a union with one member per pointer field. Members are
+// tagged with the discriminant when there is one, and otherwise exclude the other members' keys.
+func unionType(t TypeInfo) string {
+	var common, members []FieldInfo
+	for _, field := range t.Fields {
+		switch {
+		case field.Name == t.UnionDiscriminant:
+		case isPointerField(field):
+			members = append(members, field)
+		default:
+			common = append(common, field)
+		}
+	}
+
+	var variants []string
+	for _, member := range members {
+		var props []string
+		if t.UnionDiscriminant != "" {
+			props = append(props, fmt.Sprintf("%s: '%s'", t.UnionDiscriminant, member.Name))
+		}
+		for _, field := range common {
+			props = append(props, fmt.Sprintf("%s: %s", field.Name, field.Type))
+		}
+		props = append(props, fmt.Sprintf("%s: %s", member.Name, nonNullType(member.Type)))
+		if t.UnionDiscriminant == "" {
+			for _, other := range members {
+				if other.Name != member.Name {
+					props = append(props, fmt.Sprintf("%s?: never", other.Name))
+				}
+			}
+		}
+		variants = append(variants, "{ "+strings.Join(props, "; ")+" }")
+	}
+
+	return "\n  | " + strings.Join(variants, "\n  | ")
+}
+
+// validationObject renders the validate tag rules of t's fields as an exported object, e.g.
+// `export const UserValidation = { email: { required: true, max: 255 } } as const;`, or returns
+// an empty string when no field has rules. Rules without a parameter are true, numeric
+// parameters are numbers and other parameters are strings.
+func validationObject(t TypeInfo) string {
+	var fields []string
+	for _, field := range t.Fields {
+		if len(field.Validation) == 0 {
+			continue
+		}
+		var rules []string
+		for _, rule := range field.Validation {
+			name, param, hasParam := strings.Cut(rule, "=")
+			value := "true"
+			if hasParam {
+				if _, err := strconv.ParseFloat(param, 64); err == nil {
+					value = param
+				} else {
+					value = tsStringLiteral(param)
+				}
+			}
+			rules = append(rules, fmt.Sprintf("%s: %s", tsPropertyName(name), value))
+		}
+		fields = append(fields, fmt.Sprintf("  %s: { %s },", tsPropertyName(field.Name), strings.Join(rules, ", ")))
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("export const %sValidation = {\n%s\n} as const;\n", strings.Split(t.Name, " ")[0], strings.Join(fields, "\n"))
+}
+
+// defaultsObject renders the default tag values of a type's fields as e.g.
+// `export const UserDefaults = { role: "member", active: true } as const;`, or returns an empty
+// string when no field has a default
+func defaultsObject(t TypeInfo, w io.Writer) string {
+	var fields []string
+	for _, field := range t.Fields {
+		if field.Default == "" {
+			continue
+		}
+		literal, ok := defaultLiteral(field)
+		if !ok {
+			fmt.Fprintf(w, "Warning: Ignoring default %q on %s.%s: it isn't a valid %s\n", field.Default, t.Name, field.Name, field.Type)
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("  %s: %s,", tsPropertyName(field.Name), literal))
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("export const %sDefaults = {\n%s\n} as const;\n", strings.Split(t.Name, " ")[0], strings.Join(fields, "\n"))
+}
+
+// defaultLiteral coerces a field's default tag value to a TypeScript literal of the field's
+// type. Defaults of number and boolean fields must parse as such. Fields of other types, such
+// as enums, get a number or boolean if the value is one and a string otherwise.
+func defaultLiteral(field FieldInfo) (string, bool) {
+	tsType := nonNullType(field.Type)
+	number, numberErr := strconv.ParseFloat(field.Default, 64)
+	isNumber := numberErr == nil && !math.IsInf(number, 0) && !math.IsNaN(number)
+	boolean, boolErr := strconv.ParseBool(field.Default)
+
+	switch {
+	case field.Default == "":
+		return "", false
+	case tsType == "number":
+		if !isNumber {
+			return "", false
+		}
+		return strconv.FormatFloat(number, 'g', -1, 64), true
+	case tsType == "boolean":
+		if boolErr != nil {
+			return "", false
+		}
+		return strconv.FormatBool(boolean), true
+	case tsType == "string" || strings.HasPrefix(tsType, "string "):
+		return tsStringLiteral(field.Default), true
+	case isNumber:
+		return strconv.FormatFloat(number, 'g', -1, 64), true
+	case field.Default == "true" || field.Default == "false":
+		return field.Default, true
+	}
+	return tsStringLiteral(field.Default), true
+}
+
+var tsIdentifierRegex = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+
+// tsPropertyName quotes name if it isn't a valid TypeScript identifier
+func tsPropertyName(name string) string {
+	if tsIdentifierRegex.MatchString(name) {
+		return name
+	}
+	return tsStringLiteral(name)
+}
+
+// parseDerivedExpr splits a Pick/Omit expression into its base type and field names
+func parseDerivedExpr(expr string) (string, []string, error) {
+	matches := derivedExprRegex.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", nil, fmt.Errorf("expected Pick<Type, \"field\" | ...> or Omit<Type, \"field\" | ...>, got %s", expr)
+	}
+
+	var fields []string
+	for _, key := range strings.Split(matches[3], "|") {
+		key = strings.TrimSpace(key)
+		if len(key) < 2 || (key[0] != '"' && key[0] != '\'') || key[len(key)-1] != key[0] {
+			return "", nil, fmt.Errorf("field name %s must be a quoted string", key)
+		}
+		fields = append(fields, key[1:len(key)-1])
+	}
+
+	return matches[2], fields, nil
+}
+
+// validateDerivedType checks that the base type of a Pick/Omit expression exists and has the referenced fields
+func validateDerivedType(expr string, registry *TypeRegistry) error {
+	base, fields, err := parseDerivedExpr(expr)
+	if err != nil {
+		return err
+	}
+
+	baseType, ok := registry.GetType(base)
+	if !ok {
+		return fmt.Errorf("base type %s not found", base)
+	}
+
+	for _, field := range fields {
+		found := false
+		for _, f := range baseType.Fields {
+			if f.Name == field {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("type %s has no field %q", base, field)
+		}
+	}
+
+	return nil
+}
+
+// parseHandlerComments parses the handler directives in fn's doc comment. Routes found in a
+// router file fill in the method and path when the directives don't declare them. Warnings about
+// invalid directives are printed to w.
+func parseHandlerComments(fn *ast.FuncDecl, routes map[string]RouteInfo, w io.Writer) *HandlerInfo {
 	var method, path, inputType, outputType string
-	var urlParams []string
 	var headers []HeaderInfo
-	for _, comment := range fn.Doc.List {
+	var statuses []StatusInfo
+	var batch, version, deprecated, envelope string
+	var description []string
+	var isDeprecated, paginated bool
+	var poll *PollInfo
+	var queryParams []QueryParamInfo
+	var outputs []OutputVariant
+	var comments []*ast.Comment
+	if fn.Doc != nil {
+		comments = fn.Doc.List
+	}
+	for _, comment := range comments {
 		text := comment.Text
 		switch {
+		case strings.Contains(text, "@Description"):
+			// Checked first, as descriptions may mention other directives
+			description = append(description, directiveValue(text, "@Description"))
+		case strings.Contains(text, "@Deprecated"):
+			// Checked early, as the reason may mention other directives
+			deprecated = directiveValue(text, "@Deprecated")
+			isDeprecated = true
 		case strings.Contains(text, "@Method"):
-			method = strings.TrimSpace(strings.Split(text, "@Method")[1])
+			method = directiveValue(text, "@Method")
 		case strings.Contains(text, "@Path"):
-			path = strings.TrimSpace(strings.Split(text, "@Path")[1])
-			// Extract URL parameters
-			parts := strings.Split(path, "/")
-			for _, part := range parts {
-				if strings.HasPrefix(part, ":") {
-					urlParams = append(urlParams, strings.TrimPrefix(part, ":"))
-				}
-			}
+			path = directiveValue(text, "@Path")
 		case strings.Contains(text, "@Input"):
-			inputType = strings.TrimSpace(strings.Split(text, "@Input")[1])
+			inputType = directiveValue(text, "@Input")
 		case strings.Contains(text, "@Output"):
-			outputType = strings.TrimSpace(strings.Split(text, "@Output")[1])
+			if output := parseOutputDirective(directiveValue(text, "@Output")); output.Status == 0 {
+				outputType = output.Type
+			} else {
+				outputs = append(outputs, output)
+			}
+		case strings.Contains(text, "@Paginated"):
+			paginated = true
+		case strings.Contains(text, "@Envelope"):
+			if field, ok := parseEnvelopeDirective(directiveValue(text, "@Envelope"), w); ok {
+				envelope = field
+			}
+		case strings.Contains(text, "@Poll"):
+			if p, ok := parsePollDirective(directiveValue(text, "@Poll"), w); ok {
+				poll = p
+			}
 		case strings.Contains(text, "@Header"):
-			headerInfo := parseHeaderDirective(strings.TrimSpace(strings.Split(text, "@Header")[1]))
+			headerInfo := parseHeaderDirective(directiveValue(text, "@Header"), w)
 			headers = append(headers, headerInfo)
+		case strings.Contains(text, "@Error"):
+			if status, ok := parseStatusDirective(directiveValue(text, "@Error"), w); ok {
+				statuses = append(statuses, status)
+			}
+		case strings.Contains(text, "@Batch"):
+			batch = directiveValue(text, "@Batch")
+			if batch == "" {
+				batch = formatHookName(fn.Name.Name) + "Batch"
+			}
+		case strings.Contains(text, "@Status"):
+			if status, ok := parseStatusDirective(directiveValue(text, "@Status"), w); ok {
+				statuses = append(statuses, status)
+			}
+		case strings.Contains(text, "@Version"):
+			version = directiveValue(text, "@Version")
+		case strings.Contains(text, "@Query"):
+			if param, ok := parseQueryDirective(directiveValue(text, "@Query")); ok {
+				queryParams = append(queryParams, param)
+			}
+		}
+	}
+
+	// A handler declaring outputs by status returns their union. An @Output without a status is the
+	// output of any other success status, and without either the response has no content.
+	if len(outputs) > 0 {
+		if outputType == "" && successType(HandlerInfo{Outputs: outputs}) == "" {
+			outputType = "void"
+		}
+		if outputType != "" {
+			outputs = append([]OutputVariant{{Type: outputType}}, outputs...)
+		}
+		outputType = outputUnion(outputs)
+	}
+
+	if route, ok := routes[fn.Name.Name]; ok {
+		if method == "" {
+			method = route.Method
+		}
+		if path == "" {
+			path = route.Path
 		}
 	}
 
 	if method != "" && path != "" {
 		return &HandlerInfo{
-			Name:       formatHookName(fn.Name.Name),
-			Method:     method,
-			Path:       path,
-			InputType:  inputType,
-			OutputType: outputType,
-			URLParams:  urlParams,
-			Headers:    headers,
+			Name:         formatHookName(fn.Name.Name),
+			Method:       method,
+			Path:         path,
+			InputType:    inputType,
+			OutputType:   outputType,
+			Outputs:      outputs,
+			URLParams:    extractURLParams(path),
+			Headers:      headers,
+			Statuses:     statuses,
+			Batch:        batch,
+			Version:      version,
+			QueryParams:  queryParams,
+			Description:  strings.Join(description, "\n"),
+			Deprecated:   deprecated,
+			IsDeprecated: isDeprecated,
+			Paginated:    paginated,
+			Envelope:     envelope,
+			Poll:         poll,
 		}
 	}
 
 	return nil
 }
 
+// funcDeclName returns the name of a function, or of a method with its receiver type, e.g. Server.GetUser
+func funcDeclName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	// Generic receivers such as Server[T]
+	switch r := recv.(type) {
+	case *ast.IndexExpr:
+		recv = r.X
+	case *ast.IndexListExpr:
+		recv = r.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// hasQueryParams reports whether any of handlers has @Query parameters
+func hasQueryParams(handlers []HandlerInfo) bool {
+	for _, h := range handlers {
+		if len(h.QueryParams) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseQueryDirective parses a `@Query page:number` (or `limit?:number`) parameter, or a
+// `@Query FilterInput` type whose fields are all sent as query parameters
+func parseQueryDirective(directive string) (QueryParamInfo, bool) {
+	if directive == "" {
+		return QueryParamInfo{}, false
+	}
+
+	key, paramType, hasType := strings.Cut(directive, ":")
+	key, paramType = strings.TrimSpace(key), strings.TrimSpace(paramType)
+	if !hasType {
+		if unicode.IsUpper([]rune(key)[0]) {
+			return QueryParamInfo{Key: "queryParams", Name: "queryParams", Type: key, Struct: true}, true
+		}
+		paramType = "string"
+	}
+
+	optional := strings.HasSuffix(key, "?")
+	key = strings.TrimSuffix(key, "?")
+	return QueryParamInfo{
+		Key:      key,
+		Name:     queryParamNameRegex.ReplaceAllString(key, "_"),
+		Type:     paramType,
+		Optional: optional,
+	}, true
+}
+
+var queryParamNameRegex = regexp.MustCompile(`[^A-Za-z0-9_$]`)
+
+// versionedPath prefixes path with pathTemplate, in which :version is replaced by version.
+// Without a template, the version is used as the first path segment.
+func versionedPath(pathTemplate, version, path string) string {
+	if pathTemplate == "" {
+		pathTemplate = "/:version"
+	}
+	prefix := strings.TrimSuffix(strings.ReplaceAll(pathTemplate, ":version", version), "/")
+	return prefix + "/" + strings.TrimPrefix(path, "/")
+}
+
+// parseStatusDirective parses `404 User not found` into a status code and description
+func parseStatusDirective(directive string, w io.Writer) (StatusInfo, bool) {
+	fields := strings.SplitN(directive, " ", 2)
+	code, err := strconv.Atoi(fields[0])
+	if err != nil {
+		fmt.Fprintf(w, "Warning: Invalid status code in directive: %s\n", directive)
+		return StatusInfo{}, false
+	}
+	status := StatusInfo{Code: code}
+	if len(fields) == 2 {
+		status.Description = strings.TrimSpace(fields[1])
+	}
+	return status, true
+}
+
+// handlerDoc returns the JSDoc comment emitted above a handler's generated functions, or an empty string
+func handlerDoc(h HandlerInfo) string {
+	var lines []string
+	if h.Description != "" {
+		lines = strings.Split(strings.ReplaceAll(h.Description, "*/", "*\\/"), "\n")
+		if h.IsDeprecated || len(h.Statuses) > 0 {
+			// The tags are separated from the summary by a blank line
+			lines = append(lines, "")
+		}
+	}
+	if h.IsDeprecated {
+		lines = append(lines, deprecatedTag(h))
+	}
+
+	var errorStatuses []StatusInfo
+	for _, status := range h.Statuses {
+		if status.Code >= 400 {
+			errorStatuses = append(errorStatuses, status)
+		}
+	}
+	if len(errorStatuses) > 0 {
+		lines = append(lines, "@throws {APIError} When the request fails with one of the following statuses:")
+		for _, status := range errorStatuses {
+			line := fmt.Sprintf("- %d", status.Code)
+			if status.Description != "" {
+				line += ": " + status.Description
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	switch len(lines) {
+	case 0:
+		return ""
+	case 1:
+		return "/** " + lines[0] + " */\n"
+	}
+	return strings.ReplaceAll("/**\n * "+strings.Join(lines, "\n * ")+"\n */\n", " * \n", " *\n")
+}
+
+// deprecatedDoc returns the JSDoc comment emitted above the hooks of a deprecated handler, or an
+// empty string
+func deprecatedDoc(h HandlerInfo) string {
+	if !h.IsDeprecated {
+		return ""
+	}
+	return "/** " + deprecatedTag(h) + " */\n"
+}
+
+// deprecatedTag returns the @deprecated tag of a handler, with its reason if one was given
+func deprecatedTag(h HandlerInfo) string {
+	if h.Deprecated == "" {
+		return "@deprecated"
+	}
+	return "@deprecated " + strings.ReplaceAll(h.Deprecated, "*/", "*\\/")
+}
+
+// extractURLParams returns the names of the :param segments in path
+func extractURLParams(path string) []string {
+	var urlParams []string
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, ":") {
+			urlParams = append(urlParams, strings.TrimPrefix(part, ":"))
+		}
+	}
+	return urlParams
+}
+
 func toTypescriptSafeHeader(s string) string {
 	// Convert to lowercase
 	s = strings.ToLower(s)
@@ -995,7 +4357,40 @@ func toTypescriptSafeHeader(s string) string {
 	return s
 }
 
-func parseHeaderDirective(directive string) HeaderInfo {
+func parseHeaderDirective(directive string, w io.Writer) HeaderInfo {
+	// Constant values may themselves contain colons, e.g. const:X-Client:web:2
+	if strings.HasPrefix(directive, "const:") {
+		parts := strings.SplitN(directive, ":", 3)
+		header := HeaderInfo{
+			HeaderKey: parts[1],
+			SafeName:  toTypescriptSafeHeader(parts[1]),
+			Source:    "const",
+		}
+		if len(parts) == 3 {
+			header.Value = parts[2]
+		}
+		return header
+	}
+	// Function headers call a configured function, e.g. fn:X-Signature:getSignature. Without a
+	// name the function is named after the header, like getXSignature.
+	if strings.HasPrefix(directive, "fn:") {
+		parts := strings.SplitN(directive, ":", 3)
+		header := HeaderInfo{
+			HeaderKey: parts[1],
+			SafeName:  toTypescriptSafeHeader(parts[1]),
+			Source:    "fn",
+		}
+		if len(parts) == 3 && tsIdentifierRegex.MatchString(parts[2]) {
+			header.Func = parts[2]
+		} else {
+			if len(parts) == 3 {
+				fmt.Fprintf(w, "Warning: Invalid @Header function name %s. Using the header's name instead.\n", parts[2])
+			}
+			header.Func = "get" + strings.ReplaceAll(cases.Title(language.Und).String(strings.ReplaceAll(header.SafeName, "_", " ")), " ", "")
+		}
+		return header
+	}
+
 	parts := strings.Split(directive, ":")
 	if len(parts) == 3 {
 		return HeaderInfo{
@@ -1053,32 +4448,58 @@ var defaultTypeMappings = map[string]string{
 	"uuid.UUID":           "string /* uuid */",
 	"pgtypes.Timestamptz": "string /* date-time */",
 	"types.Interface":     "any",
+	"time.Duration":       "number /* nanoseconds */",
+	"json.RawMessage":     "unknown",
+	"sql.NullString":      "string | null",
+	"sql.NullInt64":       "number | null",
+	"sql.NullInt32":       "number | null",
+	"sql.NullInt16":       "number | null",
+	"sql.NullByte":        "number | null",
+	"sql.NullFloat64":     "number | null",
+	"sql.NullBool":        "boolean | null",
+	"url.Values":          "{ [key: string]: Array<string> }",
+	"http.Header":         "{ [key: string]: Array<string> }",
 }
 
-func formatCode(filePath string, prettierPath string) error {
+// formatCode formats a generated file with Prettier, falling back to clang-format. Which formatter
+// was used is printed to out, and why Prettier failed to errOut.
+func formatCode(filePath, configDir, prettierPath string, omitSemicolons bool, out, errOut io.Writer) error {
 	// Try Prettier first
 	if prettierPath != "" {
-		configPath, err := findPrettierConfig(filepath.Dir(filePath))
+		configPath, err := findPrettierConfig(configDir)
 		args := []string{"--write"}
 		if err == nil {
 			args = append(args, "--config", configPath)
 		}
+		if omitSemicolons {
+			args = append(args, "--no-semi")
+		}
 		args = append(args, filePath)
 
 		cmd := exec.Command(prettierPath, args...)
 		output, err := cmd.CombinedOutput()
 		if err == nil {
-			fmt.Printf("Formatted %s with Prettier (config: %s)\n", filePath, configPath)
+			fmt.Fprintf(out, "Formatted %s with Prettier (config: %s)\n", filePath, configPath)
 			return nil
 		}
-		fmt.Printf("Prettier failed: %v\n%s\n", err, output)
+		fmt.Fprintf(errOut, "Prettier failed: %v\n%s\n", err, output)
 	}
 
 	// try clang-format
 	cmd := exec.Command("clang-format", "-i", filePath)
 	output, err := cmd.CombinedOutput()
 	if err == nil {
-		fmt.Printf("Formatted %s with clang-format\n", filePath)
+		fmt.Fprintf(out, "Formatted %s with clang-format\n", filePath)
+		return nil
+	}
+
+	// Without either formatter, the indentation and blank lines are normalized by the built-in one.
+	// It isn't used in place of a configured Prettier that failed, so that failure is still reported.
+	if prettierPath == "" && errors.Is(err, exec.ErrNotFound) {
+		if err := formatFile(filePath); err != nil {
+			return fmt.Errorf("failed to format %s: %v", filePath, err)
+		}
+		fmt.Fprintf(out, "Formatted %s with the built-in formatter\n", filePath)
 		return nil
 	}
 
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -47,7 +55,7 @@ packages:
 	}
 
 	// Test loading the config
-	config, err := loadConfig(tmpfile.Name())
+	config, err := loadConfig(tmpfile.Name(), io.Discard)
 	if err != nil {
 		t.Fatalf("Failed to load config: %v", err)
 	}
@@ -113,7 +121,7 @@ prettier_path: /usr/local/bin/prettier
 		t.Fatalf("Failed to close temp file for default config: %v", err)
 	}
 
-	defaultLoadedConfig, err := loadConfig(tmpfile2.Name())
+	defaultLoadedConfig, err := loadConfig(tmpfile2.Name(), io.Discard)
 	if err != nil {
 		t.Fatalf("Failed to load default config: %v", err)
 	}
@@ -231,7 +239,7 @@ type UserInfo struct {
 		"time.Time":   "Date",
 		"StringArray": "Array<string>",
 	}
-	types, handlers, err := parsePackage(modulePath, customTypeMappings, true)
+	types, handlers, err := parsePackage(modulePath, ParseOptions{TypeMappings: customTypeMappings, UseDateObject: true})
 	if err != nil {
 		t.Fatalf("Failed to parse package: %v", err)
 	}
@@ -239,7 +247,8 @@ type UserInfo struct {
 	// Verify the parsed types
 	expectedTypes := []TypeInfo{
 		{
-			Name: "User",
+			Name:     "User",
+			FullName: "User",
 			Fields: []FieldInfo{
 				{PackageName: "int", Name: "id", Type: "number", JSONName: "id", IsOptional: false},
 				{PackageName: "string", Name: "name", Type: "string", JSONName: "name", IsOptional: false},
@@ -250,7 +259,8 @@ type UserInfo struct {
 			},
 		},
 		{
-			Name: "ModelsUserInfo",
+			Name:     "ModelsUserInfo",
+			FullName: "models",
 			Fields: []FieldInfo{
 				{PackageName: "string", Name: "email", Type: "string", JSONName: "email", IsOptional: false},
 				{PackageName: "int", Name: "age", Type: "number", JSONName: "age", IsOptional: false},
@@ -286,6 +296,7 @@ type UserInfo struct {
 					StorageKey: "X-Custom-Header", // Default to header key
 				},
 			},
+			Position: filepath.Join(modulePath, "main.go") + ":26:1",
 		},
 		{
 			Name:       "CreateUser",
@@ -307,6 +318,7 @@ type UserInfo struct {
 					StorageKey: "",
 				},
 			},
+			Position: filepath.Join(modulePath, "main.go") + ":34:1",
 		},
 	}
 
@@ -520,6 +532,7 @@ func TestGenerateFile(t *testing.T) {
 		useHooks         bool
 		useReactQuery    bool
 		useDateObject    bool
+		useBuilder       bool
 		authTokenStorage string
 		expectedContent  []string
 		outputFile       string
@@ -660,12 +673,26 @@ func TestGenerateFile(t *testing.T) {
 			useDateObject:    true,
 			authTokenStorage: "localStorage",
 			expectedContent: []string{
-				"const x_auth_tokenValue = localStorage.getItem('auth_token');",
-				"const x_custom_headerValue = localStorage.getItem('X-Custom-Header');",
-				"const x_session_idValue = sessionStorage.getItem('session_id');",
+				"export const TEST_TOKEN_KEY = 'test_token';",
+				"export const AUTH_TOKEN_KEY = 'auth_token';",
+				"const token = localStorage.getItem(TEST_TOKEN_KEY);",
+				"const x_auth_tokenValue = localStorage.getItem(AUTH_TOKEN_KEY);",
+				"const x_custom_headerValue = localStorage.getItem(X_CUSTOM_HEADER_KEY);",
+				"const x_session_idValue = sessionStorage.getItem(SESSION_ID_KEY);",
 				"headers['Content-Type'] = content_type;",
 			},
 		},
+		{
+			name:             "Request builders",
+			outputFile:       filepath.Join(tmpdir, "request_builders.ts"),
+			useBuilder:       true,
+			authTokenStorage: "localStorage",
+			expectedContent: []string{
+				"export class RequestBuilder<TOutput>",
+				"getUser: (id: string, input: GetUserInput): RequestBuilder<User> =>",
+				"createUser: (input: CreateUserInput, content_type: string): RequestBuilder<User> =>",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -680,9 +707,10 @@ func TestGenerateFile(t *testing.T) {
 				UseReactQuery:    tc.useReactQuery,
 				ShouldFormat:     false,
 				UseDateObject:    tc.useDateObject,
+				UseBuilder:       tc.useBuilder,
 			}
 
-			if err := generateFile(opts); err != nil {
+			if _, err := generateFile(opts); err != nil {
 				t.Fatalf("Failed to generate file: %v", err)
 			}
 
@@ -717,7 +745,103 @@ func TestGenerateFile(t *testing.T) {
 		})
 	}
 }
-func runTypeScriptCompilation(t *testing.T, dir string, filePath string) error {
+
+func TestVerbatimModuleSyntax(t *testing.T) {
+	tmpdir := createTempFolder(t.Name())
+	defer func() {
+		if !t.Failed() {
+			_ = os.RemoveAll(tmpdir)
+		} else {
+			t.Logf("Test failed. Temporary directory retained at: %s", tmpdir)
+		}
+	}()
+
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{
+			{Name: "id", Type: "number", JSONName: "id"},
+			{Name: "role", Type: "Role", JSONName: "role"},
+		}},
+		{Name: "Role", EnumValues: []string{"'admin'", "'member'"}, EnumNames: []string{"RoleAdmin", "RoleMember"}},
+		{Name: "CreateUserInput", Fields: []FieldInfo{{Name: "name", Type: "string", JSONName: "name"}}},
+	}
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+		{Name: "CreateUser", Method: "POST", Path: "/users", InputType: "CreateUserInput", OutputType: "User"},
+	}
+	testCases := []struct {
+		name     string
+		opts     GenerateFileOptions
+		expected []string
+	}{
+		{
+			name: "axios with React Query hooks",
+			opts: GenerateFileOptions{HTTPClient: "axios", UseHooks: true, UseReactQuery: true},
+			expected: []string{
+				"import axios from 'axios';\nimport type { AxiosInstance, AxiosResponse } from 'axios';\n",
+				"import { useQuery, useQueries, useMutation } from '@tanstack/react-query';\nimport type { UseQueryOptions, UseMutationOptions, UseMutationResult, UseQueryResult } from '@tanstack/react-query';\n",
+			},
+		},
+		{
+			name: "SWR hooks",
+			opts: GenerateFileOptions{UseHooks: true, UseSWR: true},
+			expected: []string{
+				"import useSWR from 'swr';\nimport type { SWRConfiguration, SWRResponse } from 'swr';\n",
+			},
+		},
+		{
+			name: "per-type split",
+			opts: GenerateFileOptions{Split: "per-type", EnumStyle: "enum", UseBuilder: true, DefaultExport: true},
+		},
+	}
+
+	for i, tc := range testCases {
+		tc.opts.Types, tc.opts.Handlers = types, handlers
+		tc.opts.OutputFile = filepath.Join(tmpdir, fmt.Sprintf("case%d", i), "api.generated.ts")
+		testCases[i] = tc
+		if _, err := generateFile(tc.opts); err != nil {
+			t.Fatalf("Failed to generate %s: %v", tc.name, err)
+		}
+		content, err := os.ReadFile(tc.opts.OutputFile)
+		if err != nil {
+			t.Fatalf("Failed to read generated file: %v", err)
+		}
+		for _, expected := range tc.expected {
+			if !strings.Contains(string(content), expected) {
+				t.Errorf("Expected %q in %s, got:\n%s", expected, tc.name, content)
+			}
+		}
+	}
+	client, err := os.ReadFile(filepath.Join(tmpdir, "case2", clientFileName))
+	if err != nil {
+		t.Fatalf("Failed to read split client: %v", err)
+	}
+	for _, expected := range []string{
+		"import type { CreateUserInput } from './CreateUserInput.generated';\n",
+		"import type { User } from './User.generated';\n",
+	} {
+		if !strings.Contains(string(client), expected) {
+			t.Errorf("Expected the split client to import %q, got:\n%s", expected, client)
+		}
+	}
+
+	// Compile the files with verbatimModuleSyntax, which rejects type-only names imported or
+	// re-exported without import type or export type
+	if _, err := exec.LookPath("npm"); err != nil {
+		t.Skip("npm not found, skipping compilation")
+	}
+	if err := setupNpmProject(tmpdir); err != nil {
+		t.Fatalf("Failed to setup npm project: %v", err)
+	}
+	for _, tc := range testCases {
+		if err := runTypeScriptCompilation(t, tmpdir, tc.opts.OutputFile, `"verbatimModuleSyntax": true`); err != nil {
+			t.Errorf("TypeScript compilation of %s failed: %v", tc.name, err)
+		}
+	}
+}
+
+// runTypeScriptCompilation type checks filePath with tsc, adding compilerOptions, e.g.
+// `"verbatimModuleSyntax": true`, to the shared compiler options
+func runTypeScriptCompilation(t *testing.T, dir string, filePath string, compilerOptions ...string) error {
 	tsconfigPath := filepath.Join(dir, "tsconfig.json")
 	// Get the relative path of the file from the directory
 	relFilePath, err := filepath.Rel(dir, filePath)
@@ -725,6 +849,10 @@ func runTypeScriptCompilation(t *testing.T, dir string, filePath string) error {
 		return fmt.Errorf("failed to get relative path: %v", err)
 	}
 
+	var extraOptions string
+	for _, option := range compilerOptions {
+		extraOptions += option + ",\n    "
+	}
 	tsconfig := fmt.Sprintf(`{
   "compilerOptions": {
     "target": "es2020",
@@ -739,11 +867,11 @@ func runTypeScriptCompilation(t *testing.T, dir string, filePath string) error {
     "allowSyntheticDefaultImports": true,
     "resolveJsonModule": true,
     "isolatedModules": true,
-    "noEmit": true
+    %s"noEmit": true
   },
   "include": ["%s"],
   "exclude": ["node_modules"]
-}`, relFilePath)
+}`, extraOptions, relFilePath)
 
 	if err := os.WriteFile(tsconfigPath, []byte(tsconfig), 0644); err != nil {
 		return fmt.Errorf("failed to create tsconfig.json: %v", err)
@@ -776,7 +904,7 @@ func setupNpmProject(dir string) error {
 	}
 
 	// Run npm install
-	cmd := exec.Command("npm", "install", "typescript", "@types/react", "@tanstack/react-query")
+	cmd := exec.Command("npm", "install", "typescript", "@types/react", "@tanstack/react-query", "axios", "swr")
 	cmd.Dir = dir
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("npm install failed: %v\nOutput: %s", err, output)
@@ -800,3 +928,4405 @@ func createTempFolder(name string) string {
 
 	return fullPath
 }
+
+func TestIsUpToDate(t *testing.T) {
+	tmpdir := createTempFolder(t.Name())
+	defer func() {
+		if !t.Failed() {
+			_ = os.RemoveAll(tmpdir)
+		} else {
+			t.Logf("Test failed. Temporary directory retained at: %s", tmpdir)
+		}
+	}()
+
+	writeTestFiles(t, tmpdir, map[string]string{
+		"go.mod": "module github.com/example/uptodate\n\ngo 1.16\n",
+		"main.go": `package main
+
+import "github.com/example/uptodate/internal/models"
+
+var _ models.UserInfo
+`,
+		"internal/models/user_info.go": `package models
+
+type UserInfo struct {
+	Email string ` + "`json:\"email\"`" + `
+}
+`,
+	})
+
+	outputFile := filepath.Join(tmpdir, "api.generated.ts")
+
+	// A missing output is never up to date
+	upToDate, err := isUpToDate(tmpdir, outputFile)
+	if err != nil {
+		t.Fatalf("isUpToDate failed: %v", err)
+	}
+	if upToDate {
+		t.Errorf("Expected missing output to not be up to date")
+	}
+
+	if err := os.WriteFile(outputFile, []byte("// generated"), 0644); err != nil {
+		t.Fatalf("Failed to write output file: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	for _, name := range []string{"go.mod", "main.go", "internal/models/user_info.go"} {
+		if err := os.Chtimes(filepath.Join(tmpdir, name), past, past); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+
+	upToDate, err = isUpToDate(tmpdir, outputFile)
+	if err != nil {
+		t.Fatalf("isUpToDate failed: %v", err)
+	}
+	if !upToDate {
+		t.Errorf("Expected package to be up to date when output is newer than sources")
+	}
+
+	// Touching a dependency package should invalidate the output
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(tmpdir, "internal", "models", "user_info.go"), future, future); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+
+	upToDate, err = isUpToDate(tmpdir, outputFile)
+	if err != nil {
+		t.Fatalf("isUpToDate failed: %v", err)
+	}
+	if upToDate {
+		t.Errorf("Expected package to be out of date when a dependency changed")
+	}
+}
+
+// writeTestFiles writes the given files (keyed by slash-separated relative path) under dir
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// writeTestModule creates a Go module named github.com/example/testmodule containing files in a
+// temporary directory, which is removed when the test passes
+func writeTestModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	tmpdir := createTempFolder(t.Name())
+	t.Cleanup(func() {
+		if !t.Failed() {
+			_ = os.RemoveAll(tmpdir)
+		} else {
+			t.Logf("Test failed. Temporary directory retained at: %s", tmpdir)
+		}
+	})
+
+	writeTestFiles(t, tmpdir, map[string]string{"go.mod": "module github.com/example/testmodule\n\ngo 1.16\n"})
+	writeTestFiles(t, tmpdir, files)
+	return tmpdir
+}
+
+// renderTestFile generates an unformatted file from opts and returns its content
+func renderTestFile(t *testing.T, opts GenerateFileOptions) string {
+	t.Helper()
+	if opts.OutputFile == "" {
+		opts.OutputFile = filepath.Join(createTempFolder(t.Name()), "api.generated.ts")
+	}
+	if opts.AuthTokenStorage == "" {
+		opts.AuthTokenStorage = "localStorage"
+	}
+	if _, err := generateFile(opts); err != nil {
+		t.Fatalf("Failed to generate file: %v", err)
+	}
+	content, err := os.ReadFile(opts.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	return string(content)
+}
+
+func TestLineEndings(t *testing.T) {
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types})
+	if strings.Contains(content, "\r\n") {
+		t.Errorf("Expected LF line endings by default")
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Types: types, EOL: "crlf"})
+	if !strings.Contains(content, "export type User = { \r\n  id: number;\r\n}") {
+		t.Errorf("Expected CRLF line endings in generated file:\n%q", content)
+	}
+	if strings.Count(content, "\n") != strings.Count(content, "\r\n") {
+		t.Errorf("Expected every line to end with CRLF")
+	}
+}
+
+func TestSharedOutputPath(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"users/users.go": `package users
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`,
+		"orders/orders.go": `package orders
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type Order struct {
+	ID    int  ` + "`json:\"id\"`" + `
+	Owner User ` + "`json:\"owner\"`" + `
+}
+
+// @Method GET
+// @Path /orders/:id
+// @Output Order
+func GetOrderHandler() {}
+`,
+		"go2type.yaml": `auth_token: token
+hooks: "false"
+packages:
+  - path: users
+    output_path: out/api.generated.ts
+  - path: orders
+    output_path: ./out/api.generated.ts
+`,
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	if err := generate(GenerateOptions{}); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "out", "api.generated.ts"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	expected := []string{
+		"export const GetUserQuery = async",
+		"export const GetOrderQuery = async",
+		"export type Order = {",
+		"owner: User;",
+	}
+	for _, str := range expected {
+		if !strings.Contains(string(content), str) {
+			t.Errorf("Expected string not found in generated file: %s\n%s", str, content)
+		}
+	}
+	if count := strings.Count(string(content), "export type User = {"); count != 1 {
+		t.Errorf("Expected User to be declared once, got %d", count)
+	}
+
+	// Only one User could be emitted, so declaring it differently fails instead of dropping one
+	writeTestFiles(t, dir, map[string]string{
+		"orders/orders.go": `package orders
+
+type User struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+// @Method GET
+// @Path /orders/users/:id
+// @Output User
+func GetOrderUserHandler() {}
+`,
+	})
+	if err := generate(GenerateOptions{}); err == nil || !strings.Contains(err.Error(), "type User is declared differently") {
+		t.Errorf("Expected generation to fail for a conflicting User, got %v", err)
+	}
+}
+
+func TestDerivedTypes(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type User struct {
+	ID        int    ` + "`json:\"id\"`" + `
+	Name      string ` + "`json:\"name\"`" + `
+	CreatedAt string ` + "`json:\"created_at\"`" + `
+}
+
+// CreateUserInput is the body for creating a user
+// @TSDerive CreateUserInput = Omit<User, "id" | "created_at">
+type CreateUserInput struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+// @TSDerive UpdateUserInput = Pick<User, "name" | "missing">
+type UpdateUserInput struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+// @Method POST
+// @Path /users
+// @Input CreateUserInput
+// @Output User
+func CreateUserHandler() {}
+
+// @Method PUT
+// @Path /users/:id
+// @Input UpdateUserInput
+// @Output User
+func UpdateUserHandler() {}
+`,
+	})
+
+	types, handlers, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+
+	if !strings.Contains(content, `export type CreateUserInput = Omit<User, "id" | "created_at">;`) {
+		t.Errorf("Expected derived CreateUserInput type, got:\n%s", content)
+	}
+	// A directive referencing an unknown field falls back to the struct fields
+	if strings.Contains(content, "Pick<User") || !strings.Contains(content, "export type UpdateUserInput = {") {
+		t.Errorf("Expected invalid @TSDerive to fall back to the struct definition, got:\n%s", content)
+	}
+	if !strings.Contains(content, "export type User = {") {
+		t.Errorf("Expected base type User to be emitted, got:\n%s", content)
+	}
+}
+
+func TestContentTypeHeaderPrecedence(t *testing.T) {
+	handlers := []HandlerInfo{
+		{
+			Name:       "UploadDocument",
+			Method:     "POST",
+			Path:       "/documents",
+			InputType:  "Document",
+			OutputType: "Document",
+			Headers: []HeaderInfo{
+				parseHeaderDirective("input:Content-Type", io.Discard),
+			},
+		},
+	}
+	types := []TypeInfo{
+		{Name: "Document", Fields: []FieldInfo{{Name: "title", Type: "string", JSONName: "title"}}},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, AuthToken: "test_token"})
+
+	expected := []string{
+		"export const UploadDocumentQuery = async (input: Document, content_type: string, ",
+		"headers['Content-Type'] = content_type;",
+		"const contentTypeKey = Object.keys(headers).find((key) => key.toLowerCase() === 'content-type');",
+		"const defaultHeaders: Record<string, string> = contentTypeKey ? {} : {",
+		"requestOptions.body = /json/i.test(contentType) ? JSON.stringify(input) : (input as unknown as BodyInit);",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+
+	// The default JSON Content-Type must not be set unconditionally alongside the explicit one
+	if strings.Contains(content, "'Content-Type': 'application/json'") {
+		t.Errorf("Default Content-Type should only be set when no explicit Content-Type header is given")
+	}
+}
+
+func TestConstHeader(t *testing.T) {
+	header := parseHeaderDirective("const:X-Client:web:2", io.Discard)
+	if header.Source != "const" || header.HeaderKey != "X-Client" || header.Value != "web:2" {
+		t.Errorf("Unexpected header parsed from const directive: %+v", header)
+	}
+
+	handlers := []HandlerInfo{
+		{
+			Name:       "GetStatus",
+			Method:     "GET",
+			Path:       "/status",
+			OutputType: "Status",
+			Headers:    []HeaderInfo{parseHeaderDirective("const:X-API-Version:2", io.Discard)},
+		},
+	}
+	types := []TypeInfo{
+		{Name: "Status", Fields: []FieldInfo{{Name: "ok", Type: "boolean", JSONName: "ok"}}},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseHooks: true, UseReactQuery: true})
+
+	expected := []string{
+		"export const GetStatusQuery = async (onResponse?: (response: Response) => void): Promise<Status> => {",
+		"headers['X-API-Version'] = '2';",
+		"queryFn: () => GetStatusQuery(),",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s\n%s", str, content)
+		}
+	}
+	if strings.Contains(content, "getItem('2')") || strings.Contains(content, "x_api_version") {
+		t.Errorf("Const headers should not be read from storage or taken as a parameter:\n%s", content)
+	}
+}
+
+func TestFunctionHeader(t *testing.T) {
+	header := parseHeaderDirective("fn:X-Signature:getSignature", io.Discard)
+	if header.Source != "fn" || header.HeaderKey != "X-Signature" || header.Func != "getSignature" {
+		t.Errorf("Unexpected header parsed from fn directive: %+v", header)
+	}
+	if header := parseHeaderDirective("fn:X-Request-Timestamp", io.Discard); header.Func != "getXRequestTimestamp" {
+		t.Errorf("Expected the function to be named after the header, got %s", header.Func)
+	}
+
+	handlers := []HandlerInfo{
+		{Name: "CreateOrder", Method: "POST", Path: "/orders", InputType: "Order", OutputType: "Order", Headers: []HeaderInfo{header}},
+		{Name: "GetOrder", Method: "GET", Path: "/orders/:id", OutputType: "Order", URLParams: []string{"id"}, Headers: []HeaderInfo{header}},
+	}
+	types := []TypeInfo{
+		{Name: "Order", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+	for _, expected := range []string{
+		"export interface HeaderConfig {\n  getSignature?: () => string | Promise<string>;\n}",
+		"export const headerConfig: HeaderConfig = {};",
+		"const x_signatureValue = await headerConfig.getSignature?.();",
+		"throw new Error('Missing required header: X-Signature');",
+		"headers['X-Signature'] = x_signatureValue;",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected string not found in generated file: %s\n%s", expected, content)
+		}
+	}
+	if strings.Count(content, "getSignature?: ") != 1 {
+		t.Errorf("Expected the function to be declared once, got:\n%s", content)
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, APIConfig: true})
+	if !strings.Contains(content, "  onError?: (error: APIError) => void;\n  getSignature?: () => string | Promise<string>;\n}") ||
+		!strings.Contains(content, "await apiConfig.getSignature?.();") || strings.Contains(content, "headerConfig") {
+		t.Errorf("Expected the function to be set on apiConfig with api_config, got:\n%s", content)
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseAngular: true})
+	if !strings.Contains(content, "  getSignature?: () => string;\n}") || !strings.Contains(content, "const x_signatureValue = headerConfig.getSignature?.();") {
+		t.Errorf("Expected the Angular service to call the function synchronously, got:\n%s", content)
+	}
+}
+
+func TestSharedStorageHeader(t *testing.T) {
+	handlers := []HandlerInfo{
+		{
+			Name:       "GetAccount",
+			Method:     "GET",
+			Path:       "/account",
+			OutputType: "Account",
+			Headers: []HeaderInfo{
+				parseHeaderDirective("localStorage:X-Tenant-ID:tenant_id", io.Discard),
+				parseHeaderDirective("localStorage:X-Org-ID:tenant_id", io.Discard),
+				parseHeaderDirective("sessionStorage:X-Session-Tenant:tenant_id", io.Discard),
+			},
+		},
+	}
+	types := []TypeInfo{{Name: "Account", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}}
+
+	for _, opts := range []GenerateFileOptions{
+		{Types: types, Handlers: handlers},
+		{Types: types, Handlers: handlers, UseAngular: true},
+	} {
+		content := renderTestFile(t, opts)
+		// The key is read once per storage, and the second header reuses the value
+		if strings.Count(content, "localStorage.getItem(TENANT_ID_KEY)") != 1 || strings.Count(content, "sessionStorage.getItem(TENANT_ID_KEY)") != 1 {
+			t.Errorf("Expected the storage key to be read once per storage, got:\n%s", content)
+		}
+		for _, expected := range []string{
+			"headers['X-Tenant-ID'] = x_tenant_idValue;",
+			"headers['X-Org-ID'] = x_tenant_idValue;",
+			"headers['X-Session-Tenant'] = x_session_tenantValue;",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected string not found in generated file: %s\n%s", expected, content)
+			}
+		}
+		if strings.Contains(content, "x_org_idValue") {
+			t.Errorf("Expected no separate read for the second header, got:\n%s", content)
+		}
+	}
+}
+
+func TestDedupeRequests(t *testing.T) {
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+	}
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+	if strings.Contains(content, "inFlightRequests") || strings.Contains(content, "dedupeQuery") {
+		t.Errorf("Request deduplication should only be generated when enabled")
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, DedupeRequests: true})
+
+	expected := []string{
+		"const inFlightRequests = new Map<string, Promise<unknown>>();",
+		"if (method !== 'GET') {",
+		"const key = `${method} ${url} ${input === undefined ? '' : JSON.stringify(input)} ${JSON.stringify(Object.entries(headers).sort())}`;",
+		"const pending = inFlightRequests.get(key);",
+		"inFlightRequests.delete(key);",
+		"inFlightRequests.set(key, request);",
+		"return dedupeQuery<void, User>('GET', url, undefined, headers, onResponse);",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+}
+
+func TestAPIConfig(t *testing.T) {
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+	}
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, AuthToken: "test_token"})
+	if strings.Contains(content, "apiConfig") {
+		t.Errorf("apiConfig should only be generated when enabled")
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, AuthToken: "test_token", APIConfig: true})
+
+	expected := []string{
+		"export interface APIConfig {",
+		"export const apiConfig: APIConfig = {",
+		"baseUrl: '',",
+		"getToken: () => localStorage.getItem(TEST_TOKEN_KEY),",
+		"defaultHeaders: {},",
+		"const token = apiConfig.getToken();",
+		"const requestHeaders = { ...defaultHeaders, ...apiConfig.defaultHeaders, ...headers };",
+		"const response = await fetch(apiConfig.baseUrl + url, requestOptions);",
+		"apiConfig.onError?.(apiError);",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+	if strings.Contains(content, "const token = localStorage.getItem") {
+		t.Errorf("Query functions should read the token through apiConfig")
+	}
+}
+
+func TestSWRHooks(t *testing.T) {
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+		{Name: "CreateUserInput", Fields: []FieldInfo{{Name: "name", Type: "string", JSONName: "name"}}},
+	}
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+		{Name: "CreateUser", Method: "POST", Path: "/users", InputType: "CreateUserInput", OutputType: "User"},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{
+		Types:     types,
+		Handlers:  handlers,
+		AuthToken: "test_token",
+		UseHooks:  true,
+		UseSWR:    true,
+	})
+
+	expected := []string{
+		"import useSWR from 'swr';\nimport type { SWRConfiguration, SWRResponse } from 'swr';",
+		"import useSWRMutation from 'swr/mutation';\nimport type { SWRMutationConfiguration, SWRMutationResponse } from 'swr/mutation';",
+		"export const useGetUser = (",
+		"): SWRResponse<User, APIError> =>",
+		"useSWR<User, APIError>(",
+		"['/users/:id', id]",
+		"() => GetUserQuery(",
+		"export const useCreateUser = (",
+		"useSWRMutation<User, APIError, [string], CreateUserInput>(",
+		"(_key, { arg }: { arg: CreateUserInput }) => CreateUserQuery(",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+
+	// SWR replaces the plain React hooks
+	if strings.Contains(content, "useState") {
+		t.Errorf("Plain React hooks should not be generated in SWR mode")
+	}
+}
+
+func TestQueryKeyStyle(t *testing.T) {
+	handler := HandlerInfo{
+		Name:       "GetUser",
+		Method:     "GET",
+		Path:       "/users/:id",
+		InputType:  "GetUserInput",
+		OutputType: "User",
+		URLParams:  []string{"id"},
+		Headers:    []HeaderInfo{parseHeaderDirective("input:X-Tenant", io.Discard)},
+	}
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+		{Name: "GetUserInput", Fields: []FieldInfo{{Name: "expand", Type: "string", JSONName: "expand"}}},
+	}
+
+	testCases := []struct {
+		style           string
+		expectedContent []string
+	}{
+		{
+			style: "array",
+			expectedContent: []string{
+				"queryKey: ['GetUser', id, input, x_tenant],",
+				"useQuery<User, APIError, User, [string, string, GetUserInput, string]>({",
+			},
+		},
+		{
+			style: "object",
+			expectedContent: []string{
+				"queryKey: [{ scope: 'GetUser', id, input, x_tenant }],",
+				"useQuery<User, APIError, User, [{ scope: string; id: string; input: GetUserInput; x_tenant: string }]>({",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.style, func(t *testing.T) {
+			content := renderTestFile(t, GenerateFileOptions{
+				Types:         types,
+				Handlers:      []HandlerInfo{handler},
+				AuthToken:     "test_token",
+				UseHooks:      true,
+				UseReactQuery: true,
+				QueryKeyStyle: tc.style,
+			})
+			for _, str := range tc.expectedContent {
+				if !strings.Contains(content, str) {
+					t.Errorf("Expected string not found in generated file: %s", str)
+				}
+			}
+		})
+	}
+}
+
+func TestGenericTypes(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type Box[T any] struct {
+	Value T ` + "`json:\"value\"`" + `
+}
+
+type Pair[K comparable, V any] struct {
+	Key   K ` + "`json:\"key\"`" + `
+	Value V ` + "`json:\"value\"`" + `
+}
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type UserResponse struct {
+	User  Box[User]           ` + "`json:\"user\"`" + `
+	Pairs []Pair[string, User] ` + "`json:\"pairs\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output UserResponse
+func GetUserHandler() {}
+`,
+	})
+
+	types, handlers, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+
+	expected := []string{
+		"export type Box<T> = {",
+		"value: T;",
+		"export type Pair<K, V> = {",
+		"key: K;",
+		"user: Box<User>;",
+		"pairs: Array<Pair<string, User>>;",
+		"export type User = {",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+	if strings.Contains(content, ": unknown;") {
+		t.Errorf("Type parameters should not render as unknown:\n%s", content)
+	}
+}
+
+func TestFullExportPackages(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type Address struct {
+	City    string  ` + "`json:\"city\"`" + `
+	Country Country ` + "`json:\"country\"`" + `
+}
+
+type Country struct {
+	Code string ` + "`json:\"code\"`" + `
+}
+
+type internalState struct {
+	Dirty bool ` + "`json:\"dirty\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`,
+	})
+
+	types, _, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+	if len(types) != 1 || types[0].Name != "User" {
+		t.Fatalf("Expected only the handler-referenced User type, got %v", types)
+	}
+
+	types, handlers, err := parsePackage(dir, ParseOptions{
+		FullExportPackages: []string{"github.com/example/testmodule"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, typ := range types {
+		names[typ.Name] = true
+	}
+	for _, name := range []string{"User", "Address", "Country"} {
+		if !names[name] {
+			t.Errorf("Expected type %s to be exported, got %v", name, names)
+		}
+	}
+	if names["internalState"] {
+		t.Errorf("Unexported type internalState should not be exported")
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+	if !strings.Contains(content, "export type Address = {") {
+		t.Errorf("Expected Address in generated file:\n%s", content)
+	}
+}
+
+func TestUnionTypes(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type ClickEvent struct {
+	X int ` + "`json:\"x\"`" + `
+}
+
+type KeyEvent struct {
+	Key string ` + "`json:\"key\"`" + `
+}
+
+// @TSUnion
+type Event struct {
+	Click *ClickEvent ` + "`json:\"click,omitempty\"`" + `
+	Key   *KeyEvent   ` + "`json:\"key,omitempty\"`" + `
+}
+
+// @TSUnion
+type TaggedEvent struct {
+	Kind  string      ` + "`json:\"kind\"`" + `
+	Click *ClickEvent ` + "`json:\"click,omitempty\"`" + `
+	Key   *KeyEvent   ` + "`json:\"key,omitempty\"`" + `
+}
+
+type EventLog struct {
+	Events []Event       ` + "`json:\"events\"`" + `
+	Tagged []TaggedEvent ` + "`json:\"tagged\"`" + `
+}
+
+// @Method GET
+// @Path /events
+// @Output EventLog
+func GetEventsHandler() {}
+`,
+	})
+
+	types, handlers, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+
+	expected := []string{
+		"export type Event =\n  | { click: ClickEvent; key?: never }\n  | { key: KeyEvent; click?: never };",
+		"export type TaggedEvent =\n  | { kind: 'click'; click: ClickEvent }\n  | { kind: 'key'; key: KeyEvent };",
+		"export type ClickEvent = {",
+		"export type KeyEvent = {",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s\n%s", str, content)
+		}
+	}
+}
+
+func TestValidationMetadata(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type CreateUserInput struct {
+	Email    string ` + "`json:\"email\" validate:\"required,email,max=255\"`" + `
+	Role     string ` + "`json:\"role\" validate:\"oneof=admin user\"`" + `
+	Nickname string ` + "`json:\"nick-name\" validate:\"-\"`" + `
+}
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method POST
+// @Path /users
+// @Input CreateUserInput
+// @Output User
+func CreateUserHandler() {}
+`,
+	})
+
+	types, handlers, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+
+	expected := []string{
+		"export const CreateUserInputValidation = {",
+		"  email: { required: true, email: true, max: 255 },",
+		`  role: { oneof: "admin user" },`,
+		"} as const;",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s\n%s", str, content)
+		}
+	}
+	if strings.Contains(content, "\"nick-name\": {") {
+		t.Errorf("Fields without validation rules should be left out:\n%s", content)
+	}
+	if strings.Contains(content, "export const UserValidation") {
+		t.Errorf("Types without validation rules should not get a validation object")
+	}
+}
+
+// captureOutput returns what fn prints to stdout
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+
+	fn()
+	_ = writer.Close()
+	return <-output
+}
+
+func TestMappedSelectorTypes(t *testing.T) {
+	src := `package main
+
+type Event struct {
+	ID     uuid.UUID   ` + "`json:\"id\"`" + `
+	Parent *uuid.UUID  ` + "`json:\"parent\"`" + `
+	Refs   []uuid.UUID ` + "`json:\"refs\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	structType := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	typeInfo := parseType("Event", structType, defaultTypeMappings, nil, "")
+
+	for _, field := range typeInfo.Fields {
+		if field.PackageName != "uuid.UUID" {
+			t.Errorf("Expected %s to keep uuid.UUID as its package name, got %s", field.Name, field.PackageName)
+		}
+	}
+
+	// Neither a missing import nor an external one should be looked up for a mapped type
+	for _, importMap := range []map[string]string{{}, {"uuid": "github.com/google/uuid"}} {
+		registry := &TypeRegistry{Types: map[string]TypeInfo{"Event": typeInfo}}
+		output := captureOutput(t, func() {
+			_ = resolveNestedAndExternalTypes(&typeInfo, registry, "", "", defaultTypeMappings, importMap, "github.com/example/testmodule", nil, "", "", make(map[string]string), io.Discard)
+		})
+		if strings.Contains(output, "Warning") {
+			t.Errorf("Expected no resolution warnings for mapped selector types, got %q", output)
+		}
+	}
+
+	expected := []string{"string /* uuid */", "string /* uuid */ | null", "Array<string /* uuid */>"}
+	for i, field := range typeInfo.Fields {
+		if field.Type != expected[i] {
+			t.Errorf("Expected %s to have type %s, got %s", field.Name, expected[i], field.Type)
+		}
+	}
+}
+
+func TestJSONTagOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		want  *FieldInfo
+	}{
+		{name: "untagged", field: "Name string", want: &FieldInfo{Name: "Name", Type: "string"}},
+		{name: "named", field: "Name string `json:\"name\"`", want: &FieldInfo{Name: "name", Type: "string"}},
+		{name: "skipped", field: "Secret string `json:\"-\"`", want: nil},
+		{name: "named dash", field: "Dash string `json:\"-,\"`", want: &FieldInfo{Name: "-", Type: "string"}},
+		{name: "omitempty", field: "Count int `json:\",omitempty\"`", want: &FieldInfo{Name: "Count", Type: "number | undefined", IsOptional: true}},
+		{name: "fieldname,omitempty", field: "Email string `json:\"email,omitempty\"`", want: &FieldInfo{Name: "email", Type: "string | undefined", IsOptional: true}},
+		{name: "pointer", field: "Age *int `json:\"age\"`", want: &FieldInfo{Name: "age", Type: "number | null", IsOptional: true}},
+		{name: "pointer,omitempty", field: "Age *int `json:\"age,omitempty\"`", want: &FieldInfo{Name: "age", Type: "number | null | undefined", IsOptional: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package main\n\ntype T struct {\n\t" + tt.field + "\n}\n"
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "main.go", src, 0)
+			if err != nil {
+				t.Fatalf("Failed to parse source: %v", err)
+			}
+
+			structType := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+			astInfo := parseType("T", structType, defaultTypeMappings, nil, "")
+
+			pkg, err := (&types.Config{}).Check("main", fset, []*ast.File{file}, nil)
+			if err != nil {
+				t.Fatalf("Failed to type check source: %v", err)
+			}
+			typesInfo, err := parseTypeObject(pkg.Scope().Lookup("T"), defaultTypeMappings, "")
+			if err != nil {
+				t.Fatalf("Failed to parse type object: %v", err)
+			}
+
+			for path, fields := range map[string][]FieldInfo{"ast": astInfo.Fields, "types": typesInfo.Fields} {
+				if tt.want == nil {
+					if len(fields) != 0 {
+						t.Errorf("%s: expected the field to be skipped, got %+v", path, fields)
+					}
+					continue
+				}
+				if len(fields) != 1 {
+					t.Fatalf("%s: expected one field, got %+v", path, fields)
+				}
+				got := FieldInfo{Name: fields[0].Name, Type: fields[0].Type, IsOptional: fields[0].IsOptional}
+				if !reflect.DeepEqual(got, *tt.want) {
+					t.Errorf("%s: expected %+v, got %+v", path, *tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONStringOption(t *testing.T) {
+	src := `package main
+
+type Account struct {
+	ID      int64  ` + "`json:\"id,string\"`" + `
+	Parent  *int   ` + "`json:\"parent,string\"`" + `
+	Active  bool   ` + "`json:\"active,string\"`" + `
+	Scores  []int  ` + "`json:\"scores,string\"`" + `
+	Balance float64 ` + "`json:\"balance\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	structType := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	astInfo := parseType("Account", structType, defaultTypeMappings, nil, "")
+
+	pkg, err := (&types.Config{}).Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+	typesInfo, err := parseTypeObject(pkg.Scope().Lookup("Account"), defaultTypeMappings, "")
+	if err != nil {
+		t.Fatalf("Failed to parse type object: %v", err)
+	}
+
+	expected := map[string]string{
+		"id":      "string",
+		"parent":  "string | null",
+		"active":  "string",
+		"scores":  "Array<number>",
+		"balance": "number",
+	}
+	for path, typeInfo := range map[string]TypeInfo{"ast": astInfo, "types": typesInfo} {
+		if len(typeInfo.Fields) != len(expected) {
+			t.Fatalf("%s: expected %d fields, got %+v", path, len(expected), typeInfo.Fields)
+		}
+		for _, field := range typeInfo.Fields {
+			if field.Type != expected[field.Name] {
+				t.Errorf("%s: expected %s type %q, got %q", path, field.Name, expected[field.Name], field.Type)
+			}
+		}
+	}
+}
+
+func TestFieldDefaults(t *testing.T) {
+	src := `package main
+
+type Priority int
+
+const (
+	Low Priority = iota
+	High
+)
+
+type Settings struct {
+	Role     string   ` + "`json:\"role\" default:\"member\"`" + `
+	PageSize int      ` + "`json:\"page_size\" default:\"25\"`" + `
+	Active   bool     ` + "`json:\"active\" default:\"true\"`" + `
+	Priority Priority ` + "`json:\"priority\" default:\"1\"`" + `
+	Limit    int      ` + "`json:\"limit\" default:\"many\"`" + `
+	Name     string   ` + "`json:\"name\"`" + `
+}
+
+// @Method GET
+// @Path /settings
+// @Output Settings
+func GetSettingsHandler() {}
+`
+	dir := writeTestModule(t, map[string]string{"main.go": src})
+
+	typeInfos, handlers, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: typeInfos, Handlers: handlers})
+	expected := []string{
+		"export const SettingsDefaults = {",
+		`  role: "member",`,
+		"  page_size: 25,",
+		"  active: true,",
+		"  priority: 1,",
+		"} as const;",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s\n%s", str, content)
+		}
+	}
+	if strings.Contains(content, "many") {
+		t.Errorf("Defaults that don't match the field type should be left out:\n%s", content)
+	}
+
+	// The go/types path used for types from other packages
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	pkg, err := (&types.Config{}).Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+	typeInfo, err := parseTypeObject(pkg.Scope().Lookup("Settings"), defaultTypeMappings, "")
+	if err != nil {
+		t.Fatalf("Failed to parse type object: %v", err)
+	}
+	if got := typeInfo.Fields[1]; got.Default != "25" {
+		t.Errorf("Expected default 25 for page_size, got %q", got.Default)
+	}
+}
+
+func TestStorageKeyConstants(t *testing.T) {
+	handlers := []HandlerInfo{
+		{
+			Name:       "GetUser",
+			Method:     "GET",
+			Path:       "/users",
+			OutputType: "User",
+			Headers: []HeaderInfo{
+				parseHeaderDirective("localStorage:X-Auth-Token:auth_token", io.Discard),
+				parseHeaderDirective("sessionStorage:X-Session:session-id", io.Discard),
+				parseHeaderDirective("localStorage:X-Legacy:SESSION_ID", io.Discard),
+			},
+		},
+	}
+	types := []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number"}}}}
+
+	content := renderTestFile(t, GenerateFileOptions{
+		Types:            types,
+		Handlers:         handlers,
+		AuthToken:        "auth_token",
+		AuthTokenStorage: "localStorage",
+	})
+
+	expected := []string{
+		"export const AUTH_TOKEN_KEY = 'auth_token';",
+		"export const SESSION_ID_KEY = 'session-id';",
+		"export const SESSION_ID_KEY_2 = 'SESSION_ID';",
+		"const token = localStorage.getItem(AUTH_TOKEN_KEY);",
+		"const x_auth_tokenValue = localStorage.getItem(AUTH_TOKEN_KEY);",
+		"const x_sessionValue = sessionStorage.getItem(SESSION_ID_KEY);",
+		"const x_legacyValue = localStorage.getItem(SESSION_ID_KEY_2);",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+	if strings.Count(content, "export const AUTH_TOKEN_KEY") != 1 {
+		t.Errorf("Expected the auth token key to be declared once:\n%s", content)
+	}
+}
+
+func TestAliasChains(t *testing.T) {
+	src := `package main
+
+type A = B
+type B = C
+type C int
+
+type IDs = []ID
+type ID string
+
+type Record struct {
+	Count A   ` + "`json:\"count\"`" + `
+	Refs  IDs ` + "`json:\"refs\"`" + `
+	Next  *A  ` + "`json:\"next\"`" + `
+}
+
+// @Method GET
+// @Path /records/:id
+// @Output Record
+func GetRecordHandler() {}
+`
+	dir := writeTestModule(t, map[string]string{"main.go": src})
+
+	typeInfos, handlers, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: typeInfos, Handlers: handlers})
+	expected := []string{
+		"count: number;",
+		"refs: Array<string>;",
+		"next?: number | null;",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s\n%s", str, content)
+		}
+	}
+
+	// The go/types path used for types from other packages
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	pkg, err := (&types.Config{}).Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+	for name, want := range map[string]string{"A": "number", "B": "number", "IDs": "Array<string>"} {
+		got, _, _ := parseFieldTypeFromTypes(pkg.Scope().Lookup(name).Type(), defaultTypeMappings)
+		if got != want {
+			t.Errorf("Expected %s to resolve to %s, got %s", name, want, got)
+		}
+	}
+}
+
+func TestClean(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go2type.yaml": `packages:
+  - path: users
+    output_path: out/users.generated.ts
+  - path: orders
+    output_path: out/orders.generated.ts
+  - path: missing
+    output_path: out/missing.generated.ts
+`,
+		"out/users.generated.ts":  "export {};\n",
+		"out/orders.generated.ts": "export {};\n",
+		"out/other.ts":            "export {};\n",
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	// Declining the prompt keeps the files
+	if err := clean(CleanOptions{}, strings.NewReader("n\n")); err != nil {
+		t.Fatalf("Failed to clean: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "users.generated.ts")); err != nil {
+		t.Errorf("Expected output to be kept when the prompt is declined: %v", err)
+	}
+
+	if err := clean(CleanOptions{Force: true}, nil); err != nil {
+		t.Fatalf("Failed to clean: %v", err)
+	}
+	for _, name := range []string{"users.generated.ts", "orders.generated.ts"} {
+		if _, err := os.Stat(filepath.Join(dir, "out", name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "other.ts")); err != nil {
+		t.Errorf("Expected files not listed in the config to be kept: %v", err)
+	}
+
+	// --config names the configuration file whose outputs are removed
+	config := "packages:\n  - path: web\n    output_path: out/other.ts\n"
+	if err := os.WriteFile(filepath.Join(dir, "go2type.web.yaml"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	opts, err := parseCleanFlags([]string{"--force", "--config", "go2type.web.yaml"})
+	if err != nil || opts.ConfigFile != "go2type.web.yaml" {
+		t.Fatalf("Expected --config to be parsed, got %+v, %v", opts, err)
+	}
+	if err := clean(opts, nil); err != nil {
+		t.Fatalf("Failed to clean: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "other.ts")); !os.IsNotExist(err) {
+		t.Errorf("Expected the output of go2type.web.yaml to be removed, got %v", err)
+	}
+
+	// The files a split output is written to and the MSW handlers are removed with the output
+	writeTestFiles(t, dir, map[string]string{
+		"go2type.split.yaml": `emit_mocks: true
+packages:
+  - path: shop
+    output_path: split/shop.generated.ts
+    split: per-type
+  - path: admin
+    output_path: admin/admin.generated.ts
+    split: types-and-client
+`,
+		"shop/shop.go":                    "package shop\n\ntype Item struct {\n\tID int `json:\"id\"`\n}\n\n// @Method GET\n// @Path /items\n// @Output Item\nfunc GetItemHandler() {}\n",
+		"split/shop.generated.ts":         "export {};\n",
+		"split/Item.generated.ts":         "export {};\n",
+		"split/client.generated.ts":       "export {};\n",
+		"split/msw-handlers.generated.ts": "export {};\n",
+		"admin/admin.generated.ts":        "export {};\n",
+		"admin/types.generated.ts":        "export {};\n",
+		"admin/client.generated.ts":       "export {};\n",
+		"admin/other.ts":                  "export {};\n",
+	})
+	if err := clean(CleanOptions{Force: true, ConfigFile: "go2type.split.yaml"}, nil); err != nil {
+		t.Fatalf("Failed to clean: %v", err)
+	}
+	for _, name := range []string{
+		"split/shop.generated.ts", "split/Item.generated.ts", "split/client.generated.ts", "split/msw-handlers.generated.ts",
+		"admin/admin.generated.ts", "admin/types.generated.ts", "admin/client.generated.ts",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "admin", "other.ts")); err != nil {
+		t.Errorf("Expected files not listed in the config to be kept: %v", err)
+	}
+
+	// A bundle is removed instead of the outputs of its packages
+	writeTestFiles(t, dir, map[string]string{
+		"go2type.bundle.yaml":  "bundle: true\noutput_path: out/api.generated.ts\npackages:\n  - path: users\n    namespace: Users\n",
+		"out/api.generated.ts": "export {};\n",
+	})
+	if err := clean(CleanOptions{Force: true, ConfigFile: "go2type.bundle.yaml"}, nil); err != nil {
+		t.Fatalf("Failed to clean: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "api.generated.ts")); !os.IsNotExist(err) {
+		t.Errorf("Expected the bundle to be removed, got %v", err)
+	}
+}
+
+func TestAxiosClient(t *testing.T) {
+	handlers := []HandlerInfo{
+		{
+			Name:        "ListUsers",
+			Method:      "GET",
+			Path:        "/users",
+			OutputType:  "User",
+			QueryParams: []QueryParamInfo{{Key: "page", Name: "page", Type: "number", Optional: true}},
+			Headers:     []HeaderInfo{parseHeaderDirective("input:X-Request-ID", io.Discard)},
+		},
+		{
+			Name:       "CreateUser",
+			Method:     "POST",
+			Path:       "/users",
+			InputType:  "User",
+			OutputType: "User",
+		},
+	}
+	types := []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number"}}}}
+
+	content := renderTestFile(t, GenerateFileOptions{
+		Types:      types,
+		Handlers:   handlers,
+		AuthToken:  "auth_token",
+		HTTPClient: "axios",
+	})
+
+	expected := []string{
+		"import axios from 'axios';\nimport type { AxiosInstance, AxiosResponse } from 'axios';",
+		"let httpClient: AxiosInstance = axios.create();",
+		"export const setHTTPClient = (instance: AxiosInstance): void => {",
+		"const response = await httpClient.request<TOutput>({",
+		"data: method !== 'GET' ? input : undefined,",
+		"if (axios.isAxiosError(error) && error.response) {",
+		"apiError = new APIError(error.response.status, error.response.statusText, error.response.data as Record<string, unknown> | string);",
+		"export const ListUsersQuery = async (x_request_id: string, page?: number, onResponse?: (response: AxiosResponse) => void): Promise<User> => {",
+		"params['page'] = page;",
+		"headers['X-Request-ID'] = x_request_id;",
+		"return createQuery<void, User>('GET', url, undefined, headers, onResponse, params);",
+		"return createQuery<User, User>('POST', url, input, headers, onResponse);",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+
+	unexpected := []string{"await fetch(", "new URLSearchParams", "(response: Response)"}
+	for _, str := range unexpected {
+		if strings.Contains(content, str) {
+			t.Errorf("Unexpected string found in generated file: %s", str)
+		}
+	}
+}
+
+func TestImportPathPackage(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"api/users.go": `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`,
+		"go2type.yaml": `auth_token: token
+hooks: "false"
+packages:
+  - path: github.com/example/testmodule/api
+    output_path: out/api.generated.ts
+`,
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	pkgDir, err := resolvePackageDir("github.com/example/testmodule/api")
+	if err != nil {
+		t.Fatalf("Failed to resolve import path: %v", err)
+	}
+	want, _ := filepath.EvalSymlinks(filepath.Join(dir, "api"))
+	if got, _ := filepath.EvalSymlinks(pkgDir); got != want {
+		t.Errorf("Expected package directory %s, got %s", want, pkgDir)
+	}
+
+	if err := generate(GenerateOptions{}); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "out", "api.generated.ts"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	if !strings.Contains(string(content), "export const GetUserQuery = async") {
+		t.Errorf("Expected handler from the import path package:\n%s", content)
+	}
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	src := `package main
+
+type Base struct {
+	ID        int    ` + "`json:\"id\"`" + `
+	CreatedAt string ` + "`json:\"created_at\"`" + `
+}
+
+type User struct {
+	Base
+	Name string ` + "`json:\"name\"`" + `
+}
+
+type Audit struct {
+	By string ` + "`json:\"by\"`" + `
+}
+
+type Admin struct {
+	User
+	*Audit
+	Base  ` + "`json:\"base\"`" + `
+	Level int    ` + "`json:\"level\"`" + `
+	Name  string ` + "`json:\"display_name\"`" + `
+	ID    string ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /admins/:id
+// @Output Admin
+func GetAdminHandler() {}
+`
+	dir := writeTestModule(t, map[string]string{"main.go": src})
+
+	typeInfos, _, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	// Admin's own id shadows the promoted one and keeps its declaration position, as in encoding/json
+	expected := []FieldInfo{
+		{Name: "created_at", Type: "string"},
+		{Name: "name", Type: "string"},
+		{Name: "by", Type: "string", IsOptional: true},
+		{Name: "base", Type: "Base"},
+		{Name: "level", Type: "number"},
+		{Name: "display_name", Type: "string"},
+		{Name: "id", Type: "string"},
+	}
+	fieldsOf := func(fields []FieldInfo) []FieldInfo {
+		var got []FieldInfo
+		for _, field := range fields {
+			got = append(got, FieldInfo{Name: field.Name, Type: field.Type, IsOptional: field.IsOptional})
+		}
+		return got
+	}
+
+	var admin *TypeInfo
+	for i := range typeInfos {
+		if typeInfos[i].Name == "Admin" {
+			admin = &typeInfos[i]
+		}
+	}
+	if admin == nil {
+		t.Fatalf("Expected Admin type, got %+v", typeInfos)
+	}
+	if got := fieldsOf(admin.Fields); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected flattened fields %+v, got %+v", expected, got)
+	}
+
+	// The go/types path used for types from other packages
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	pkg, err := (&types.Config{}).Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+	typeInfo, err := parseTypeObject(pkg.Scope().Lookup("Admin"), defaultTypeMappings, "")
+	if err != nil {
+		t.Fatalf("Failed to parse type object: %v", err)
+	}
+	var names []string
+	for _, field := range typeInfo.Fields {
+		names = append(names, field.Name)
+	}
+	expectedNames := []string{"created_at", "name", "by", "base", "level", "display_name", "id"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("Expected fields %v from go/types, got %v", expectedNames, names)
+	}
+	if !typeInfo.Fields[2].IsOptional {
+		t.Errorf("Expected fields promoted from an embedded pointer to be optional")
+	}
+}
+
+func TestTaggedEmbeddedFields(t *testing.T) {
+	src := `package main
+
+type Embedded struct {
+	Tags  ` + "`json:\"tags,omitempty\"`" + `
+	Count ` + "`json:\"count,string\" default:\"1\"`" + `
+}
+
+type Named struct {
+	Tags  Tags  ` + "`json:\"tags,omitempty\"`" + `
+	Count Count ` + "`json:\"count,string\" default:\"1\"`" + `
+}
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	typeMappings := map[string]string{"Tags": "Array<string>", "Count": "number"}
+	var parsed []TypeInfo
+	for _, decl := range file.Decls {
+		spec := decl.(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+		parsed = append(parsed, parseType(spec.Name.Name, spec.Type.(*ast.StructType), typeMappings, nil, ""))
+	}
+
+	// An embedded field with a json tag name is nested under it, so its tag options apply as to any field
+	if !reflect.DeepEqual(parsed[0].Fields, parsed[1].Fields) {
+		t.Errorf("Expected tagged embedded fields %+v to match named fields %+v", parsed[0].Fields, parsed[1].Fields)
+	}
+	if tags := parsed[0].Fields[0]; tags.Type != "Array<string>" || !tags.IsOptional {
+		t.Errorf("Expected omitempty to make tags optional without null, got %+v", tags)
+	}
+	if count := parsed[0].Fields[1]; count.Type != "string" || count.Default != "1" {
+		t.Errorf("Expected the string option and default of count, got %+v", count)
+	}
+}
+
+func TestInterfaceFallback(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type Shape interface {
+	Area() float64
+}
+
+// @TSType { name: string }
+type Named interface {
+	Name() string
+}
+
+type Handler interface {
+	Handle()
+}
+
+type Circle struct {
+	Radius float64 ` + "`json:\"radius\"`" + `
+}
+
+type Drawing struct {
+	Main    Shape   ` + "`json:\"main\"`" + `
+	Shapes  []Shape ` + "`json:\"shapes\"`" + `
+	Label   Named   ` + "`json:\"label\"`" + `
+	Handler Handler ` + "`json:\"handler\"`" + `
+}
+
+// @Method GET
+// @Path /drawings/:id
+// @Output Drawing
+func GetDrawingHandler() {}
+`,
+	})
+
+	types, handlers, err := parsePackage(dir, ParseOptions{
+		InterfaceFallback: map[string]string{"Shape": "Circle"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+
+	expected := []string{
+		"main: Circle;",
+		"shapes: Array<Circle>;",
+		"label: { name: string };",
+		"handler: any;",
+		"export type Circle = {",
+		"radius: number;",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s\n%s", str, content)
+		}
+	}
+}
+
+func TestThrowsDoc(t *testing.T) {
+	src := `package api
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+// @Status 200 OK
+// @Error 404 User not found
+// @Status 403
+func GetUserHandler() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "handlers.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	handler := parseHandlerComments(f.Decls[0].(*ast.FuncDecl), nil, io.Discard)
+	if handler == nil {
+		t.Fatalf("Expected handler to be parsed")
+	}
+
+	expectedStatuses := []StatusInfo{
+		{Code: 200, Description: "OK"},
+		{Code: 404, Description: "User not found"},
+		{Code: 403},
+	}
+	if !reflect.DeepEqual(handler.Statuses, expectedStatuses) {
+		t.Errorf("Parsed statuses do not match expected.\nGot: %+v\nWant: %+v", handler.Statuses, expectedStatuses)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{
+		Types:    []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}},
+		Handlers: []HandlerInfo{*handler},
+	})
+
+	expectedDoc := `/**
+ * @throws {APIError} When the request fails with one of the following statuses:
+ * - 404: User not found
+ * - 403
+ */
+export const GetUserQuery = async (`
+	if !strings.Contains(content, expectedDoc) {
+		t.Errorf("Expected @throws JSDoc not found in generated file:\n%s", content)
+	}
+}
+
+func TestDeprecatedDirective(t *testing.T) {
+	src := `package api
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+// @Deprecated Use GetUserV2, which returns @Output UserV2
+// @Error 404 User not found
+func GetUserHandler() {}
+
+// @Method DELETE
+// @Path /users/:id
+// @Deprecated
+func DeleteUserHandler() {}
+
+// @Method GET
+// @Path /status
+func GetStatusHandler() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "handlers.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	var handlers []HandlerInfo
+	for _, decl := range f.Decls {
+		handlers = append(handlers, *parseHandlerComments(decl.(*ast.FuncDecl), nil, io.Discard))
+	}
+
+	if handlers[0].Deprecated != "Use GetUserV2, which returns @Output UserV2" || handlers[0].OutputType != "User" {
+		t.Errorf("Expected the deprecation reason not to be read as another directive, got %+v", handlers[0])
+	}
+	if !handlers[1].IsDeprecated || handlers[1].Deprecated != "" {
+		t.Errorf("Expected a deprecation without a reason, got %+v", handlers[1])
+	}
+	if handlers[2].IsDeprecated {
+		t.Errorf("Expected GetStatus not to be deprecated")
+	}
+
+	types := []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}}
+	for _, hooks := range []GenerateFileOptions{{UseReactQuery: true}, {UseSWR: true}, {}} {
+		hooks.Types, hooks.Handlers, hooks.UseHooks = types, handlers, true
+		content := renderTestFile(t, hooks)
+		for _, expected := range []string{
+			`/**
+ * @deprecated Use GetUserV2, which returns @Output UserV2
+ * @throws {APIError} When the request fails with one of the following statuses:
+ * - 404: User not found
+ */
+export const GetUserQuery = async (`,
+			"/** @deprecated */\nexport const DeleteUserQuery = async (",
+			"/** @deprecated Use GetUserV2, which returns @Output UserV2 */\nexport const useGetUser = (",
+			"/** @deprecated */\nexport const useDeleteUser = (",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected %q in generated file:\n%s", expected, content)
+			}
+		}
+		// The fifth is APIError's data alias
+		if strings.Count(content, "@deprecated") != 5 {
+			t.Errorf("Expected only the deprecated handlers to be marked, got %d", strings.Count(content, "@deprecated"))
+		}
+	}
+}
+
+func TestDescriptionDirective(t *testing.T) {
+	src := `package api
+
+// @Method GET
+// @Path /users/:id
+// @Description Fetches a user by ID.
+// @Description Archived users are returned too, see @Output User.
+// @Output User
+// @Error 404 User not found
+func GetUserHandler() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "handlers.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	handler := parseHandlerComments(f.Decls[0].(*ast.FuncDecl), nil, io.Discard)
+	if handler.Description != "Fetches a user by ID.\nArchived users are returned too, see @Output User." || handler.OutputType != "User" {
+		t.Fatalf("Expected a line per @Description, not read as other directives, got %+v", handler)
+	}
+
+	types := []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}}
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: []HandlerInfo{*handler}, EmitMeta: true})
+	for _, expected := range []string{
+		`/**
+ * Fetches a user by ID.
+ * Archived users are returned too, see @Output User.
+ *
+ * @throws {APIError} When the request fails with one of the following statuses:
+ * - 404: User not found
+ */
+export const GetUserQuery = async (`,
+		`description: 'Fetches a user by ID.\u000AArchived users are returned too, see @Output User.' },`,
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q in generated file:\n%s", expected, content)
+		}
+	}
+
+	doc := buildOpenAPIDocument("Users", types, []HandlerInfo{*handler}, false)
+	if op := doc.Paths["/users/{id}"]["get"]; op == nil || op.Description != handler.Description {
+		t.Errorf("Expected the description on the OpenAPI operation, got %+v", op)
+	}
+}
+
+func TestOutputConcreteType(t *testing.T) {
+	src := `package api
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Radius float64 ` + "`json:\"radius\"`" + `
+}
+
+func (c Circle) Area() float64 { return 3.14 * c.Radius * c.Radius }
+
+// @Method GET
+// @Path /shapes/:id
+// @Output Shape as Circle
+func GetShapeHandler() Shape { return Circle{} }
+`
+	types, handlers, err := parseSource([]byte(src), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	if len(handlers) != 1 || handlers[0].OutputType != "Circle" {
+		t.Fatalf("Expected the response to be typed as the concrete type, got %+v", handlers)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+	for _, expected := range []string{
+		"export type Circle = {",
+		"radius: number;",
+		"export const GetShapeQuery = async (id: string, onResponse?: (response: Response) => void): Promise<Circle> => {",
+		"return createQuery<void, Circle>('GET', url, undefined, headers, onResponse);",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q in generated file:\n%s", expected, content)
+		}
+	}
+
+	if problems := directiveProblems("// @Output Shape as Circle", map[string]bool{"Circle": true}); len(problems) != 0 {
+		t.Errorf("Expected only the concrete type to be validated, got %v", problems)
+	}
+}
+
+func TestEqualsSignDirectives(t *testing.T) {
+	src := `package api
+
+// @Method=POST
+// @Path = /users/:id/avatar
+// @Input=UploadAvatarInput
+// @Output= User
+// @Header=localStorage:X-Auth-Token:auth_token
+// @Error=413 Avatar too large
+func UploadAvatarHandler() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "handlers.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	handler := parseHandlerComments(f.Decls[0].(*ast.FuncDecl), nil, io.Discard)
+	if handler == nil {
+		t.Fatalf("Expected handler to be parsed")
+	}
+
+	expected := HandlerInfo{
+		Name:       "UploadAvatar",
+		Method:     "POST",
+		Path:       "/users/:id/avatar",
+		InputType:  "UploadAvatarInput",
+		OutputType: "User",
+		URLParams:  []string{"id"},
+		Headers: []HeaderInfo{
+			{HeaderKey: "X-Auth-Token", SafeName: "x_auth_token", Source: "localStorage", StorageKey: "auth_token"},
+		},
+		Statuses: []StatusInfo{{Code: 413, Description: "Avatar too large"}},
+	}
+	if !reflect.DeepEqual(*handler, expected) {
+		t.Errorf("Parsed handler does not match expected.\nGot: %+v\nWant: %+v", *handler, expected)
+	}
+}
+
+func TestVersionDirective(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Version v2
+// @Output User
+func GetUserHandler() {}
+
+// @Method GET
+// @Path /status
+// @Output User
+func GetStatusHandler() {}
+`,
+	})
+
+	types, handlers, err := parsePackage(dir, ParseOptions{VersionPathTemplate: "/api/:version/"})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	paths := make(map[string]HandlerInfo)
+	for _, h := range handlers {
+		paths[h.Name] = h
+	}
+	if got := paths["GetUser"]; got.Path != "/api/v2/users/:id" || len(got.URLParams) != 1 || got.URLParams[0] != "id" {
+		t.Errorf("Expected versioned path /api/v2/users/:id with param id, got %s %v", got.Path, got.URLParams)
+	}
+	if got := paths["GetStatus"].Path; got != "/status" {
+		t.Errorf("Expected unversioned handler path to be unchanged, got %s", got)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, APIConfig: true})
+	expected := []string{
+		"let url = '/api/v2/users/:id'",
+		"const response = await fetch(apiConfig.baseUrl + url, requestOptions);",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+
+	if got := versionedPath("", "v1", "/users"); got != "/v1/users" {
+		t.Errorf("Expected default version prefix /v1/users, got %s", got)
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type UserFilter struct {
+	Role string ` + "`json:\"role\"`" + `
+}
+
+// @Method GET
+// @Path /users
+// @Query page?:number
+// @Query sort
+// @Query UserFilter
+// @Output User
+func ListUsersHandler() {}
+`,
+	})
+
+	types, handlers, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+	if len(handlers) != 1 || len(handlers[0].QueryParams) != 3 {
+		t.Fatalf("Expected one handler with three query params, got %+v", handlers)
+	}
+	expectedParams := []QueryParamInfo{
+		{Key: "page", Name: "page", Type: "number", Optional: true},
+		{Key: "sort", Name: "sort", Type: "string"},
+		{Key: "queryParams", Name: "queryParams", Type: "UserFilter", Struct: true},
+	}
+	if !reflect.DeepEqual(handlers[0].QueryParams, expectedParams) {
+		t.Errorf("Expected query params %+v, got %+v", expectedParams, handlers[0].QueryParams)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseHooks: true, UseReactQuery: true})
+
+	expected := []string{
+		"export const ListUsersQuery = async (sort: string, queryParams: UserFilter, page?: number, onResponse?: (response: Response) => void): Promise<User> => {",
+		"let url = '/users'",
+		"if (page !== undefined) {",
+		"queryParamEntries('page', page).forEach((entry) => searchParams.append(...entry));",
+		"Object.entries(queryParams).forEach(([key, value]) => {",
+		"url += (url.includes('?') ? '&' : '?') + searchParams.toString();",
+		"queryKey: ['ListUsers', sort, queryParams, page],",
+		"[string, string, UserFilter, number | undefined]",
+		"queryFn: () => ListUsersQuery(sort, queryParams, page),",
+		"export type UserFilter = {",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+}
+
+func TestArrayQueryFormat(t *testing.T) {
+	handlers := []HandlerInfo{{
+		Name:        "ListUsers",
+		Method:      "GET",
+		Path:        "/users",
+		OutputType:  "string",
+		QueryParams: []QueryParamInfo{{Key: "ids", Name: "ids", Type: "Array<number>"}},
+	}}
+	if format := validArrayQueryFormat("semicolon", io.Discard); format != "" {
+		t.Errorf("Expected unknown formats to repeat keys, got %q", format)
+	}
+
+	node, nodeErr := exec.LookPath("node")
+	for _, tc := range []struct {
+		format   string
+		expected string
+	}{
+		{"", "ids=1&ids=2&page=3"},
+		{"bracket", "ids%5B%5D=1&ids%5B%5D=2&page=3"},
+		{"comma", "ids=1%2C2&page=3"},
+	} {
+		content := renderTestFile(t, GenerateFileOptions{Handlers: handlers, ArrayQueryFormat: tc.format})
+		if !strings.Contains(content, "queryParamEntries('ids', ids).forEach((entry) => searchParams.append(...entry));") {
+			t.Errorf("Expected the query params to be serialized by queryParamEntries, got:\n%s", content)
+		}
+		angular := renderTestFile(t, GenerateFileOptions{Handlers: handlers, ArrayQueryFormat: tc.format, UseAngular: true})
+		helper := regexp.MustCompile(`(?s)function queryParamEntries\(.*?\n\}\n`).FindString(content)
+		if helper == "" || !strings.Contains(angular, helper) {
+			t.Errorf("Expected the same queryParamEntries in the client and the Angular service, got:\n%s\n%s", content, angular)
+		}
+
+		if nodeErr != nil {
+			continue
+		}
+		script := strings.NewReplacer(
+			": Array<[string, string]>", "",
+			": [string, string]", "",
+			": string", "",
+			": unknown", "",
+		).Replace(helper) + `
+const searchParams = new URLSearchParams();
+[['ids', [1, 2]], ['page', 3]].forEach(([key, value]) => {
+  queryParamEntries(key, value).forEach((entry) => searchParams.append(...entry));
+});
+if (searchParams.toString() !== '` + tc.expected + `') {
+  throw new Error('Unexpected query string ' + searchParams.toString());
+}
+`
+		if output, err := exec.Command(node, "-e", script).CombinedOutput(); err != nil {
+			t.Errorf("Query string check for %q failed: %v\n%s\n%s", tc.format, err, output, script)
+		}
+	}
+
+	for format, expected := range map[string]string{
+		"":        "paramsSerializer: { indexes: null },",
+		"bracket": "paramsSerializer: { indexes: false },",
+		"comma":   "params['ids'] = Array.isArray(ids) ? ids.join(',') : ids;",
+	} {
+		content := renderTestFile(t, GenerateFileOptions{Handlers: handlers, ArrayQueryFormat: format, HTTPClient: "axios"})
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected axios to send arrays as %q, got:\n%s", format, content)
+		}
+	}
+}
+
+func TestBatchHook(t *testing.T) {
+	src := `package api
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+// @Batch GetUsers
+func GetUserHandler() {}
+
+// @Method GET
+// @Path /accounts/:id
+// @Output Account
+// @Batch
+func GetAccountHandler() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "handlers.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	var handlers []HandlerInfo
+	for _, decl := range f.Decls {
+		if handler := parseHandlerComments(decl.(*ast.FuncDecl), nil, io.Discard); handler != nil {
+			handlers = append(handlers, *handler)
+		}
+	}
+	if len(handlers) != 2 || handlers[0].Batch != "GetUsers" || handlers[1].Batch != "GetAccountBatch" {
+		t.Fatalf("Expected @Batch names GetUsers and GetAccountBatch, got %+v", handlers)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{
+		Types: []TypeInfo{
+			{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+			{Name: "Account", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+		},
+		Handlers:      handlers,
+		UseHooks:      true,
+		UseReactQuery: true,
+	})
+
+	expected := []string{
+		"import { useQuery, useQueries, useMutation } from '@tanstack/react-query';",
+		"export const useGetUsers = (",
+		"ids: Array<string>,",
+		"): Array<UseQueryResult<User, APIError>> =>",
+		"queries: ids.map((id): UseQueryOptions<User, APIError, User, [string, string]> => ({",
+		"queryKey: ['GetUser', id],",
+		"queryFn: () => GetUserQuery(id),",
+		"export const useGetAccountBatch = (",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+}
+
+func TestReactHookStatus(t *testing.T) {
+	content := renderTestFile(t, GenerateFileOptions{
+		Types: []TypeInfo{
+			{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+		},
+		Handlers: []HandlerInfo{
+			{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+			{Name: "DeleteUser", Method: "DELETE", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+		},
+		UseHooks: true,
+	})
+
+	expected := []string{
+		"onResponse?: (response: Response) => void",
+		"onResponse?.(response);",
+		"export const GetUserQuery = async (id: string, onResponse?: (response: Response) => void): Promise<User> => {",
+		"const [status, setStatus] = useState<number | null>(null);",
+		"id, (response) => setStatus(response.status)",
+		"setStatus(e instanceof APIError && e.status ? e.status : null);",
+		"return { data, error, isLoading, status, query };",
+		"return { data, error, isLoading, status, mutate };",
+	}
+	for _, str := range expected {
+		if !strings.Contains(content, str) {
+			t.Errorf("Expected string not found in generated file: %s", str)
+		}
+	}
+}
+
+func TestBundleNamespaces(t *testing.T) {
+	namespaces := []NamespaceInfo{
+		{
+			Name: "Users",
+			Types: []TypeInfo{
+				{Name: "User", Fields: []FieldInfo{
+					{Name: "id", Type: "number", JSONName: "id"},
+					{Name: "profile", Type: "ModelsProfile", JSONName: "profile"},
+					{Name: "history", Type: "Array<ModelsProfile>", JSONName: "history"},
+				}},
+				{Name: "ModelsProfile", Fields: []FieldInfo{{Name: "bio", Type: "string", JSONName: "bio"}}},
+				{Name: "GetUserInput", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+			},
+			Handlers: []HandlerInfo{
+				{Name: "GetUser", Method: "GET", Path: "/users/:id", InputType: "GetUserInput", OutputType: "User", URLParams: []string{"id"}},
+				{Name: "GetUserProfile", Method: "GET", Path: "/users/:id/profile", InputType: "GetUserInput", OutputType: "ModelsProfile", URLParams: []string{"id"}},
+			},
+		},
+		{
+			Name:  "Models",
+			Types: []TypeInfo{{Name: "Profile", Fields: []FieldInfo{{Name: "bio", Type: "string", JSONName: "bio"}}}},
+			Handlers: []HandlerInfo{
+				{Name: "ListProfiles", Method: "GET", Path: "/profiles", OutputType: "Array<Profile>"},
+			},
+		},
+	}
+	qualifyNamespaceTypes(namespaces)
+
+	content := renderTestFile(t, GenerateFileOptions{Namespaces: namespaces, AuthToken: "token"})
+	for _, expected := range []string{
+		"export namespace Users {",
+		"export namespace Models {",
+		"profile: Models.Profile;",
+		"history: Array<Models.Profile>;",
+		"Promise<Models.Profile>",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected bundle to contain %q", expected)
+		}
+	}
+	if strings.Contains(content, "ModelsProfile") {
+		t.Errorf("Expected the copy of Models.Profile to be replaced by a namespace reference")
+	}
+	if count := strings.Count(content, "async function createQuery<"); count != 1 {
+		t.Errorf("Expected the request helpers to be emitted once, got %d", count)
+	}
+
+	tsc, err := exec.LookPath("tsc")
+	if err != nil {
+		t.Skip("tsc not found, skipping compile check")
+	}
+	file := filepath.Join(createTempFolder(t.Name()), "bundle.ts")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	cmd := exec.Command(tsc, "--noEmit", "--strict", "--target", "es2020", "--lib", "es2020,dom", file)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("Expected bundle to compile: %v\n%s", err, output)
+	}
+}
+
+func TestNamespaceName(t *testing.T) {
+	tests := map[string]string{
+		"./api/users":                 "Users",
+		"github.com/org/repo/billing": "Billing",
+		"user-service":                "UserService",
+		"./v2":                        "V2",
+		"2fa":                         "_2Fa",
+	}
+	for pkgPath, expected := range tests {
+		if got := namespaceName(pkgPath); got != expected {
+			t.Errorf("namespaceName(%q) = %q, expected %q", pkgPath, got, expected)
+		}
+	}
+}
+
+func TestBundleRequiresOutputPath(t *testing.T) {
+	err := generateBundle(&Config{Bundle: true}, GenerateOptions{}, GenerateFileOptions{})
+	if err == nil || !strings.Contains(err.Error(), "output_path") {
+		t.Errorf("Expected an error about the missing output_path, got %v", err)
+	}
+}
+
+func TestPackageAuthToken(t *testing.T) {
+	tests := []struct {
+		name            string
+		group           []PackageConfig
+		expectedToken   string
+		expectedStorage string
+	}{
+		{"global", []PackageConfig{{Path: "users"}}, "auth_token", "localStorage"},
+		{"token override", []PackageConfig{{Path: "billing", AuthToken: "billing_token"}}, "billing_token", "localStorage"},
+		{"storage override", []PackageConfig{{Path: "billing", AuthTokenStorage: "sessionStorage"}}, "auth_token", "sessionStorage"},
+		{"first override wins", []PackageConfig{{Path: "a"}, {Path: "b", AuthToken: "b_token"}, {Path: "c", AuthToken: "c_token"}}, "b_token", "localStorage"},
+		{"unknown storage", []PackageConfig{{Path: "a", AuthTokenStorage: "cookie"}}, "auth_token", "localStorage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, storage := groupAuthToken(tt.group, "auth_token", "localStorage", io.Discard)
+			if token != tt.expectedToken || storage != tt.expectedStorage {
+				t.Errorf("Expected %s in %s, got %s in %s", tt.expectedToken, tt.expectedStorage, token, storage)
+			}
+		})
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{
+		Handlers:         []HandlerInfo{{Name: "GetInvoice", Method: "GET", Path: "/invoices", OutputType: "string"}},
+		AuthToken:        "billing_token",
+		AuthTokenStorage: "sessionStorage",
+	})
+	if !strings.Contains(content, "sessionStorage.getItem(BILLING_TOKEN_KEY)") {
+		t.Errorf("Expected the package's storage key to be used for the auth header")
+	}
+}
+
+func TestZeroTimeAsNull(t *testing.T) {
+	src := "package main\n\nimport \"time\"\n\ntype Event struct {\n\tStartsAt time.Time `json:\"starts_at\"`\n\tEndsAt *time.Time `json:\"ends_at\"`\n}\n"
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	typeMappings := map[string]string{"time.Time": "string /* date-time */ | null"}
+	structType := file.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	info := parseType("Event", structType, typeMappings, nil, "")
+	for _, field := range info.Fields {
+		if field.Type != "string /* date-time */ | null" {
+			t.Errorf("Expected %s to be nullable once, got %s", field.Name, field.Type)
+		}
+	}
+
+	handlers := []HandlerInfo{{Name: "GetEvent", Method: "GET", Path: "/event", OutputType: "Event"}}
+	for _, useDateObject := range []bool{false, true} {
+		content := renderTestFile(t, GenerateFileOptions{Handlers: handlers, ZeroTimeAsNull: true, UseDateObject: useDateObject})
+		if !strings.Contains(content, "dateString.startsWith('0001-01-01T00:00:00') ? null :") {
+			t.Errorf("Expected the zero time to be converted to null (use_date_object: %v)", useDateObject)
+		}
+		if !strings.Contains(content, "? reviveDate(value) : value") {
+			t.Errorf("Expected responses to be revived with reviveDate (use_date_object: %v)", useDateObject)
+		}
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Handlers: handlers})
+	if strings.Contains(content, "reviveDate") {
+		t.Errorf("Expected no date reviver without zero_time_as_null")
+	}
+}
+
+func TestAngularService(t *testing.T) {
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"},
+			QueryParams: []QueryParamInfo{{Key: "fields", Name: "fields", Type: "string", Optional: true}}},
+		{Name: "UpdateUser", Method: "PUT", Path: "/users/:id", InputType: "UpdateUserInput", OutputType: "User", URLParams: []string{"id"}},
+	}
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+		{Name: "UpdateUserInput", Fields: []FieldInfo{{Name: "name", Type: "string", JSONName: "name"}}},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseAngular: true, AuthToken: "token"})
+	for _, expected := range []string{
+		"import { HttpClient, HttpParams } from '@angular/common/http'",
+		"@Injectable({ providedIn: 'root' })",
+		"export class APIService {",
+		"constructor(private http: HttpClient) {}",
+		"getUser(id: string, fields?: string): Observable<User> {",
+		"queryParamEntries('fields', fields).forEach((entry) => {\n        params = params.append(...entry);",
+		"return this.http.request<User>('GET', url, {",
+		"updateUser(id: string, input: UpdateUserInput): Observable<User> {",
+		"body: input,",
+		"export type UpdateUserInput = {",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected Angular service to contain %q", expected)
+		}
+	}
+	for _, unexpected := range []string{"async function createQuery", "GetUserQuery", "export const queries"} {
+		if strings.Contains(content, unexpected) {
+			t.Errorf("Expected the fetch client to be replaced, found %q", unexpected)
+		}
+	}
+}
+
+func TestStdlibTypeMappings(t *testing.T) {
+	src := `package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type Job struct {
+	Timeout  time.Duration   ` + "`json:\"timeout\"`" + `
+	Payload  json.RawMessage ` + "`json:\"payload\"`" + `
+	Note     sql.NullString  ` + "`json:\"note\"`" + `
+	Attempts sql.NullInt64   ` + "`json:\"attempts\"`" + `
+	Retry    *sql.NullInt64  ` + "`json:\"retry\"`" + `
+}
+`
+	expected := map[string]string{
+		"timeout":  "number /* nanoseconds */",
+		"payload":  "unknown",
+		"note":     "string | null",
+		"attempts": "number | null",
+		"retry":    "number | null",
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	structType := file.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	astInfo := parseType("Job", structType, defaultTypeMappings, nil, "")
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+	typesInfo, err := parseTypeObject(pkg.Scope().Lookup("Job"), defaultTypeMappings, "")
+	if err != nil {
+		t.Fatalf("Failed to parse type object: %v", err)
+	}
+
+	for path, fields := range map[string][]FieldInfo{"ast": astInfo.Fields, "types": typesInfo.Fields} {
+		for _, field := range fields {
+			if field.Type != expected[field.Name] {
+				t.Errorf("%s: expected %s to be %s, got %s", path, field.Name, expected[field.Name], field.Type)
+			}
+		}
+	}
+
+	// Mappings can be overridden, e.g. for durations serialized as strings
+	typeMappings := map[string]string{"time.Duration": "string"}
+	astInfo = parseType("Job", structType, typeMappings, nil, "")
+	if astInfo.Fields[0].Type != "string" {
+		t.Errorf("Expected time.Duration to be overridable, got %s", astInfo.Fields[0].Type)
+	}
+}
+
+func TestRecursivePackage(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"api/users.go": `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`,
+		"api/admin/admin.go": `package admin
+
+type Admin struct {
+	Role string ` + "`json:\"role\"`" + `
+}
+
+// @Method GET
+// @Path /admins
+// @Output Admin
+func ListAdminsHandler() {}
+`,
+		"api/models/models.go": `package models
+
+type Profile struct {
+	Bio string ` + "`json:\"bio\"`" + `
+}
+
+// @Method GET
+// @Path /profiles/:id
+// @Output Profile
+func GetProfileHandler() {}
+`,
+		"api/orders/orders.go": `package orders
+
+import "github.com/example/testmodule/api/models"
+
+type Order struct {
+	Buyer models.Profile ` + "`json:\"buyer\"`" + `
+}
+
+// @Method GET
+// @Path /orders/:id
+// @Output Order
+func GetOrderHandler() {}
+`,
+		"api/admin/testdata/ignored.go": `package ignored
+
+// @Method GET
+// @Path /ignored
+func IgnoredHandler() {}
+`,
+	})
+
+	pkg := PackageConfig{Path: filepath.Join(dir, "api"), Recursive: true}
+	dirs, err := packageDirs(pkg)
+	if err != nil {
+		t.Fatalf("Failed to find package directories: %v", err)
+	}
+	if len(dirs) != 4 {
+		t.Errorf("Expected the package and its three sub-packages, got %v", dirs)
+	}
+
+	pkgTypes, handlers, err := parseConfiguredPackage(&Config{}, pkg, io.Discard)
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	handlerNames := make(map[string]bool)
+	for _, h := range handlers {
+		handlerNames[h.Name] = true
+	}
+	for _, name := range []string{"GetUser", "ListAdmins", "GetProfile", "GetOrder"} {
+		if !handlerNames[name] {
+			t.Errorf("Expected handler %s, got %v", name, handlerNames)
+		}
+	}
+	if handlerNames["Ignored"] {
+		t.Errorf("Expected handlers in testdata to be skipped")
+	}
+
+	typeNames := make(map[string]TypeInfo)
+	for _, ty := range pkgTypes {
+		typeNames[ty.Name] = ty
+	}
+	for _, name := range []string{"User", "Admin", "Profile", "Order"} {
+		if _, ok := typeNames[name]; !ok {
+			t.Errorf("Expected type %s, got %v", name, typeNames)
+		}
+	}
+	if _, ok := typeNames["ModelsProfile"]; ok {
+		t.Errorf("Expected the copy of models.Profile to be replaced by its declaration")
+	}
+	if order := typeNames["Order"]; len(order.Fields) != 1 || order.Fields[0].Type != "Profile" {
+		t.Errorf("Expected Order.buyer to reference Profile, got %+v", order.Fields)
+	}
+}
+
+func TestGenerateDryRun(t *testing.T) {
+	source := `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`
+	dir := writeTestModule(t, map[string]string{
+		"api/users.go": source,
+		"go2type.yaml": `auth_token: token
+hooks: "false"
+packages:
+  - path: api
+    output_path: out/api.generated.ts
+`,
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	outputPath := filepath.Join(dir, "out", "api.generated.ts")
+
+	// A dry run of a new file shows all of it as added, without writing it or the formatter's messages
+	output := captureOutput(t, func() {
+		if err := generate(GenerateOptions{DryRun: true, ShouldFormat: true}); err != nil {
+			t.Errorf("Failed to dry run: %v", err)
+		}
+	})
+	if !strings.Contains(output, "+++ b/out/api.generated.ts") || !strings.Contains(output, "+export type User = {") {
+		t.Errorf("Expected a diff adding the generated file, got:\n%s", output)
+	}
+	if strings.Contains(output, "Formatted") {
+		t.Errorf("Expected the formatter's messages to be left out of the diff, got:\n%s", output)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("Expected a dry run not to write the file, got %v", err)
+	}
+
+	if err := generate(GenerateOptions{}); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	generated, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	// Regenerating at a later time changes nothing but the timestamp, which isn't a change
+	output = captureOutput(t, func() {
+		if err := generate(GenerateOptions{DryRun: true, Check: true}); err != nil {
+			t.Errorf("Expected check to pass for up to date output: %v", err)
+		}
+	})
+	if !strings.Contains(output, "no changes") {
+		t.Errorf("Expected no changes, got:\n%s", output)
+	}
+
+	writeTestFiles(t, dir, map[string]string{
+		"api/users.go": strings.Replace(source, "ID int", "Name string `json:\"name\"`\n\tID int", 1),
+	})
+	var checkErr error
+	output = captureOutput(t, func() {
+		checkErr = generate(GenerateOptions{DryRun: true, Check: true})
+	})
+	if !strings.Contains(output, "+  name: string;") {
+		t.Errorf("Expected the diff to show the new field, got:\n%s", output)
+	}
+	if checkErr == nil {
+		t.Errorf("Expected check to fail for out of date output")
+	}
+	if current, _ := os.ReadFile(outputPath); string(current) != string(generated) {
+		t.Errorf("Expected a dry run to leave the existing file untouched")
+	}
+
+	// A package that doesn't parse can't be compared, so check fails instead of finding no changes
+	writeTestFiles(t, dir, map[string]string{"api/users.go": "package api\n\nfunc {"})
+	output = captureOutput(t, func() {
+		checkErr = generate(GenerateOptions{DryRun: true, Check: true})
+	})
+	if checkErr == nil || strings.Contains(output, "no changes") {
+		t.Errorf("Expected check to fail for a package that doesn't parse, got %v:\n%s", checkErr, output)
+	}
+}
+
+func TestMapOfSliceTypes(t *testing.T) {
+	src := `package main
+
+import (
+	"net/http"
+	"net/url"
+)
+
+type Params map[string][]string
+
+type Request struct {
+	Filters map[string][]string ` + "`json:\"filters\"`" + `
+	Query   url.Values          ` + "`json:\"query\"`" + `
+	Headers http.Header         ` + "`json:\"headers\"`" + `
+	Nested  map[string]map[string][]int ` + "`json:\"nested\"`" + `
+}
+`
+	expected := map[string]string{
+		"filters": "{ [key: string]: Array<string> }",
+		"query":   "{ [key: string]: Array<string> }",
+		"headers": "{ [key: string]: Array<string> }",
+		"nested":  "{ [key: string]: { [key: string]: Array<number> } }",
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	structType := file.Decls[2].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	astInfo := parseType("Request", structType, defaultTypeMappings, nil, "")
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+	typesInfo, err := parseTypeObject(pkg.Scope().Lookup("Request"), defaultTypeMappings, "")
+	if err != nil {
+		t.Fatalf("Failed to parse type object: %v", err)
+	}
+
+	for path, fields := range map[string][]FieldInfo{"ast": astInfo.Fields, "types": typesInfo.Fields} {
+		if len(fields) != len(expected) {
+			t.Errorf("%s: expected %d fields, got %+v", path, len(expected), fields)
+		}
+		for _, field := range fields {
+			if field.Type != expected[field.Name] {
+				t.Errorf("%s: expected %s to be %s, got %s", path, field.Name, expected[field.Name], field.Type)
+			}
+		}
+	}
+
+	// Named map types without a mapping are emitted as their underlying type
+	tsType, _, _ := parseFieldTypeFromTypes(pkg.Scope().Lookup("Params").Type(), defaultTypeMappings)
+	if tsType != "{ [key: string]: Array<string> }" {
+		t.Errorf("Expected the named map type to be emitted as its underlying type, got %s", tsType)
+	}
+}
+
+func TestMapStructValues(t *testing.T) {
+	src := `package main
+
+type UserInfo struct {
+	Name    string  ` + "`json:\"name\"`" + `
+	Address Address ` + "`json:\"address\"`" + `
+}
+
+type Address struct {
+	City string ` + "`json:\"city\"`" + `
+}
+
+type Config struct {
+	Users  map[string]UserInfo           ` + "`json:\"users\"`" + `
+	ByID   map[int]*UserInfo             ` + "`json:\"by_id\"`" + `
+	Groups map[string]map[string][]Address ` + "`json:\"groups\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+
+	obj := pkg.Scope().Lookup("Config")
+	typeInfo, err := parseTypeObject(obj, defaultTypeMappings, "")
+	if err != nil {
+		t.Fatalf("Failed to parse type object: %v", err)
+	}
+	expected := map[string][2]string{
+		"users":  {"{ [key: string]: UserInfo }", "UserInfo"},
+		"by_id":  {"{ [key: number]: UserInfo | null }", "UserInfo"},
+		"groups": {"{ [key: string]: { [key: string]: Array<Address> } }", "Address"},
+	}
+	for _, field := range typeInfo.Fields {
+		if field.Type != expected[field.Name][0] || field.PackageName != expected[field.Name][1] {
+			t.Errorf("Expected %s to be %v, got %s (%s)", field.Name, expected[field.Name], field.Type, field.PackageName)
+		}
+	}
+
+	// The structs used as map values are resolved along with the structs they use
+	nested := referencedStructTypes(obj, defaultTypeMappings, "")
+	var names []string
+	for _, n := range nested {
+		names = append(names, n.Name)
+	}
+	if strings.Join(names, ",") != "UserInfo,Address" {
+		t.Fatalf("Expected UserInfo and Address to be referenced, got %v", names)
+	}
+	if nested[0].Fields[1].Type != "Address" {
+		t.Errorf("Expected UserInfo.address to be Address, got %s", nested[0].Fields[1].Type)
+	}
+
+	registry := &TypeRegistry{Types: map[string]TypeInfo{"Address": {Name: "Address"}}}
+	addReferencedTypes(registry, nested, false)
+	if _, ok := registry.GetType("UserInfo"); !ok {
+		t.Errorf("Expected UserInfo to be registered")
+	}
+	if address, _ := registry.GetType("Address"); len(address.Fields) != 0 {
+		t.Errorf("Expected the registered Address type to be kept")
+	}
+
+	// The AST path reports the map value type so it is resolved like other fields
+	structType := file.Decls[2].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	astInfo := parseType("Config", structType, defaultTypeMappings, nil, "")
+	for _, field := range astInfo.Fields {
+		if field.Type != expected[field.Name][0] || field.PackageName != expected[field.Name][1] {
+			t.Errorf("ast: expected %s to be %v, got %s (%s)", field.Name, expected[field.Name], field.Type, field.PackageName)
+		}
+	}
+}
+
+func TestGenerateStdin(t *testing.T) {
+	src := `package api
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"example.com/billing"
+)
+
+type User struct {
+	ID        uuid.UUID       ` + "`json:\"id\"`" + `
+	Name      string          ` + "`json:\"name\"`" + `
+	CreatedAt time.Time       ` + "`json:\"created_at\"`" + `
+	Plan      billing.Plan    ` + "`json:\"plan\"`" + `
+	Invoices  []billing.Invoice ` + "`json:\"invoices\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Error abc Not found
+// @Output User
+func GetUserHandler() {}
+`
+	opts, err := parseGenerateFlags([]string{"-"})
+	if err != nil || !opts.Stdin {
+		t.Fatalf("Expected - to select stdin mode, got %+v (%v)", opts, err)
+	}
+	opts.ShouldFormat = false
+
+	var out, messages bytes.Buffer
+	opts.Stdout, opts.Stderr = &out, &messages
+	if err := generateStdin(opts, strings.NewReader(src)); err != nil {
+		t.Fatalf("Failed to generate from stdin: %v", err)
+	}
+	content := out.String()
+
+	// Warnings go to stderr, so stdout has only the TypeScript
+	if strings.Contains(content, "Warning") {
+		t.Errorf("Expected no warnings in the generated output:\n%s", content)
+	}
+	if !strings.Contains(messages.String(), "Warning: Invalid status code in directive: abc Not found") {
+		t.Errorf("Expected the warning on stderr, got %q", messages.String())
+	}
+
+	for _, expected := range []string{
+		"id: string /* uuid */;",
+		"created_at: string /* date-time */;",
+		"plan: unknown;",
+		"invoices: Array<unknown>;",
+		"export const GetUserQuery = async (id: string",
+		"const response = await fetch(url, requestOptions);",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q in generated output:\n%s", expected, content)
+		}
+	}
+	if strings.Contains(content, "useGetUser") {
+		t.Errorf("Expected no hooks in stdin mode")
+	}
+
+	if err := generateStdin(opts, strings.NewReader("package api\n\nfunc {")); err == nil {
+		t.Errorf("Expected invalid source to fail")
+	}
+}
+
+func TestMethodHandlers(t *testing.T) {
+	src := `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type Server struct{}
+
+type Admin[T any] struct{}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func (s *Server) GetUser() {}
+
+// @Method DELETE
+// @Path /users/:id
+func (s Server) deleteUserHandler() {}
+
+// @Method GET
+// @Path /admin/users/:id
+// @Output User
+func (a *Admin[T]) GetUser() {}
+
+// Not a handler
+func (s *Server) Close() {}
+`
+	types, handlers, err := parseSource([]byte(src), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	expected := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}, Position: "stdin.go:14:1"},
+		{Name: "DeleteUser", Method: "DELETE", Path: "/users/:id", URLParams: []string{"id"}, Position: "stdin.go:18:1"},
+	}
+	if !reflect.DeepEqual(handlers, expected) {
+		t.Errorf("Parsed handlers do not match expected.\nGot: %+v\nWant: %+v", handlers, expected)
+	}
+	if len(types) != 1 || types[0].Name != "User" {
+		t.Errorf("Expected the User type to be used by the method handlers, got %+v", types)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "api.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	var names []string
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			names = append(names, funcDeclName(fn))
+		}
+	}
+	if strings.Join(names, ",") != "Server.GetUser,Server.deleteUserHandler,Admin.GetUser,Server.Close" {
+		t.Errorf("Unexpected declaration names: %v", names)
+	}
+}
+
+func TestFieldDocs(t *testing.T) {
+	src := `package main
+
+type User struct {
+	// ID is the user's database ID
+	ID int ` + "`json:\"id\"`" + `
+	Name string ` + "`json:\"name\"`" + ` // Display name, not unique
+	// Email is verified before login.
+	// Changing it requires verifying again.
+	Email string ` + "`json:\"email\"`" + ` // Lower-cased
+	Age int ` + "`json:\"age\"`" + `
+	// Note may contain */ sequences
+	Note string ` + "`json:\"note\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	structType := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	typeInfo := parseType("User", structType, defaultTypeMappings, nil, "")
+
+	expectedDocs := []string{
+		"ID is the user's database ID",
+		"Display name, not unique",
+		"Email is verified before login.\nChanging it requires verifying again.\nLower-cased",
+		"",
+		"Note may contain */ sequences",
+	}
+	for i, field := range typeInfo.Fields {
+		if field.Doc != expectedDocs[i] {
+			t.Errorf("Expected %s doc %q, got %q", field.Name, expectedDocs[i], field.Doc)
+		}
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: []TypeInfo{typeInfo}})
+	expected := `export type User = { 
+  /** ID is the user's database ID */
+  id: number;
+  /** Display name, not unique */
+  name: string;
+  /**
+   * Email is verified before login.
+   * Changing it requires verifying again.
+   * Lower-cased
+   */
+  email: string;
+  age: number;
+  /** Note may contain *\/ sequences */
+  note: string;
+}`
+	if !strings.Contains(content, expected) {
+		t.Errorf("Expected documented type:\n%s\ngot:\n%s", expected, content)
+	}
+}
+
+func TestDefaultExport(t *testing.T) {
+	user := TypeInfo{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}
+	order := TypeInfo{Name: "Order", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}
+	handlers := []HandlerInfo{{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}}}
+
+	tests := []struct {
+		name     string
+		opts     GenerateFileOptions
+		expected string
+	}{
+		{"client", GenerateFileOptions{Types: []TypeInfo{user}, Handlers: handlers, DefaultExport: true}, "export default queries;"},
+		{"angular", GenerateFileOptions{Types: []TypeInfo{user}, Handlers: handlers, UseAngular: true, DefaultExport: true}, "export default APIService;"},
+		{"single type", GenerateFileOptions{Types: []TypeInfo{user}, DefaultExport: true}, "export type { User as default };"},
+		{"several types", GenerateFileOptions{Types: []TypeInfo{user, order}, DefaultExport: true}, ""},
+		{"disabled", GenerateFileOptions{Types: []TypeInfo{user}, Handlers: handlers}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := renderTestFile(t, tt.opts)
+			if tt.expected == "" {
+				if strings.Contains(content, "export default") || strings.Contains(content, "as default") {
+					t.Errorf("Expected no default export:\n%s", content)
+				}
+				return
+			}
+			if !strings.Contains(content, tt.expected) {
+				t.Errorf("Expected %q in generated file:\n%s", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestTSTypeOverride(t *testing.T) {
+	src := `package api
+
+// Money marshals as a decimal string such as "12.34"
+// @TSType string
+type Money struct {
+	cents int64
+}
+
+// @TSType 'low' | 'high'
+type Priority int
+
+const (
+	Low Priority = iota
+	High
+)
+
+type Order struct {
+	Total    Money            ` + "`json:\"total\"`" + `
+	Discount *Money           ` + "`json:\"discount\"`" + `
+	Lines    map[string]Money ` + "`json:\"lines\"`" + `
+	Priority Priority         ` + "`json:\"priority\"`" + `
+}
+
+// @Method GET
+// @Path /orders/:id
+// @Output Order
+func GetOrderHandler() {}
+`
+	types, _, err := parseSource([]byte(src), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	if len(types) != 1 || types[0].Name != "Order" {
+		t.Fatalf("Expected only Order to be generated, got %+v", types)
+	}
+	expected := map[string]string{
+		"total":    "string",
+		"discount": "string | null",
+		"lines":    "{ [key: string]: string }",
+		"priority": "'low' | 'high'",
+	}
+	for _, field := range types[0].Fields {
+		if field.Type != expected[field.Name] {
+			t.Errorf("Expected %s to be %s, got %s", field.Name, expected[field.Name], field.Type)
+		}
+	}
+
+	// Overrides on types in other packages are read from their declarations
+	file, err := parser.ParseFile(token.NewFileSet(), "money.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	if tsType, ok := typeSpecDirective([]*ast.File{file}, "Money", "@TSType"); !ok || tsType != "string" {
+		t.Errorf("Expected the Money override, got %q", tsType)
+	}
+	if _, ok := typeSpecDirective([]*ast.File{file}, "Order", "@TSType"); ok {
+		t.Errorf("Expected no override on Order")
+	}
+}
+
+func TestOmitEmptyCollections(t *testing.T) {
+	src := `package main
+
+type Post struct {
+	// Tags are lower-cased
+	Tags     []string          ` + "`json:\"tags,omitempty\"`" + `
+	Meta     map[string]string ` + "`json:\"meta,omitempty\"`" + `
+	Comments *[]string         ` + "`json:\"comments,omitempty\"`" + `
+	Title    string            ` + "`json:\"title,omitempty\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	structType := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	astInfo := parseType("Post", structType, defaultTypeMappings, nil, "")
+
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+	typesInfo, err := parseTypeObject(pkg.Scope().Lookup("Post"), defaultTypeMappings, "")
+	if err != nil {
+		t.Fatalf("Failed to parse type object: %v", err)
+	}
+
+	note := "Left out when nil or empty, never null."
+	expected := map[string]string{
+		"tags":     "Array<string>",
+		"meta":     "{ [key: string]: string }",
+		"comments": "Array<string> | null | undefined",
+		"title":    "string | undefined",
+	}
+	for _, typeInfo := range []TypeInfo{astInfo, typesInfo} {
+		for _, field := range typeInfo.Fields {
+			if field.Type != expected[field.Name] {
+				t.Errorf("Expected %s type %q, got %q", field.Name, expected[field.Name], field.Type)
+			}
+			if !field.IsOptional {
+				t.Errorf("Expected %s to be optional", field.Name)
+			}
+			hasNote := strings.HasSuffix(field.Doc, note)
+			if hasNote != (field.Name == "tags" || field.Name == "meta") {
+				t.Errorf("Unexpected %s doc %q", field.Name, field.Doc)
+			}
+		}
+	}
+	if astInfo.Fields[0].Doc != "Tags are lower-cased\n"+note {
+		t.Errorf("Expected the note after the field's own doc, got %q", astInfo.Fields[0].Doc)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: []TypeInfo{astInfo}})
+	for _, want := range []string{
+		"  /**\n   * Tags are lower-cased\n   * " + note + "\n   */\n  tags?: Array<string>;",
+		"  /** " + note + " */\n  meta?: { [key: string]: string };",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected generated types to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestGenerateConcurrently(t *testing.T) {
+	files := map[string]string{
+		"go2type.yaml": `auth_token: token
+hooks: "false"
+concurrency: 2
+packages:
+`,
+	}
+	var names []string
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("pkg%d", i)
+		names = append(names, name)
+		files[name+"/types.go"] = "package " + name + "\n\ntype Item struct {\n\tID int `json:\"id\"`\n}\n"
+		files["go2type.yaml"] += fmt.Sprintf("  - path: %s\n    output_path: out/%s.generated.ts\n", name, name)
+	}
+	// A package that fails to parse doesn't stop the others from being generated
+	files["broken/types.go"] = "package broken\n\ntype Item struct {\n"
+	files["go2type.yaml"] += "  - path: broken\n    output_path: out/broken.generated.ts\n"
+
+	dir := writeTestModule(t, files)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	output := captureOutput(t, func() {
+		if err := generate(GenerateOptions{}); err != nil {
+			t.Errorf("Failed to generate: %v", err)
+		}
+	})
+
+	var expected []string
+	for _, name := range names {
+		expected = append(expected, fmt.Sprintf("Generated file for package %s at out/%s.generated.ts", name, name))
+		if _, err := os.Stat(filepath.Join(dir, "out", name+".generated.ts")); err != nil {
+			t.Errorf("Expected %s to be generated: %v", name, err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != len(expected)+1 {
+		t.Fatalf("Expected %d lines of output, got:\n%s", len(expected)+1, output)
+	}
+	for i, want := range expected {
+		if lines[i] != want {
+			t.Errorf("Expected line %d to be %q, got %q", i, want, lines[i])
+		}
+	}
+	if !strings.HasPrefix(lines[len(expected)], "Error parsing package broken:") {
+		t.Errorf("Expected the broken package's error last, got %q", lines[len(expected)])
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "broken.generated.ts")); !os.IsNotExist(err) {
+		t.Errorf("Expected the broken package not to be generated, got %v", err)
+	}
+}
+
+func TestRequestBuilder(t *testing.T) {
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}},
+		{Name: "CreateUserInput", Fields: []FieldInfo{{Name: "name", Type: "string", JSONName: "name"}}},
+	}
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+		{Name: "CreateUser", Method: "POST", Path: "/users", InputType: "CreateUserInput", OutputType: "User"},
+	}
+
+	for _, httpClient := range []string{"fetch", "axios"} {
+		content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, HTTPClient: httpClient, UseBuilder: true})
+		for _, expected := range []string{
+			"export class RequestBuilder<TOutput> {",
+			"withHeader(name: string, value: string): this {",
+			"withHeaders(headers: Record<string, string>): this {",
+			"withSignal(signal: AbortSignal): this {",
+			"execute(): Promise<TOutput> {",
+			"export const api = {",
+			"getUser: (id: string): RequestBuilder<User> =>\n    new RequestBuilder<User>((options, onResponse) => GetUserQuery(id, onResponse, options)),",
+			"createUser: (input: CreateUserInput): RequestBuilder<User> =>\n    new RequestBuilder<User>((options, onResponse) => CreateUserQuery(input, onResponse, options)),",
+			"options?: RequestOptions): Promise<User> => {",
+			"Object.assign(headers, options?.headers);",
+			"  signal?: AbortSignal\n): Promise<TOutput> {",
+			"    signal,\n",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected %s client to contain %q, got:\n%s", httpClient, expected, content)
+			}
+		}
+	}
+
+	fetchContent := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseBuilder: true})
+	if !strings.Contains(fetchContent, "createQuery<void, User>('GET', url, undefined, headers, onResponse, options?.signal);") {
+		t.Errorf("Expected the fetch client to pass the signal, got:\n%s", fetchContent)
+	}
+	axiosContent := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, HTTPClient: "axios", UseBuilder: true})
+	if !strings.Contains(axiosContent, "createQuery<void, User>('GET', url, undefined, headers, onResponse, undefined, options?.signal);") {
+		t.Errorf("Expected the axios client to pass the signal after params, got:\n%s", axiosContent)
+	}
+
+	// Without client_style builder, the client is unchanged
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+	for _, unexpected := range []string{"RequestBuilder", "RequestOptions", "signal", "export const api"} {
+		if strings.Contains(content, unexpected) {
+			t.Errorf("Expected no %q without builders", unexpected)
+		}
+	}
+}
+
+func TestHookStyle(t *testing.T) {
+	tests := []struct {
+		opts     GenerateFileOptions
+		expected string
+	}{
+		{GenerateFileOptions{}, ""},
+		{GenerateFileOptions{UseHooks: true}, "react"},
+		{GenerateFileOptions{UseHooks: true, UseReactQuery: true}, "react-query"},
+		{GenerateFileOptions{UseHooks: true, UseSWR: true}, "swr"},
+		{GenerateFileOptions{UseReactQuery: true}, "react-query"},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.hookStyle(); got != tt.expected {
+			t.Errorf("Expected hook style %q for %+v, got %q", tt.expected, tt.opts, got)
+		}
+	}
+
+	handlers := []HandlerInfo{{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}}}
+	types := []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}}
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseHooks: true, UseSWR: true})
+	if !strings.Contains(content, "import useSWR") || strings.Contains(content, "@tanstack/react-query") || strings.Contains(content, "useState") {
+		t.Errorf("Expected only SWR hooks and imports, got:\n%s", content)
+	}
+}
+
+func TestGenericSQLNull(t *testing.T) {
+	src := `package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Account struct {
+	Name      sql.Null[string]    ` + "`json:\"name\"`" + `
+	Balance   sql.Null[int64]     ` + "`json:\"balance\"`" + `
+	ClosedAt  sql.Null[time.Time] ` + "`json:\"closed_at\"`" + `
+	Nickname  sql.NullString      ` + "`json:\"nickname\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	typeMappings := map[string]string{"time.Time": "string"}
+	for k, v := range defaultTypeMappings {
+		typeMappings[k] = v
+	}
+	structType := file.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	astInfo := parseType("Account", structType, typeMappings, nil, "")
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+	typesInfo, err := parseTypeObject(pkg.Scope().Lookup("Account"), typeMappings, "")
+	if err != nil {
+		t.Fatalf("Failed to parse type object: %v", err)
+	}
+
+	expected := map[string]string{
+		"name":      "string | null",
+		"balance":   "number | null",
+		"closed_at": "string | null",
+		"nickname":  "string | null",
+	}
+	for _, typeInfo := range []TypeInfo{astInfo, typesInfo} {
+		if len(typeInfo.Fields) != len(expected) {
+			t.Fatalf("Expected %d fields, got %+v", len(expected), typeInfo.Fields)
+		}
+		for _, field := range typeInfo.Fields {
+			if field.Type != expected[field.Name] {
+				t.Errorf("Expected %s type %q, got %q", field.Name, expected[field.Name], field.Type)
+			}
+		}
+	}
+}
+
+func TestPointerArrayFields(t *testing.T) {
+	src := `package main
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type Team struct {
+	Members  []*User            ` + "`json:\"members\"`" + `
+	Invited  *[]User            ` + "`json:\"invited\"`" + `
+	Pending  *[]*User           ` + "`json:\"pending\"`" + `
+	Grid     [][]*User          ` + "`json:\"grid\"`" + `
+	ByRole   map[string][]*User ` + "`json:\"by_role\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	structType := file.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	astInfo := parseType("Team", structType, defaultTypeMappings, nil, "")
+
+	expected := map[string]string{
+		"members": "Array<User | null>",
+		"invited": "Array<User> | null",
+		"pending": "Array<User | null> | null",
+		"grid":    "Array<Array<User | null>>",
+		"by_role": "{ [key: string]: Array<User | null> }",
+	}
+	for _, field := range astInfo.Fields {
+		if field.Type != expected[field.Name] {
+			t.Errorf("Expected %s type %q, got %q", field.Name, expected[field.Name], field.Type)
+		}
+		if field.PackageName != "User" {
+			t.Errorf("Expected %s to reference User, got %q", field.Name, field.PackageName)
+		}
+		if field.Name != "by_role" && !field.IsArray {
+			t.Errorf("Expected %s to be an array", field.Name)
+		}
+	}
+
+	// The go/types path emits the same types
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+	typesInfo, err := parseTypeObject(pkg.Scope().Lookup("Team"), defaultTypeMappings, "")
+	if err != nil {
+		t.Fatalf("Failed to parse type object: %v", err)
+	}
+	for _, field := range typesInfo.Fields {
+		if field.Type != expected[field.Name] {
+			t.Errorf("Expected %s type %q from go/types, got %q", field.Name, expected[field.Name], field.Type)
+		}
+	}
+}
+
+// asiRiskyLines returns the lines of content that end a statement or declaration without a
+// semicolon, so that the code relies on automatic semicolon insertion
+func asiRiskyLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	indent := func(line string) int { return len(line) - len(strings.TrimLeft(line, " \t")) }
+	var risky []string
+	// Indentation of the open object literals and type bodies, e.g. export type X = {
+	var objects []int
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "@") {
+			continue
+		}
+		if len(objects) > 0 && indent(line) == objects[len(objects)-1] && strings.HasPrefix(trimmed, "}") {
+			objects = objects[:len(objects)-1]
+			if trimmed == "}" {
+				risky = append(risky, line)
+			}
+			continue
+		}
+		if strings.HasSuffix(trimmed, "= {") {
+			objects = append(objects, indent(line))
+			continue
+		}
+		if strings.HasSuffix(trimmed, ";") || strings.HasSuffix(trimmed, "{") || strings.HasSuffix(trimmed, "}") {
+			continue
+		}
+
+		next := ""
+		for _, l := range lines[i+1:] {
+			if strings.TrimSpace(l) != "" {
+				next = l
+				break
+			}
+		}
+		nextTrimmed := strings.TrimSpace(next)
+		continues := strings.ContainsAny(trimmed[len(trimmed)-1:], ",([:?|&=>+") ||
+			strings.HasPrefix(nextTrimmed, ")") || strings.HasPrefix(nextTrimmed, "]") ||
+			strings.HasPrefix(nextTrimmed, ".") || strings.HasPrefix(nextTrimmed, "?") ||
+			strings.HasPrefix(nextTrimmed, ":") || strings.HasPrefix(nextTrimmed, "|")
+		if !continues && indent(next) <= indent(line) {
+			risky = append(risky, line)
+		}
+	}
+	return risky
+}
+
+func TestSemicolons(t *testing.T) {
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}, {Name: "active", Type: "boolean", JSONName: "active"}}},
+		{Name: "GetUserInput", Fields: []FieldInfo{{Name: "q", Type: "string", JSONName: "q"}}},
+		{Name: "CreateUserInput", Fields: []FieldInfo{{Name: "name", Type: "string", JSONName: "name", Validation: []string{"required"}, Default: "anon"}}},
+		{Name: "Status", EnumValues: []string{"'active'", "'banned'"}, EnumLabels: []string{"Active", "Banned"}},
+	}
+	handlers := []HandlerInfo{
+		{
+			Name: "GetUser", Method: "GET", Path: "/users/:id", InputType: "GetUserInput", OutputType: "User", URLParams: []string{"id"}, IsDeprecated: true,
+			Headers: []HeaderInfo{
+				{HeaderKey: "X-Session", SafeName: "x_session", Source: "localStorage", StorageKey: "session"},
+				{HeaderKey: "X-Trace", SafeName: "x_trace", Source: "input"},
+				{HeaderKey: "X-Version", SafeName: "x_version", Source: "const", Value: "2"},
+			},
+			QueryParams: []QueryParamInfo{{Key: "page", Name: "page", Type: "number", Optional: true}},
+		},
+		{Name: "CreateUser", Method: "POST", Path: "/users", InputType: "CreateUserInput", OutputType: "User"},
+	}
+
+	for _, opts := range []GenerateFileOptions{
+		{UseBuilder: true, DedupeRequests: true, APIConfig: true, UseDateObject: true, BrandIDs: true, EmitGuards: true, ValidateResponses: true, BooleanPrefix: true, DefaultExport: true},
+		{HTTPClient: "axios", UseHooks: true, UseReactQuery: true, ZeroTimeAsNull: true, UseBuilder: true},
+		{UseHooks: true, UseSWR: true},
+		{UseHooks: true},
+		{UseAngular: true},
+	} {
+		opts.Types, opts.Handlers, opts.AuthToken = types, handlers, "token"
+		content := renderTestFile(t, opts)
+		if risky := asiRiskyLines(content); len(risky) > 0 {
+			t.Errorf("Expected every statement to end with a semicolon, got:\n%s", strings.Join(risky, "\n"))
+		}
+	}
+
+	// The checker catches a missing semicolon
+	if risky := asiRiskyLines("let url = '/users'\nurl = url.replace(':id', id);\n"); len(risky) != 1 {
+		t.Errorf("Expected one risky line, got %q", risky)
+	}
+}
+
+func TestQueryKeys(t *testing.T) {
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+		{
+			Name: "ListUsers", Method: "GET", Path: "/users", InputType: "ListUsersInput", OutputType: "Array<User>",
+			QueryParams: []QueryParamInfo{{Key: "page", Name: "page", Type: "number", Optional: true}},
+		},
+		{Name: "CreateUser", Method: "POST", Path: "/users", InputType: "CreateUserInput", OutputType: "User"},
+	}
+
+	tests := []struct {
+		style    string
+		expected []string
+	}{
+		{"array", []string{
+			"GetUser: (id: string): [string, string] => ['GetUser', id],",
+			"ListUsers: (input: ListUsersInput, page?: number): [string, ListUsersInput, number | undefined] => ['ListUsers', input, page],",
+		}},
+		{"object", []string{
+			"GetUser: (id: string): [{ scope: string; id: string }] => [{ scope: 'GetUser', id }],",
+		}},
+	}
+	for _, tt := range tests {
+		content := renderTestFile(t, GenerateFileOptions{Handlers: handlers, UseHooks: true, UseReactQuery: true, QueryKeyStyle: tt.style})
+		if !strings.Contains(content, "export const QueryKeys = {") {
+			t.Fatalf("Expected a QueryKeys object, got:\n%s", content)
+		}
+		for _, expected := range tt.expected {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected %s query keys to contain %q, got:\n%s", tt.style, expected, content)
+			}
+		}
+		// The keys match the ones the hooks use
+		for _, h := range handlers[:2] {
+			if !strings.Contains(content, "queryKey: "+queryKey(h, tt.style)+",") {
+				t.Errorf("Expected the %s hook to use key %s", h.Name, queryKey(h, tt.style))
+			}
+		}
+		if strings.Contains(content, "CreateUser: (") {
+			t.Errorf("Expected no query key for mutations")
+		}
+	}
+
+	for _, opts := range []GenerateFileOptions{{UseHooks: true, UseSWR: true}, {UseHooks: true}, {}} {
+		opts.Handlers = handlers
+		if content := renderTestFile(t, opts); strings.Contains(content, "QueryKeys") {
+			t.Errorf("Expected QueryKeys only with react-query hooks")
+		}
+	}
+}
+
+func TestComposedInterfaces(t *testing.T) {
+	src := `package api
+
+// @TSType { name: string }
+type Named interface {
+	Name() string
+}
+
+// @TSType { age: number }
+type Aged interface {
+	Age() int
+}
+
+// Person is composed of two interfaces with a TypeScript shape
+type Person interface {
+	Named
+	Aged
+}
+
+type Opaque interface {
+	Do()
+}
+
+// Mixed embeds an interface without a shape, so it can't extend it
+type Mixed interface {
+	Named
+	Opaque
+}
+
+type Team struct {
+	Lead    Person   ` + "`json:\"lead\"`" + `
+	Members []Person ` + "`json:\"members\"`" + `
+	Other   Mixed    ` + "`json:\"other\"`" + `
+	Opaque
+}
+
+// @Method GET
+// @Path /teams/:id
+// @Output Team
+func GetTeamHandler() {}
+`
+	types, handlers, err := parseSource([]byte(src), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	byName := make(map[string]TypeInfo)
+	for _, ty := range types {
+		byName[ty.Name] = ty
+	}
+	if len(types) != 4 {
+		t.Errorf("Expected Team, Person, Named and Aged, got %+v", types)
+	}
+	if person := byName["Person"]; !reflect.DeepEqual(person.Extends, []string{"Named", "Aged"}) {
+		t.Errorf("Expected Person to extend Named and Aged, got %+v", person)
+	}
+	if named := byName["Named"]; named.TSType != "{ name: string }" {
+		t.Errorf("Expected Named to be declared with its @TSType, got %+v", named)
+	}
+	expected := map[string]string{
+		"lead":    "Person",
+		"members": "Array<Person>",
+		"other":   "any",
+		"Opaque":  "any",
+	}
+	for _, field := range byName["Team"].Fields {
+		if field.Type != expected[field.Name] {
+			t.Errorf("Expected %s to be %s, got %s", field.Name, expected[field.Name], field.Type)
+		}
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, EmitGuards: true})
+	for _, want := range []string{
+		"export interface Person extends Named, Aged {}",
+		"export type Named = { name: string };",
+		"export type Aged = { age: number };",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "isPerson") || strings.Contains(content, "isNamed") {
+		t.Errorf("Expected no guards for interfaces")
+	}
+}
+
+func TestGenerateQuiet(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"api/users.go": `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`,
+		"broken/types.go": "package broken\n\ntype Item struct {\n",
+		"go2type.yaml": `auth_token: token
+hooks: "false"
+eol: cr
+packages:
+  - path: api
+    output_path: out/api.generated.ts
+`,
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	opts, err := parseGenerateFlags([]string{"--quiet"})
+	if err != nil || !opts.Quiet {
+		t.Fatalf("Expected --quiet to be parsed, got %+v, %v", opts, err)
+	}
+	var stdout, stderr bytes.Buffer
+	opts.Stdout, opts.Stderr = &stdout, &stderr
+	output := captureOutput(t, func() {
+		if err := generate(opts); err != nil {
+			t.Errorf("Failed to generate: %v", err)
+		}
+	})
+	if stdout.Len() != 0 || output != "" {
+		t.Errorf("Expected nothing on stdout, got:\n%s%s", stdout.String(), output)
+	}
+	// Warnings are still printed, to stderr
+	if !strings.Contains(stderr.String(), "Warning: Unknown eol style cr. Using lf instead.") {
+		t.Errorf("Expected the warning on stderr, got %q", stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "api.generated.ts")); err != nil {
+		t.Errorf("Expected the file to be generated: %v", err)
+	}
+
+	// Errors are still printed, to stderr
+	config := "auth_token: token\nhooks: \"false\"\npackages:\n  - path: broken\n    output_path: out/broken.generated.ts\n"
+	if err := os.WriteFile(filepath.Join(dir, "go2type.yaml"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	stdout.Reset()
+	stderr.Reset()
+	if err := generate(opts); err != nil {
+		t.Errorf("Failed to generate: %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected nothing on stdout, got:\n%s", stdout.String())
+	}
+	if !strings.HasPrefix(stderr.String(), "Error parsing package broken:") {
+		t.Errorf("Expected the error on stderr, got %q", stderr.String())
+	}
+}
+
+func TestGenerateSkipUnchanged(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"api/users.go": `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`,
+		"go2type.yaml": "auth_token: token\nhooks: \"false\"\npackages:\n  - path: api\n    output_path: out/api.generated.ts\n",
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	opts, err := parseGenerateFlags([]string{"--skip-unchanged"})
+	if err != nil || !opts.SkipUnchanged {
+		t.Fatalf("Expected --skip-unchanged to be parsed, got %+v, %v", opts, err)
+	}
+	var stdout bytes.Buffer
+	opts.Stdout = &stdout
+	if err := generate(opts); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	outputFile := filepath.Join(dir, "out", "api.generated.ts")
+	if strings.Contains(stdout.String(), "Skipping") {
+		t.Errorf("Expected the package to be generated on the first run, got:\n%s", stdout.String())
+	}
+
+	// The output is dated after the sources, so a rewrite would change its modification time
+	modTime := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(outputFile, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+	stdout.Reset()
+	if err := generate(opts); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Skipping package api: out/api.generated.ts is up to date") {
+		t.Errorf("Expected the package to be skipped, got:\n%s", stdout.String())
+	}
+	info, err := os.Stat(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to stat output: %v", err)
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("Expected the output to be left alone, modified at %v instead of %v", info.ModTime(), modTime)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+	}
+	types := []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseReactQuery: true, UseHooks: true, BaseURL: "/api/v1/"})
+	if !strings.Contains(content, "let url = '/api/v1/users/:id';") {
+		t.Errorf("Expected the path to be prefixed with the base URL, got:\n%s", content)
+	}
+	if strings.Contains(content, "process.env") {
+		t.Errorf("Expected a static base URL not to be read from the environment, got:\n%s", content)
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, BaseURL: "env:API_BASE_URL"})
+	for _, expected := range []string{
+		"const baseURL = (process.env.API_BASE_URL ?? '').replace(/\\/+$/, '');",
+		"let url = baseURL + '/users/:id';",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, content)
+		}
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseAngular: true, BaseURL: "env:API_BASE_URL"})
+	for _, expected := range []string{"const baseURL = (process.env.API_BASE_URL", "let url = baseURL + '/users/:id';"} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected the Angular service to contain %q, got:\n%s", expected, content)
+		}
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers})
+	if !strings.Contains(content, "let url = '/users/:id';") || strings.Contains(content, "baseURL") {
+		t.Errorf("Expected paths to be unchanged without a base URL, got:\n%s", content)
+	}
+}
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		baseURL, path, expected string
+	}{
+		{"/api/v1", "/users", "/api/v1/users"},
+		{"/api/v1/", "/users", "/api/v1/users"},
+		{"/api/v1//", "//users", "/api/v1/users"},
+		{"https://api.example.com", "users", "https://api.example.com/users"},
+		{"/", "/users", "/users"},
+	}
+	for _, tt := range tests {
+		if got := joinURL(tt.baseURL, tt.path); got != tt.expected {
+			t.Errorf("joinURL(%q, %q) = %q, expected %q", tt.baseURL, tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestGroupBaseURL(t *testing.T) {
+	group := []PackageConfig{{Path: "users"}, {Path: "orders", BaseURL: "/orders-api"}, {Path: "items", BaseURL: "/items-api"}}
+	if got := groupBaseURL(group, "/api", io.Discard); got != "/orders-api" {
+		t.Errorf("Expected the first package's base URL to override the global one, got %q", got)
+	}
+	if got := groupBaseURL(group[:1], "/api", io.Discard); got != "/api" {
+		t.Errorf("Expected the global base URL, got %q", got)
+	}
+	if got := validBaseURL("env:not-a-name", io.Discard); got != "" {
+		t.Errorf("Expected an invalid environment variable to be ignored, got %q", got)
+	}
+}
+
+func TestTypeKeyword(t *testing.T) {
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{
+			{Name: "id", Type: "number", JSONName: "id"},
+			{Name: "role", Type: "Role", JSONName: "role"},
+		}},
+		{Name: "Page", TypeParams: []string{"T"}, Fields: []FieldInfo{{Name: "items", Type: "Array<T>", JSONName: "items"}}},
+		{Name: "Role", EnumValues: []string{"'admin'", "'member'"}},
+		{Name: "Amount", TSType: "string | number"},
+		{Name: "UserSummary", Derived: "Pick<User, 'id'>"},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, UseInterfaces: true})
+	for _, expected := range []string{
+		"export interface User { \n  id: number;\n  role: Role;\n}\n",
+		"export interface Page<T> { \n  items: Array<T>;\n}\n",
+		"export type Role = 'admin' | 'member';",
+		"export type Amount = string | number;",
+		"export type UserSummary = Pick<User, 'id'>;",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, content)
+		}
+	}
+	if strings.Contains(content, "export type User =") || strings.Contains(content, "export type Page<") {
+		t.Errorf("Expected object types to be interfaces, got:\n%s", content)
+	}
+	if risky := asiRiskyLines(content); len(risky) > 0 {
+		t.Errorf("Expected no statements relying on automatic semicolon insertion, got %q", risky)
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Types: types})
+	if !strings.Contains(content, "export type User = { \n  id: number;\n  role: Role;\n};\n") {
+		t.Errorf("Expected object types to be type aliases by default, got:\n%s", content)
+	}
+}
+
+func TestUnknownForAny(t *testing.T) {
+	src := `package api
+
+type Handler interface {
+	Serve()
+}
+
+type Event struct {
+	Payload any                      ` + "`json:\"payload\"`" + `
+	Raw     interface{}              ` + "`json:\"raw\"`" + `
+	Target  interface{ ID() string } ` + "`json:\"target\"`" + `
+	Handler Handler                  ` + "`json:\"handler\"`" + `
+}
+
+// @Method GET
+// @Path /events/:id
+// @Output Event
+func GetEventHandler() {}
+`
+	fieldTypes := func(opts ParseOptions) map[string]string {
+		t.Helper()
+		types, _, err := parseSource([]byte(src), opts)
+		if err != nil {
+			t.Fatalf("Failed to parse source: %v", err)
+		}
+		fields := make(map[string]string)
+		for _, ty := range types {
+			for _, field := range ty.Fields {
+				fields[field.Name] = field.Type
+			}
+		}
+		return fields
+	}
+
+	// Inline interfaces are unknown either way
+	expected := map[string]string{"payload": "any", "raw": "unknown", "target": "unknown", "handler": "any"}
+	if fields := fieldTypes(ParseOptions{}); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected %v by default, got %v", expected, fields)
+	}
+	expected = map[string]string{"payload": "unknown", "raw": "unknown", "target": "unknown", "handler": "unknown"}
+	if fields := fieldTypes(ParseOptions{UseUnknownForAny: true}); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected %v with use_unknown_for_any, got %v", expected, fields)
+	}
+	// A type mapping for any takes precedence
+	expected = map[string]string{"payload": "JSONValue", "raw": "JSONValue", "target": "unknown", "handler": "JSONValue"}
+	if fields := fieldTypes(ParseOptions{UseUnknownForAny: true, TypeMappings: map[string]string{"any": "JSONValue"}}); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected %v with a mapping for any, got %v", expected, fields)
+	}
+
+	// Types resolved with go/types, such as those of other packages, are mapped the same way
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "api.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	pkg, err := (&types.Config{}).Check("api", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type check source: %v", err)
+	}
+	typeMappings := map[string]string{"any": "unknown"}
+	for k, v := range defaultTypeMappings {
+		typeMappings[k] = v
+	}
+	typeInfo, err := parseTypeObject(pkg.Scope().Lookup("Event"), typeMappings, "")
+	if err != nil {
+		t.Fatalf("Failed to parse type object: %v", err)
+	}
+	for _, field := range typeInfo.Fields {
+		if field.Type != "unknown" {
+			t.Errorf("Expected %s to be unknown, got %q", field.Name, field.Type)
+		}
+	}
+}
+
+func TestInternalPackageWithErrors(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"models/user.go": `package models
+
+type User struct {
+	ID   int    ` + "`json:\"id\"`" + `
+	Name string ` + "`json:\"name\"`" + `
+}
+`,
+		"models/draft.go": `package models
+
+type Draft struct {
+	Owner Missing ` + "`json:\"owner\"`" + `
+}
+
+func broken() int {
+	return "not an int"
+}
+`,
+		"api/api.go": `package api
+
+import "github.com/example/testmodule/models"
+
+type UserResponse struct {
+	User models.User ` + "`json:\"user\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output UserResponse
+func GetUserHandler() {}
+`,
+	})
+
+	var types []TypeInfo
+	output := captureOutput(t, func() {
+		var err error
+		types, _, err = parsePackage(filepath.Join(dir, "api"), ParseOptions{})
+		if err != nil {
+			t.Errorf("Expected the package errors not to fail parsing, got %v", err)
+		}
+	})
+	if !strings.Contains(output, "Warning: Package github.com/example/testmodule/models has errors") {
+		t.Errorf("Expected a warning about the package errors, got:\n%s", output)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types})
+	for _, expected := range []string{"id: number;", "name: string;"} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q from the resolvable User type, got:\n%s", expected, content)
+		}
+	}
+}
+
+func TestOnUnresolved(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"models/user.go": `package models
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+`,
+		"api/api.go": `package api
+
+import "github.com/example/testmodule/models"
+
+type Order struct {
+	ID       int               ` + "`json:\"id\"`" + `
+	Customer models.Customer   ` + "`json:\"customer\"`" + `
+	Refunds  []models.Refund   ` + "`json:\"refunds\"`" + `
+	Buyer    models.User       ` + "`json:\"buyer\"`" + `
+}
+
+// @Method GET
+// @Path /orders/:id
+// @Output Order
+func GetOrderHandler() {}
+`,
+	})
+	pkgDir := filepath.Join(dir, "api")
+
+	orderFields := func(t *testing.T, types []TypeInfo) map[string]string {
+		t.Helper()
+		for _, ty := range types {
+			if ty.Name == "Order" {
+				fields := make(map[string]string)
+				for _, field := range ty.Fields {
+					fields[field.Name] = field.Type
+				}
+				return fields
+			}
+		}
+		t.Fatalf("Expected an Order type, got %v", types)
+		return nil
+	}
+
+	var types []TypeInfo
+	output := captureOutput(t, func() {
+		var err error
+		if types, _, err = parsePackage(pkgDir, ParseOptions{}); err != nil {
+			t.Errorf("Failed to parse package: %v", err)
+		}
+	})
+	fields := orderFields(t, types)
+	if fields["customer"] != "unknown /* unresolved: models.Customer */" || fields["refunds"] != "Array<unknown /* unresolved: models.Refund */>" {
+		t.Errorf("Expected the unresolved types to be unknown by default, got %v", fields)
+	}
+	if fields["buyer"] != "ModelsUser" {
+		t.Errorf("Expected models.User to still resolve, got %s", fields["buyer"])
+	}
+	if !strings.Contains(output, "Warning: Using unknown for field customer of Order: failed to resolve internal type models.Customer") {
+		t.Errorf("Expected a warning for the unresolved field, got:\n%s", output)
+	}
+
+	captureOutput(t, func() {
+		var err error
+		if types, _, err = parsePackage(pkgDir, ParseOptions{OnUnresolved: "skip"}); err != nil {
+			t.Errorf("Failed to parse package: %v", err)
+		}
+	})
+	fields = orderFields(t, types)
+	if _, ok := fields["customer"]; ok || len(fields) != 2 {
+		t.Errorf("Expected only id and buyer with on_unresolved: skip, got %v", fields)
+	}
+
+	captureOutput(t, func() {
+		_, _, err := parsePackage(pkgDir, ParseOptions{OnUnresolved: "error"})
+		if err == nil || !strings.Contains(err.Error(), "field customer of Order: failed to resolve internal type models.Customer") {
+			t.Errorf("Expected an error naming the unresolved field, got %v", err)
+		}
+	})
+
+	if got := validOnUnresolved("drop", io.Discard); got != "any" {
+		t.Errorf("Expected an unknown on_unresolved to fall back to any, got %s", got)
+	}
+}
+
+func TestAPIErrorClass(t *testing.T) {
+	content := renderTestFile(t, GenerateFileOptions{
+		Handlers: []HandlerInfo{{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "string", URLParams: []string{"id"}}},
+		UseHooks: true, UseReactQuery: true,
+	})
+	class := regexp.MustCompile(`(?s)export class APIError extends Error \{.*?\n\}\n`).FindString(content)
+	if class == "" {
+		t.Fatalf("Expected APIError to be a class extending Error, got:\n%s", content)
+	}
+	for _, expected := range []string{"status: number;", "body: Record<string, unknown> | string;", "Object.setPrototypeOf(this, new.target.prototype);"} {
+		if !strings.Contains(class, expected) {
+			t.Errorf("Expected APIError to contain %q, got:\n%s", expected, class)
+		}
+	}
+	if !strings.Contains(content, "UseQueryResult<string, APIError>") {
+		t.Errorf("Expected the hooks to be typed with APIError, got:\n%s", content)
+	}
+
+	// Construct an error to check instances carry the status and body
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found, skipping runtime check")
+	}
+	script := strings.NewReplacer(
+		"export ", "",
+		": Record<string, unknown> | string", "",
+		": number", "",
+		": string", "",
+	).Replace(class) + `
+const error = new APIError(404, 'Not Found', { message: 'no such user' });
+if (!(error instanceof APIError) || !(error instanceof Error)) {
+  throw new Error('Expected an instance of APIError and Error');
+}
+if (error.status !== 404 || error.body.message !== 'no such user' || error.data !== error.body) {
+  throw new Error('Expected the status and body, got ' + JSON.stringify(error));
+}
+if (error.name !== 'APIError' || error.message !== 'API Error 404: Not Found') {
+  throw new Error('Unexpected name or message: ' + error.name + ', ' + error.message);
+}
+`
+	if output, err := exec.Command(node, "-e", script).CombinedOutput(); err != nil {
+		t.Errorf("APIError check failed: %v\n%s\n%s", err, output, script)
+	}
+}
+
+func TestUnchangedFilesSkipped(t *testing.T) {
+	types := []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}}
+	opts := GenerateFileOptions{
+		Types:            types,
+		OutputFile:       filepath.Join(createTempFolder(t.Name()), "api.generated.ts"),
+		AuthTokenStorage: "localStorage",
+		Split:            "types-and-client",
+	}
+	paths := outputFiles(opts)
+
+	if written, err := generateFile(opts); err != nil || !written {
+		t.Fatalf("Expected the files to be written, got %v, %v", written, err)
+	}
+	// Backdate the files so a rewrite would show in their modification times
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		if !contentHashRegex.Match(generatedLineRegex.Find(content)) {
+			t.Errorf("Expected %s to record its content hash, got:\n%s", path, content)
+		}
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatalf("Failed to backdate %s: %v", path, err)
+		}
+	}
+
+	if written, err := generateFile(opts); err != nil || written {
+		t.Errorf("Expected unchanged files to be skipped, got %v, %v", written, err)
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(past) {
+			t.Errorf("Expected %s not to be rewritten", path)
+		}
+	}
+
+	// A changed type rewrites the file declaring it, and a changed line ending every file
+	opts.Types = []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "name", Type: "string", JSONName: "name"}}}}
+	if written, err := generateFile(opts); err != nil || !written {
+		t.Errorf("Expected the changed types to be written, got %v, %v", written, err)
+	}
+	if info, _ := os.Stat(filepath.Join(filepath.Dir(opts.OutputFile), typesFileName)); info.ModTime().Equal(past) {
+		t.Errorf("Expected the types file to be rewritten")
+	}
+	if info, _ := os.Stat(opts.OutputFile); !info.ModTime().Equal(past) {
+		t.Errorf("Expected the unchanged index not to be rewritten")
+	}
+	opts.EOL = "crlf"
+	if written, err := generateFile(opts); err != nil || !written {
+		t.Errorf("Expected a new line ending to rewrite the files, got %v, %v", written, err)
+	}
+	if content, _ := os.ReadFile(opts.OutputFile); !strings.Contains(string(content), "\r\n") {
+		t.Errorf("Expected CRLF line endings, got %q", content)
+	}
+}
+
+func TestUnformattedFilesRewritten(t *testing.T) {
+	dir := createTempFolder(t.Name())
+	failing := filepath.Join(dir, "failing-prettier")
+	succeeding := filepath.Join(dir, "prettier")
+	writeTestFiles(t, dir, map[string]string{"failing-prettier": "#!/bin/sh\nexit 1\n", "prettier": "#!/bin/sh\nexit 0\n"})
+	for _, path := range []string{failing, succeeding} {
+		if err := os.Chmod(path, 0755); err != nil {
+			t.Fatalf("Failed to make %s executable: %v", path, err)
+		}
+	}
+	// Without clang-format on the PATH, the file is left unformatted when Prettier fails
+	t.Setenv("PATH", dir)
+
+	opts := GenerateFileOptions{
+		Types:            []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}},
+		OutputFile:       filepath.Join(dir, "api.generated.ts"),
+		AuthTokenStorage: "localStorage",
+		ShouldFormat:     true,
+		PrettierPath:     failing,
+		Output:           io.Discard,
+		Warnings:         io.Discard,
+	}
+	for i := 0; i < 2; i++ {
+		if written, err := generateFile(opts); err != nil || !written {
+			t.Errorf("Expected the unformatted file to be written on run %d, got %v, %v", i+1, written, err)
+		}
+		if content, _ := os.ReadFile(opts.OutputFile); contentHashRegex.Match(content) {
+			t.Errorf("Expected no content hash for an unformatted file, got:\n%s", content)
+		}
+	}
+
+	opts.PrettierPath = succeeding
+	if written, err := generateFile(opts); err != nil || !written {
+		t.Errorf("Expected the file to be written, got %v, %v", written, err)
+	}
+	if written, err := generateFile(opts); err != nil || written {
+		t.Errorf("Expected the formatted file to be skipped, got %v, %v", written, err)
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	handlers := []HandlerInfo{{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "string", URLParams: []string{"id"}}}
+
+	content := renderTestFile(t, GenerateFileOptions{Handlers: handlers})
+	if strings.Contains(content, "maxResponseBytes") || !strings.Contains(content, "const data = await response.json();") {
+		t.Errorf("Expected no size limit by default, got:\n%s", content)
+	}
+
+	content = renderTestFile(t, GenerateFileOptions{Handlers: handlers, MaxResponseBytes: 1024})
+	for _, expected := range []string{
+		"const maxResponseBytes = 1024;",
+		"const errorText = await readResponseText(response);",
+		"const data = JSON.parse(await readResponseText(response));",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected the output to contain %q, got:\n%s", expected, content)
+		}
+	}
+
+	axiosContent := renderTestFile(t, GenerateFileOptions{Handlers: handlers, MaxResponseBytes: 1024, HTTPClient: "axios"})
+	if !strings.Contains(axiosContent, "maxContentLength: 1024,") {
+		t.Errorf("Expected axios to be given the limit, got:\n%s", axiosContent)
+	}
+
+	// Read responses under and over the limit, with and without a Content-Length
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found, skipping runtime check")
+	}
+	class := regexp.MustCompile(`(?s)export class APIError extends Error \{.*?\n\}\n`).FindString(content)
+	reader := regexp.MustCompile(`(?s)const maxResponseBytes = .*?\n\}\n`).FindString(content)
+	script := strings.NewReplacer(
+		"export ", "",
+		": Record<string, unknown> | string", "",
+		": Promise<string>", "",
+		": Response", "",
+		": number", "",
+		": string", "",
+	).Replace(class+reader) + `
+const stream = (size) => new Response(new ReadableStream({
+  start(controller) {
+    controller.enqueue(new TextEncoder().encode('"' + 'a'.repeat(size - 2) + '"'));
+    controller.close();
+  },
+}));
+(async () => {
+  if ((await readResponseText(stream(1024))).length !== 1024) {
+    throw new Error('Expected a response at the limit to be read');
+  }
+  for (const response of [stream(1025), new Response('x', { headers: { 'Content-Length': '2048' } })]) {
+    try {
+      await readResponseText(response);
+      throw new Error('Expected a response over the limit to be rejected');
+    } catch (error) {
+      if (!(error instanceof APIError) || error.statusText !== 'Response Too Large') {
+        throw error;
+      }
+    }
+  }
+})().catch((error) => {
+  console.error(error);
+  process.exit(1);
+});
+`
+	if output, err := exec.Command(node, "-e", script).CombinedOutput(); err != nil {
+		t.Errorf("Response size check failed: %v\n%s\n%s", err, output, script)
+	}
+}
+
+func TestPathPrefix(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"users/users.go": `package users
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+
+// @Method GET
+// @Path /
+// @Output User
+func RootHandler() {}
+`,
+	})
+
+	types, handlers, err := parseConfiguredPackage(&Config{}, PackageConfig{Path: filepath.Join(dir, "users"), PathPrefix: "api//v1/"}, io.Discard)
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+	paths := make(map[string]string)
+	for _, h := range handlers {
+		paths[h.Name] = h.Path
+	}
+	if paths["GetUser"] != "/api/v1/users/:id" || paths["Root"] != "/api/v1" {
+		t.Errorf("Expected the handler paths to be prefixed, got %v", paths)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, BaseURL: "https://example.com"})
+	if !strings.Contains(content, "let url = 'https://example.com/api/v1/users/:id';") {
+		t.Errorf("Expected the prefixed path after the base URL, got:\n%s", content)
+	}
+
+	for _, c := range []struct{ prefix, path, expected string }{
+		{"", "/users", "/users"},
+		{"/", "/users", "/users"},
+		{"/api", "users/", "/api/users/"},
+	} {
+		if got := prefixPath(c.prefix, c.path); got != c.expected {
+			t.Errorf("Expected %q and %q to be %q, got %q", c.prefix, c.path, c.expected, got)
+		}
+	}
+}
+
+func TestRecursiveTypes(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"main.go": `package main
+
+type A struct {
+	Name string ` + "`json:\"name\"`" + `
+	B    *B     ` + "`json:\"b\"`" + `
+}
+
+type B struct {
+	A *A ` + "`json:\"a\"`" + `
+}
+
+type TreeNode struct {
+	Value    int        ` + "`json:\"value\"`" + `
+	Children []TreeNode ` + "`json:\"children\"`" + `
+}
+
+// @Method GET
+// @Path /a
+// @Output A
+func GetAHandler() {}
+
+// @Method GET
+// @Path /tree
+// @Output TreeNode
+func GetTreeHandler() {}
+`,
+	})
+
+	types, handlers, err := parsePackage(dir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, EmitGuards: true})
+	for _, expected := range []string{
+		"export type A = {",
+		"b?: B | null;",
+		"export type B = {",
+		"a?: A | null;",
+		"export type TreeNode = {",
+		"children: Array<TreeNode>;",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q in generated file:\n%s", expected, content)
+		}
+	}
+}
+
+func TestCrossPackageCycles(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"models/user.go": `package models
+
+type User struct {
+	Name    string  ` + "`json:\"name\"`" + `
+	Manager *User   ` + "`json:\"manager\"`" + `
+	Groups  []Group ` + "`json:\"groups\"`" + `
+	Team    *Team   ` + "`json:\"team\"`" + `
+}
+
+type Team struct {
+	Lead *User ` + "`json:\"lead\"`" + `
+}
+
+type Group struct {
+	Parent *Group ` + "`json:\"parent\"`" + `
+}
+`,
+		"api/api.go": `package api
+
+import "github.com/example/testmodule/models"
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type Org struct {
+	Team  *models.Team ` + "`json:\"team\"`" + `
+	Owner models.User  ` + "`json:\"owner\"`" + `
+}
+
+// @Method GET
+// @Path /org
+// @Output Org
+func GetOrgHandler() {}
+
+// @Method GET
+// @Path /user
+// @Output User
+func GetUserHandler() {}
+`,
+	})
+
+	types, _, err := parseConfiguredPackage(&Config{}, PackageConfig{Path: filepath.Join(dir, "api")}, io.Discard)
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+	fields := make(map[string]string)
+	declared := make(map[string]int)
+	for _, ty := range types {
+		declared[ty.Name]++
+		for _, field := range ty.Fields {
+			fields[ty.Name+"."+field.Name] = field.Type
+		}
+	}
+
+	// The models reference each other under the names they're emitted with, not the api's own User
+	for name, expected := range map[string]string{
+		"Org.team":           "ModelsTeam | null",
+		"Org.owner":          "ModelsUser",
+		"ModelsUser.manager": "ModelsUser | null",
+		"ModelsUser.groups":  "Array<ModelsGroup>",
+		"ModelsUser.team":    "ModelsTeam | null",
+		"ModelsTeam.lead":    "ModelsUser | null",
+		"ModelsGroup.parent": "ModelsGroup | null",
+		"User.id":            "number",
+	} {
+		if fields[name] != expected {
+			t.Errorf("Expected %s to be %s, got %q", name, expected, fields[name])
+		}
+	}
+	for _, name := range []string{"User", "ModelsUser", "ModelsTeam", "ModelsGroup"} {
+		if declared[name] != 1 {
+			t.Errorf("Expected %s to be declared once, got %d in %v", name, declared[name], declared)
+		}
+	}
+	if declared["Team"] != 0 || declared["Group"] != 0 {
+		t.Errorf("Expected no unprefixed copies of the models, got %v", declared)
+	}
+}
+
+func TestConfigFlag(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"api/api.go": `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`,
+		"go2type.mobile.yaml": `auth_token: token
+hooks: "false"
+packages:
+  - path: api
+    output_path: mobile/api.generated.ts
+`,
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	if err := generate(GenerateOptions{}); err == nil {
+		t.Errorf("Expected an error without go2type.yaml")
+	}
+
+	opts, err := parseGenerateFlags([]string{"--config", "go2type.mobile.yaml"})
+	if err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	captureOutput(t, func() {
+		if err := generate(opts); err != nil {
+			t.Errorf("Failed to generate with --config: %v", err)
+		}
+	})
+	if _, err := os.Stat(filepath.Join(dir, "mobile", "api.generated.ts")); err != nil {
+		t.Errorf("Expected the output of go2type.mobile.yaml to be generated: %v", err)
+	}
+
+	output := captureOutput(t, func() {
+		if err := initConfig("go2type.web.yaml"); err != nil {
+			t.Errorf("Failed to initialize config: %v", err)
+		}
+	})
+	if !strings.Contains(output, "Configuration file 'go2type.web.yaml' has been created.") {
+		t.Errorf("Expected the created file to be named, got:\n%s", output)
+	}
+	config, err := loadConfig("go2type.web.yaml", io.Discard)
+	if err != nil || len(config.Packages) != 1 {
+		t.Errorf("Expected go2type.web.yaml to configure the api package, got %+v, %v", config, err)
+	}
+	if err := initConfig("go2type.web.yaml"); err == nil {
+		t.Errorf("Expected an error when the file already exists")
+	}
+}
+
+func TestEmitMeta(t *testing.T) {
+	types := []TypeInfo{{Name: "User", Fields: []FieldInfo{{Name: "id", Type: "number", JSONName: "id"}}}}
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+		{Name: "ListUsers", Method: "GET", Path: "/users", OutputType: "User[]"},
+	}
+	opts := GenerateFileOptions{
+		Types:            types,
+		Handlers:         handlers,
+		OutputFile:       filepath.Join(createTempFolder(t.Name()), "api.generated.ts"),
+		AuthTokenStorage: "localStorage",
+		EmitMeta:         true,
+	}
+
+	content := renderTestFile(t, opts)
+	for _, expected := range []string{
+		"export const __meta = {",
+		"version: '" + Version + "',",
+		"{ name: 'GetUser', method: 'GET', path: '/users/:id' },",
+		"{ name: 'ListUsers', method: 'GET', path: '/users' },",
+		"types: ['User'],",
+		"} as const;",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q in generated file:\n%s", expected, content)
+		}
+	}
+	if !metaGeneratedAtRegex.MatchString(content) {
+		t.Errorf("Expected __meta to record when it was generated:\n%s", content)
+	}
+
+	// The time in __meta alone doesn't make the file change
+	stale := metaGeneratedAtRegex.ReplaceAllLiteralString(content, "  generatedAt: '2001-01-01T00:00:00Z',")
+	if err := os.WriteFile(opts.OutputFile, []byte(stale), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", opts.OutputFile, err)
+	}
+	if written, err := generateFile(opts); err != nil || written {
+		t.Errorf("Expected the file not to be rewritten, got %v, %v", written, err)
+	}
+
+	opts.EmitMeta = false
+	if content := renderTestFile(t, opts); strings.Contains(content, "__meta") {
+		t.Errorf("Expected no __meta without emit_meta")
+	}
+}
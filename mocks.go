@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mockFileName is the file the MSW request handlers are written to with emit_mocks, next to the output
+const mockFileName = "msw-handlers.generated.ts"
+
+// mswMethods are the HTTP methods with a request handler function of their own in MSW's http
+// namespace. Handlers with any other method use http.all.
+var mswMethods = map[string]bool{"get": true, "post": true, "put": true, "patch": true, "delete": true, "head": true, "options": true}
+
+// mockFactory builds placeholder values of the generated types, for mock responses
+type mockFactory struct {
+	types     map[string]TypeInfo
+	enumStyle string
+	// values are the types referenced as values, such as enums declared as TypeScript enums
+	values map[string]bool
+	// building are the types whose placeholders are being built, so recursive types end
+	building map[string]bool
+}
+
+func newMockFactory(types []TypeInfo, enumStyle string) *mockFactory {
+	f := &mockFactory{
+		types:     make(map[string]TypeInfo),
+		enumStyle: enumStyle,
+		values:    make(map[string]bool),
+		building:  make(map[string]bool),
+	}
+	for _, t := range types {
+		f.types[strings.Split(t.Name, " ")[0]] = t
+	}
+	return f
+}
+
+// value returns a placeholder of the TypeScript type tsType: zero values for primitives, empty
+// arrays and maps, null for nullable types and objects with every required field for structs.
+// Types that can't be built field by field, such as generics and unions, are cast from {}.
+func (f *mockFactory) value(tsType string) string {
+	tsType = strings.TrimSpace(tsCommentRegex.ReplaceAllString(tsType, ""))
+
+	if parts := splitTopLevel(tsType, " | "); len(parts) > 1 {
+		for _, part := range parts {
+			if strings.TrimSpace(part) == "null" {
+				return "null"
+			}
+		}
+		for _, part := range parts {
+			if part = strings.TrimSpace(part); part != "undefined" {
+				return f.value(part)
+			}
+		}
+		return "undefined"
+	}
+
+	switch {
+	case tsType == "string":
+		return "''"
+	case tsType == "number":
+		return "0"
+	case tsType == "boolean":
+		return "false"
+	case tsType == "Date":
+		return "new Date(0)"
+	case tsType == "null" || tsType == "unknown" || tsType == "any":
+		return "null"
+	case tsLiteralRegex.MatchString(tsType):
+		return tsType
+	case strings.HasPrefix(tsType, "Array<") && strings.HasSuffix(tsType, ">"):
+		return "[]"
+	case tsRecordRegex.MatchString(tsType):
+		return "{}"
+	}
+
+	t, ok := f.types[tsType]
+	if !ok || f.building[tsType] {
+		return fmt.Sprintf("{} as %s", tsType)
+	}
+	f.building[tsType] = true
+	defer delete(f.building, tsType)
+
+	switch {
+	case len(t.EnumValues) > 0:
+		if f.enumStyle == "enum" {
+			if members := enumMemberNames(t); members != nil {
+				f.values[tsType] = true
+				return tsType + "." + members[0]
+			}
+		}
+		return t.EnumValues[0]
+	case strings.HasPrefix(t.Derived, "Brand<"):
+		// Branded types such as UserID = Brand<string, 'User'> are a cast of their base type
+		base := splitTopLevel(strings.TrimSuffix(strings.TrimPrefix(t.Derived, "Brand<"), ">"), ", ")[0]
+		return fmt.Sprintf("%s as %s", f.value(base), tsType)
+	case strings.Contains(t.Derived, " & "):
+		// As are intersections such as NonNegativeInt = number & { readonly __brand: 'NonNegativeInt' }
+		return fmt.Sprintf("%s as %s", f.value(splitTopLevel(t.Derived, " & ")[0]), tsType)
+	case t.TSType != "":
+		return f.value(t.TSType)
+	case t.Derived != "" || t.Union || len(t.TypeParams) > 0 || len(t.Extends) > 0:
+		return fmt.Sprintf("{} as %s", tsType)
+	}
+
+	var entries []string
+	for _, field := range t.Fields {
+		if field.IsOptional {
+			continue
+		}
+		entries = append(entries, fmt.Sprintf("%s: %s", tsPropertyName(field.Name), f.value(field.Type)))
+	}
+	if len(entries) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(entries, ", ") + " }"
+}
+
+// mockHandler renders the MSW request handler of h, responding with a placeholder of its success
+// output in its envelope, or with 204 No Content when it has none
+func mockHandler(h HandlerInfo, f *mockFactory, baseURL string) string {
+	method := strings.ToLower(h.Method)
+	if !mswMethods[method] {
+		method = "all"
+	}
+	output := successType(h)
+	if output == "" || output == "void" {
+		return fmt.Sprintf("  http.%s(%s, () => new HttpResponse(null, { status: 204 })),\n", method, requestPath(baseURL, h.Path))
+	}
+	value := f.value(output)
+	if h.Envelope != "" {
+		value = fmt.Sprintf("{ %s: %s }", h.Envelope, value)
+	}
+	return fmt.Sprintf("  http.%s(%s, () => HttpResponse.json<%s>(%s)),\n", method, requestPath(baseURL, h.Path), envelopeType(h), value)
+}
+
+// mockFilePath returns the path of the MSW handlers of opts, or "" when they aren't generated
+func mockFilePath(opts GenerateFileOptions) string {
+	if !opts.EmitMocks || len(opts.Namespaces) > 0 {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(opts.OutputFile), mockFileName)
+}
+
+// generateMockFile writes an MSW request handler for every handler of opts, importing the types
+// of the responses from the output file, when emit_mocks is set. It reports whether it was written.
+func generateMockFile(opts GenerateFileOptions) (bool, error) {
+	mockPath := mockFilePath(opts)
+	if mockPath == "" {
+		return false, nil
+	}
+	if filepath.Clean(mockPath) == filepath.Clean(opts.OutputFile) {
+		return false, fmt.Errorf("output path %s is also the file mocks are written to", opts.OutputFile)
+	}
+
+	f := newMockFactory(opts.Types, opts.EnumStyle)
+	var handlers strings.Builder
+	for _, h := range opts.Handlers {
+		handlers.WriteString(mockHandler(h, f, opts.BaseURL))
+	}
+
+	// Import the generated types the placeholders reference, as values where they're used as one
+	var typeImports, valueImports []string
+	seen := make(map[string]bool)
+	for _, name := range typeIdentifierRegex.FindAllString(handlers.String(), -1) {
+		if _, ok := f.types[name]; !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if f.values[name] {
+			valueImports = append(valueImports, name)
+		} else {
+			typeImports = append(typeImports, name)
+		}
+	}
+	sort.Strings(typeImports)
+	sort.Strings(valueImports)
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "// This file is auto-generated. DO NOT EDIT.\n// Generated by go2type %s on %s\n\n", Version, time.Now().Format(time.RFC3339))
+	content.WriteString("import { http, HttpResponse } from 'msw';\n")
+	if len(typeImports) > 0 {
+		fmt.Fprintf(&content, "import type { %s } from '%s';\n", strings.Join(typeImports, ", "), importPath(opts.OutputFile))
+	}
+	if len(valueImports) > 0 {
+		fmt.Fprintf(&content, "import { %s } from '%s';\n", strings.Join(valueImports, ", "), importPath(opts.OutputFile))
+	}
+	if env := baseURLEnv(opts.BaseURL); env != "" {
+		fmt.Fprintf(&content, "\nconst baseURL = (process.env.%s ?? '').replace(/\\/+$/, '');\n", env)
+	}
+	content.WriteString("\n// Request handlers responding to every endpoint with placeholder data, e.g. for\n")
+	content.WriteString("// setupServer(...handlers) in tests. Override them with server.use() where a test needs real data.\n")
+	fmt.Fprintf(&content, "export const handlers = [\n%s];\n", handlers.String())
+
+	return writeGeneratedFile(opts, mockPath, content.String())
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmitMocks(t *testing.T) {
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{
+			{Name: "id", Type: "UserID", JSONName: "id"},
+			{Name: "name", Type: "string", JSONName: "name"},
+			{Name: "admin", Type: "boolean", JSONName: "admin"},
+			{Name: "tags", Type: "Array<string>", JSONName: "tags"},
+			{Name: "manager", Type: "User | null", JSONName: "manager"},
+			{Name: "role", Type: "Role", JSONName: "role"},
+			{Name: "nickname", Type: "string", JSONName: "nickname", IsOptional: true},
+		}},
+		{Name: "UserID", Derived: "Brand<number, 'User'>"},
+		{Name: "Role", EnumValues: []string{"'admin'", "'member'"}},
+	}
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+		{Name: "ListUsers", Method: "GET", Path: "/users", OutputType: "Array<User>"},
+		{Name: "DeleteUser", Method: "DELETE", Path: "/users/:id", URLParams: []string{"id"}},
+	}
+	outputFile := filepath.Join(createTempFolder(t.Name()), "api.generated.ts")
+	renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, OutputFile: outputFile, EmitMocks: true})
+
+	mocks := readSplitFile(t, outputFile, mockFileName)
+	for _, expected := range []string{
+		"import { http, HttpResponse } from 'msw';\n",
+		"import type { User, UserID } from './api.generated';\n",
+		"  http.get('/users/:id', () => HttpResponse.json<User>({ id: 0 as UserID, name: '', admin: false, tags: [], manager: null, role: 'admin' })),\n",
+		"  http.get('/users', () => HttpResponse.json<Array<User>>([])),\n",
+		"  http.delete('/users/:id', () => new HttpResponse(null, { status: 204 })),\n",
+	} {
+		if !strings.Contains(mocks, expected) {
+			t.Errorf("Expected the mocks to contain %q, got:\n%s", expected, mocks)
+		}
+	}
+	if strings.Contains(mocks, "nickname") {
+		t.Errorf("Expected optional fields to be left out of placeholders, got:\n%s", mocks)
+	}
+	if paths := outputFiles(GenerateFileOptions{OutputFile: outputFile, EmitMocks: true}); len(paths) != 2 || paths[1] != filepath.Join(filepath.Dir(outputFile), mockFileName) {
+		t.Errorf("Expected the mocks to be one of the output files, got %v", paths)
+	}
+
+	outputFile = filepath.Join(createTempFolder(t.Name()+"Off"), "api.generated.ts")
+	renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, OutputFile: outputFile})
+	if _, err := os.Stat(filepath.Join(filepath.Dir(outputFile), mockFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected no mocks without emit_mocks, got %v", err)
+	}
+}
+
+func TestMockValues(t *testing.T) {
+	f := newMockFactory([]TypeInfo{
+		{Name: "Node", Fields: []FieldInfo{{Name: "next", Type: "Node", JSONName: "next"}}},
+		{Name: "Status", EnumValues: []string{"'active'", "'banned'"}, EnumNames: []string{"StatusActive", "StatusBanned"}},
+		{Name: "Count", Derived: "number & { readonly __brand: 'Count' }"},
+		{Name: "Page", TypeParams: []string{"T"}, Fields: []FieldInfo{{Name: "items", Type: "Array<T>", JSONName: "items"}}},
+	}, "enum")
+	for tsType, expected := range map[string]string{
+		"number /* uint */":         "0",
+		"string | undefined":        "''",
+		"Date":                      "new Date(0)",
+		"{ [key: string]: number }": "{}",
+		"'fixed'":                   "'fixed'",
+		"Node":                      "{ next: {} as Node }",
+		"Status":                    "Status.Active",
+		"Count":                     "0 as Count",
+		"Page<Node>":                "{} as Page<Node>",
+		"Missing":                   "{} as Missing",
+	} {
+		if got := f.value(tsType); got != expected {
+			t.Errorf("Expected the placeholder of %s to be %s, got %s", tsType, expected, got)
+		}
+	}
+	if !f.values["Status"] {
+		t.Errorf("Expected an enum declared as a TypeScript enum to be imported as a value")
+	}
+}
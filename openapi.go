@@ -0,0 +1,425 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// OpenAPIOptions contains the command line options for the openapi command
+type OpenAPIOptions struct {
+	OutputFile string
+	Title      string
+	// ConfigFile is the path of the configuration file, go2type.yaml when empty
+	ConfigFile string
+}
+
+func parseOpenAPIFlags(args []string) (OpenAPIOptions, error) {
+	opts := OpenAPIOptions{}
+
+	fs := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	fs.StringVar(&opts.OutputFile, "output", "openapi.yaml", "path of the generated OpenAPI document")
+	fs.StringVar(&opts.Title, "title", "API", "title of the API in the generated document")
+	fs.StringVar(&opts.ConfigFile, "config", defaultConfigFile, "path of the configuration file")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `yaml:"openapi"`
+	Info       openAPIInfo                             `yaml:"info"`
+	Security   []map[string][]string                   `yaml:"security,omitempty"`
+	Paths      map[string]map[string]*openAPIOperation `yaml:"paths"`
+	Components openAPIComponents                       `yaml:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `yaml:"schemas,omitempty"`
+	SecuritySchemes map[string]*openAPISecurityScheme `yaml:"securitySchemes,omitempty"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `yaml:"type"`
+	Scheme string `yaml:"scheme"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `yaml:"operationId"`
+	Description string                      `yaml:"description,omitempty"`
+	Parameters  []openAPIParameter          `yaml:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `yaml:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `yaml:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string         `yaml:"name"`
+	In       string         `yaml:"in"`
+	Required bool           `yaml:"required"`
+	Style    string         `yaml:"style,omitempty"`
+	Explode  *bool          `yaml:"explode,omitempty"`
+	Schema   *openAPISchema `yaml:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                         `yaml:"required"`
+	Content  map[string]*openAPIMediaType `yaml:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `yaml:"description"`
+	Content     map[string]*openAPIMediaType `yaml:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `yaml:"schema"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `yaml:"$ref,omitempty"`
+	Type                 string                    `yaml:"type,omitempty"`
+	Format               string                    `yaml:"format,omitempty"`
+	Nullable             bool                      `yaml:"nullable,omitempty"`
+	Default              interface{}               `yaml:"default,omitempty"`
+	Enum                 []interface{}             `yaml:"enum,omitempty"`
+	Items                *openAPISchema            `yaml:"items,omitempty"`
+	Properties           map[string]*openAPISchema `yaml:"properties,omitempty"`
+	Required             []string                  `yaml:"required,omitempty"`
+	AdditionalProperties *openAPISchema            `yaml:"additionalProperties,omitempty"`
+	AllOf                []*openAPISchema          `yaml:"allOf,omitempty"`
+	OneOf                []*openAPISchema          `yaml:"oneOf,omitempty"`
+}
+
+// generateOpenAPI parses every configured package and writes a single OpenAPI document
+// describing all of their handlers
+func generateOpenAPI(opts OpenAPIOptions) error {
+	config, err := loadConfig(configFileOrDefault(opts.ConfigFile), os.Stdout)
+	if err != nil {
+		return fmt.Errorf("error loading config: %v", err)
+	}
+
+	var allTypes []TypeInfo
+	var allHandlers []HandlerInfo
+	for _, pkg := range config.Packages {
+		pkgTypes, handlers, err := parseConfiguredPackage(config, pkg, os.Stdout)
+		if err != nil {
+			return fmt.Errorf("error parsing package %s: %v", pkg.Path, err)
+		}
+		allTypes, err = mergeTypes(allTypes, pkgTypes, opts.OutputFile)
+		if err != nil {
+			return err
+		}
+		allHandlers = mergeHandlers(allHandlers, handlers, opts.OutputFile, os.Stdout)
+	}
+
+	doc := buildOpenAPIDocument(opts.Title, allTypes, allHandlers, config.AuthToken != "")
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("error encoding OpenAPI document: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.OutputFile), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating directory: %v", err)
+	}
+	if err := os.WriteFile(opts.OutputFile, data, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", opts.OutputFile, err)
+	}
+
+	fmt.Printf("Generated OpenAPI document at %s\n", opts.OutputFile)
+	return nil
+}
+
+var openAPIPathParamRegex = regexp.MustCompile(`:([A-Za-z_]\w*)`)
+
+// buildOpenAPIDocument describes handlers as OpenAPI 3.0 operations, with a schema component
+// for each type. Bearer authentication is declared for every operation when the generated
+// client sends a token.
+func buildOpenAPIDocument(title string, types []TypeInfo, handlers []HandlerInfo, bearerAuth bool) openAPIDocument {
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: title, Version: "1.0.0"},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+	}
+
+	typesByName := make(map[string]TypeInfo)
+	for _, t := range types {
+		typesByName[strings.Split(t.Name, " ")[0]] = t
+	}
+	if len(types) > 0 {
+		doc.Components.Schemas = make(map[string]*openAPISchema)
+		for name, t := range typesByName {
+			doc.Components.Schemas[name] = typeSchema(t, typesByName)
+		}
+	}
+
+	if bearerAuth {
+		doc.Components.SecuritySchemes = map[string]*openAPISecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer"},
+		}
+		doc.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	for _, h := range handlers {
+		path := openAPIPathParamRegex.ReplaceAllString(h.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(h.Method)] = handlerOperation(h, typesByName)
+	}
+
+	return doc
+}
+
+// handlerOperation describes a handler's parameters, request body and responses
+func handlerOperation(h HandlerInfo, typesByName map[string]TypeInfo) *openAPIOperation {
+	op := &openAPIOperation{
+		OperationID: h.Name,
+		Description: h.Description,
+		Responses:   make(map[string]*openAPIResponse),
+	}
+
+	for _, param := range h.URLParams {
+		op.Parameters = append(op.Parameters, openAPIParameter{Name: param, In: "path", Required: true, Schema: &openAPISchema{Type: "string"}})
+	}
+
+	// Object parameters are sent as one query parameter per field
+	explode := true
+	if h.Method == "GET" && h.InputType != "" {
+		op.Parameters = append(op.Parameters, openAPIParameter{Name: "input", In: "query", Required: true, Style: "form", Explode: &explode, Schema: tsTypeSchema(h.InputType, typesByName)})
+	}
+	for _, param := range h.QueryParams {
+		p := openAPIParameter{Name: param.Key, In: "query", Required: !param.Optional, Schema: tsTypeSchema(param.Type, typesByName)}
+		if param.Struct {
+			p.Style, p.Explode = "form", &explode
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	for _, header := range h.Headers {
+		p := openAPIParameter{Name: header.HeaderKey, In: "header", Schema: &openAPISchema{Type: "string"}}
+		switch header.Source {
+		case "input":
+			// Input headers are only sent when the argument is set
+		case "const":
+			p.Required = true
+			p.Schema.Enum = []interface{}{header.Value}
+		default:
+			p.Required = true
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	if h.Method != "GET" && h.InputType != "" {
+		op.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content:  map[string]*openAPIMediaType{"application/json": {Schema: tsTypeSchema(h.InputType, typesByName)}},
+		}
+	}
+
+	success := &openAPIResponse{Description: "OK"}
+	if output := successType(h); output != "" && output != "void" {
+		schema := tsTypeSchema(output, typesByName)
+		if h.Envelope != "" {
+			schema = &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{h.Envelope: schema}, Required: []string{h.Envelope}}
+		}
+		success.Content = map[string]*openAPIMediaType{"application/json": {Schema: schema}}
+	}
+	successCode := "200"
+	for _, output := range h.Outputs {
+		if output.Status >= 200 && output.Status < 300 {
+			successCode = strconv.Itoa(output.Status)
+			break
+		}
+	}
+	for _, status := range h.Statuses {
+		if status.Code >= 200 && status.Code < 300 {
+			successCode = strconv.Itoa(status.Code)
+			if status.Description != "" {
+				success.Description = status.Description
+			}
+			break
+		}
+	}
+	op.Responses[successCode] = success
+
+	for _, status := range h.Statuses {
+		if status.Code < 300 {
+			continue
+		}
+		description := status.Description
+		if description == "" {
+			description = "Error"
+		}
+		op.Responses[strconv.Itoa(status.Code)] = &openAPIResponse{Description: description}
+	}
+	for _, output := range errorOutputs(h) {
+		code := strconv.Itoa(output.Status)
+		response, ok := op.Responses[code]
+		if !ok {
+			response = &openAPIResponse{Description: "Error"}
+			op.Responses[code] = response
+		}
+		response.Content = map[string]*openAPIMediaType{"application/json": {Schema: tsTypeSchema(output.Type, typesByName)}}
+	}
+
+	return op
+}
+
+// typeSchema describes a generated type as an OpenAPI schema
+func typeSchema(t TypeInfo, typesByName map[string]TypeInfo) *openAPISchema {
+	switch {
+	case len(t.EnumValues) > 0:
+		schema := &openAPISchema{}
+		for _, literal := range t.EnumValues {
+			var value interface{}
+			if err := json.Unmarshal([]byte(literal), &value); err != nil {
+				continue
+			}
+			schema.Enum = append(schema.Enum, value)
+		}
+		if len(schema.Enum) > 0 {
+			switch schema.Enum[0].(type) {
+			case string:
+				schema.Type = "string"
+			case bool:
+				schema.Type = "boolean"
+			default:
+				schema.Type = "number"
+			}
+		}
+		return schema
+	case len(t.Extends) > 0:
+		schema := &openAPISchema{}
+		for _, base := range t.Extends {
+			schema.AllOf = append(schema.AllOf, tsTypeSchema(base, typesByName))
+		}
+		return schema
+	case t.TSType != "":
+		return tsTypeSchema(t.TSType, typesByName)
+	case t.Derived != "":
+		base, fields, err := parseDerivedExpr(t.Derived)
+		baseType, ok := typesByName[base]
+		if err != nil || !ok {
+			return &openAPISchema{}
+		}
+		selected := make(map[string]bool)
+		for _, field := range fields {
+			selected[field] = true
+		}
+		pick := strings.HasPrefix(t.Derived, "Pick")
+		var derivedFields []FieldInfo
+		for _, field := range baseType.Fields {
+			if selected[field.Name] == pick {
+				derivedFields = append(derivedFields, field)
+			}
+		}
+		return objectSchema(derivedFields, typesByName)
+	case t.Union:
+		var common, members []FieldInfo
+		for _, field := range t.Fields {
+			switch {
+			case field.Name == t.UnionDiscriminant:
+			case isPointerField(field):
+				members = append(members, field)
+			default:
+				common = append(common, field)
+			}
+		}
+		schema := &openAPISchema{}
+		for _, member := range members {
+			member.IsOptional = false
+			member.Type = nonNullType(member.Type)
+			variant := objectSchema(append(append([]FieldInfo{}, common...), member), typesByName)
+			if t.UnionDiscriminant != "" {
+				variant.Properties[t.UnionDiscriminant] = &openAPISchema{Type: "string", Enum: []interface{}{member.Name}}
+				variant.Required = append(variant.Required, t.UnionDiscriminant)
+				sort.Strings(variant.Required)
+			}
+			schema.OneOf = append(schema.OneOf, variant)
+		}
+		return schema
+	}
+	return objectSchema(t.Fields, typesByName)
+}
+
+// objectSchema describes struct fields as an object schema. Optional fields aren't required.
+func objectSchema(fields []FieldInfo, typesByName map[string]TypeInfo) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	for _, field := range fields {
+		property := tsTypeSchema(field.Type, typesByName)
+		if literal, ok := defaultLiteral(field); ok {
+			var value interface{}
+			if err := json.Unmarshal([]byte(literal), &value); err == nil {
+				if property.Ref != "" {
+					property = &openAPISchema{AllOf: []*openAPISchema{property}}
+				}
+				property.Default = value
+			}
+		}
+		schema.Properties[wireName(field)] = property
+		if !field.IsOptional {
+			schema.Required = append(schema.Required, wireName(field))
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+var (
+	tsFormatCommentRegex = regexp.MustCompile(`\s*/\*\s*([\w-]+)\s*\*/`)
+	tsMapTypeRegex       = regexp.MustCompile(`^\{ \[key: [^\]]+\]: (.+) \}$`)
+)
+
+// tsTypeSchema converts a generated TypeScript type to an OpenAPI schema. Types with a schema
+// component are referenced, and types OpenAPI can't describe, such as any, allow any value.
+func tsTypeSchema(tsType string, typesByName map[string]TypeInfo) *openAPISchema {
+	// Fields that may be left out are optional rather than part of the schema
+	tsType = strings.TrimSuffix(strings.TrimSpace(tsType), " | undefined")
+
+	if strings.HasSuffix(tsType, " | null") {
+		schema := tsTypeSchema(strings.TrimSuffix(tsType, " | null"), typesByName)
+		// Siblings of $ref are ignored in OpenAPI 3.0, so nullable references are wrapped
+		if schema.Ref != "" {
+			return &openAPISchema{AllOf: []*openAPISchema{schema}, Nullable: true}
+		}
+		schema.Nullable = true
+		return schema
+	}
+
+	format := ""
+	if m := tsFormatCommentRegex.FindStringSubmatch(tsType); m != nil {
+		format = m[1]
+		tsType = strings.TrimSpace(tsFormatCommentRegex.ReplaceAllString(tsType, ""))
+	}
+
+	switch {
+	case tsType == "string" || tsType == "number" || tsType == "boolean":
+		return &openAPISchema{Type: tsType, Format: format}
+	case tsType == "Date":
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case strings.HasPrefix(tsType, "Array<") && strings.HasSuffix(tsType, ">"):
+		return &openAPISchema{Type: "array", Items: tsTypeSchema(tsType[len("Array<"):len(tsType)-1], typesByName)}
+	case tsMapTypeRegex.MatchString(tsType):
+		value := tsMapTypeRegex.FindStringSubmatch(tsType)[1]
+		return &openAPISchema{Type: "object", AdditionalProperties: tsTypeSchema(value, typesByName)}
+	}
+	if _, ok := typesByName[tsType]; ok {
+		return &openAPISchema{Ref: "#/components/schemas/" + tsType}
+	}
+	return &openAPISchema{}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestBuildOpenAPIDocument(t *testing.T) {
+	types := []TypeInfo{
+		{Name: "User", Fields: []FieldInfo{
+			{Name: "id", Type: "number"},
+			{Name: "email", Type: "string"},
+			{Name: "created_at", Type: "string /* date-time */"},
+			{Name: "manager", Type: "User | null", IsOptional: true},
+			{Name: "tags", Type: "Array<string>"},
+			{Name: "status", Type: "Status", Default: "active"},
+			{Name: "page_size", Type: "number", Default: "25", IsOptional: true},
+		}},
+		{Name: "Status", EnumValues: []string{`"active"`, `"disabled"`}},
+		{Name: "UpdateUserInput", Derived: `Pick<User, "email">`},
+		{Name: "UserFilter", Fields: []FieldInfo{{Name: "role", Type: "string"}}},
+	}
+	handlers := []HandlerInfo{
+		{
+			Name:        "GetUser",
+			Method:      "GET",
+			Path:        "/users/:id",
+			OutputType:  "User",
+			URLParams:   []string{"id"},
+			Headers:     []HeaderInfo{parseHeaderDirective("input:X-Request-ID", io.Discard), parseHeaderDirective("const:X-Client:web", io.Discard)},
+			QueryParams: []QueryParamInfo{{Key: "expand", Name: "expand", Type: "boolean", Optional: true}, {Key: "queryParams", Name: "queryParams", Type: "UserFilter", Struct: true}},
+			Statuses:    []StatusInfo{{Code: 404, Description: "User not found"}},
+		},
+		{
+			Name:       "UpdateUser",
+			Method:     "PUT",
+			Path:       "/users/:id",
+			InputType:  "UpdateUserInput",
+			OutputType: "User",
+			URLParams:  []string{"id"},
+			Statuses:   []StatusInfo{{Code: 202, Description: "Accepted"}},
+		},
+	}
+
+	doc := buildOpenAPIDocument("Users", types, handlers, true)
+
+	get := doc.Paths["/users/{id}"]["get"]
+	if get == nil || doc.Paths["/users/{id}"]["put"] == nil {
+		t.Fatalf("Expected get and put operations on /users/{id}, got %+v", doc.Paths)
+	}
+	var params []string
+	for _, p := range get.Parameters {
+		params = append(params, p.In+":"+p.Name)
+	}
+	expectedParams := []string{"path:id", "query:expand", "query:queryParams", "header:X-Request-ID", "header:X-Client"}
+	if !reflect.DeepEqual(params, expectedParams) {
+		t.Errorf("Expected parameters %v, got %v", expectedParams, params)
+	}
+	if get.Parameters[1].Required || !get.Parameters[2].Required || get.Parameters[2].Style != "form" {
+		t.Errorf("Unexpected query parameters %+v", get.Parameters[1:3])
+	}
+	if get.Responses["200"].Content["application/json"].Schema.Ref != "#/components/schemas/User" {
+		t.Errorf("Expected the 200 response to reference User, got %+v", get.Responses["200"])
+	}
+	if get.Responses["404"].Description != "User not found" {
+		t.Errorf("Expected a 404 response, got %+v", get.Responses)
+	}
+
+	put := doc.Paths["/users/{id}"]["put"]
+	if put.RequestBody == nil || put.RequestBody.Content["application/json"].Schema.Ref != "#/components/schemas/UpdateUserInput" {
+		t.Errorf("Expected a request body referencing UpdateUserInput, got %+v", put.RequestBody)
+	}
+	if _, ok := put.Responses["202"]; !ok {
+		t.Errorf("Expected the declared success status to be used, got %+v", put.Responses)
+	}
+
+	user := doc.Components.Schemas["User"]
+	if !reflect.DeepEqual(user.Required, []string{"created_at", "email", "id", "status", "tags"}) {
+		t.Errorf("Unexpected required fields %v", user.Required)
+	}
+	if user.Properties["created_at"].Format != "date-time" || user.Properties["tags"].Items.Type != "string" {
+		t.Errorf("Unexpected User properties %+v", user.Properties)
+	}
+	if manager := user.Properties["manager"]; !manager.Nullable || manager.AllOf[0].Ref != "#/components/schemas/User" {
+		t.Errorf("Expected a nullable reference for manager, got %+v", manager)
+	}
+	if pageSize := user.Properties["page_size"]; pageSize.Default != float64(25) {
+		t.Errorf("Expected page_size to default to 25, got %+v", pageSize)
+	}
+	if status := user.Properties["status"]; status.Default != "active" || status.AllOf[0].Ref != "#/components/schemas/Status" {
+		t.Errorf("Expected status to default to active, got %+v", status)
+	}
+	if status := doc.Components.Schemas["Status"]; status.Type != "string" || !reflect.DeepEqual(status.Enum, []interface{}{"active", "disabled"}) {
+		t.Errorf("Unexpected Status schema %+v", status)
+	}
+	if input := doc.Components.Schemas["UpdateUserInput"]; len(input.Properties) != 1 || input.Properties["email"] == nil {
+		t.Errorf("Expected UpdateUserInput to only have email, got %+v", input.Properties)
+	}
+
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Failed to encode document: %v", err)
+	}
+	expected := []string{
+		"openapi: 3.0.3",
+		"title: Users",
+		"/users/{id}:",
+		"operationId: GetUser",
+		"$ref: '#/components/schemas/User'",
+		"bearerAuth:",
+	}
+	for _, str := range expected {
+		if !strings.Contains(string(data), str) {
+			t.Errorf("Expected string not found in document: %s\n%s", str, data)
+		}
+	}
+}
+
+func TestGenerateOpenAPIConfigFile(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"api/users.go": `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`,
+		"go2type.api.yaml": "packages:\n  - path: api\n    output_path: out/api.generated.ts\n",
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	// There's no go2type.yaml, so the document is only generated from the one --config names
+	opts, err := parseOpenAPIFlags([]string{"--config", "go2type.api.yaml", "--output", "out/openapi.yaml"})
+	if err != nil || opts.ConfigFile != "go2type.api.yaml" {
+		t.Fatalf("Expected --config to be parsed, got %+v, %v", opts, err)
+	}
+	captureOutput(t, func() {
+		if err := generateOpenAPI(opts); err != nil {
+			t.Errorf("Failed to generate OpenAPI document: %v", err)
+		}
+	})
+	data, err := os.ReadFile(filepath.Join(dir, "out", "openapi.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read OpenAPI document: %v", err)
+	}
+	if !strings.Contains(string(data), "/users/{id}:") {
+		t.Errorf("Expected the handler of the configured package, got:\n%s", data)
+	}
+}
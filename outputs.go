@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// OutputVariant is one of the outputs of a handler declaring several, such as @Output 200 User and
+// @Output 400 ValidationErrors
+type OutputVariant struct {
+	// Status is the status code the output is returned with, or 0 for an @Output without one, which
+	// is returned with any success status
+	Status int
+	// Type is the TypeScript type of the output
+	Type string
+}
+
+// parseOutputDirective parses an @Output directive, whose type may be preceded by the status code
+// it's returned with, e.g. 400 ValidationErrors
+func parseOutputDirective(directive string) OutputVariant {
+	var status int
+	if code, rest, ok := strings.Cut(directive, " "); ok {
+		if n, err := strconv.Atoi(code); err == nil && n >= 100 && n <= 599 {
+			status, directive = n, strings.TrimSpace(rest)
+		}
+	}
+	return OutputVariant{Status: status, Type: outputDirectiveType(directive)}
+}
+
+// outputUnion returns the union of the types of outputs, each type once, e.g. User | ValidationErrors
+func outputUnion(outputs []OutputVariant) string {
+	var types []string
+	seen := make(map[string]bool)
+	for _, output := range outputs {
+		if !seen[output.Type] {
+			seen[output.Type] = true
+			types = append(types, output.Type)
+		}
+	}
+	return strings.Join(types, " | ")
+}
+
+// successType returns the type of a handler's successful responses: the union of its outputs
+// returned with a success status, or its output when it doesn't declare several
+func successType(h HandlerInfo) string {
+	if len(h.Outputs) == 0 {
+		return h.OutputType
+	}
+	var outputs []OutputVariant
+	for _, output := range h.Outputs {
+		if output.Status < 300 {
+			outputs = append(outputs, output)
+		}
+	}
+	return outputUnion(outputs)
+}
+
+// errorOutputs returns the outputs of a handler that are returned with an error status. The query
+// function resolves to them instead of throwing an APIError.
+func errorOutputs(h HandlerInfo) []OutputVariant {
+	var outputs []OutputVariant
+	for _, output := range h.Outputs {
+		if output.Status >= 300 {
+			outputs = append(outputs, output)
+		}
+	}
+	return outputs
+}
+
+// hasErrorOutputs reports whether any of handlers has an output returned with an error status
+func hasErrorOutputs(handlers []HandlerInfo) bool {
+	for _, h := range handlers {
+		if len(errorOutputs(h)) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOutputVariants(t *testing.T) {
+	src := `package api
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type ValidationErrors struct {
+	Fields map[string]string ` + "`json:\"fields\"`" + `
+}
+
+type Conflict struct {
+	Existing int ` + "`json:\"existing_id\"`" + `
+}
+
+// @Method POST
+// @Path /users
+// @Output 201 User
+// @Output 400 ValidationErrors
+// @Output 409 Conflict
+func CreateUserHandler() {}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+// @Output 404 ValidationErrors
+func GetUserHandler() {}
+
+// @Method DELETE
+// @Path /users/:id
+// @Output 409 Conflict
+func DeleteUserHandler() {}
+`
+	types, handlers, err := parseSource([]byte(src), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	if len(handlers) != 3 {
+		t.Fatalf("Expected 3 handlers, got %+v", handlers)
+	}
+	expected := []OutputVariant{{Status: 201, Type: "User"}, {Status: 400, Type: "ValidationErrors"}, {Status: 409, Type: "Conflict"}}
+	if !reflect.DeepEqual(handlers[0].Outputs, expected) || handlers[0].OutputType != "User | ValidationErrors | Conflict" {
+		t.Errorf("Expected an output per status, got %+v", handlers[0])
+	}
+	if handlers[1].OutputType != "User | ValidationErrors" || successType(handlers[1]) != "User" {
+		t.Errorf("Expected the @Output without a status to be the success output, got %+v", handlers[1])
+	}
+	if handlers[2].OutputType != "void | Conflict" || successType(handlers[2]) != "void" {
+		t.Errorf("Expected a handler with only error outputs to have no success content, got %+v", handlers[2])
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseHooks: true, UseReactQuery: true})
+	for _, expected := range []string{
+		"export const CreateUserQuery = async (onResponse?: (response: Response) => void): Promise<User | ValidationErrors | Conflict> => {",
+		`try {
+    return await createQuery<void, User>('POST', url, undefined, headers, onResponse);
+  } catch (error) {
+    // Responses with a status declared by @Output are returned rather than thrown
+    if (error instanceof APIError && error.status === 400) {
+      return error.body as unknown as ValidationErrors;
+    }
+    if (error instanceof APIError && error.status === 409) {
+      return error.body as unknown as Conflict;
+    }
+    throw error;
+  }
+};`,
+		"return await createQuery<void, void>('DELETE', url, undefined, headers, onResponse);",
+		"): UseQueryResult<User | ValidationErrors, APIError> =>",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, content)
+		}
+	}
+
+	angular := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseAngular: true})
+	for _, expected := range []string{
+		"import { HttpClient, HttpErrorResponse, HttpParams } from '@angular/common/http';",
+		"import { Observable, catchError, of, throwError } from 'rxjs';",
+		"createUser(): Observable<User | ValidationErrors | Conflict> {",
+		"if (error instanceof HttpErrorResponse && error.status === 400) {\n          return of(error.error as ValidationErrors);",
+		"return throwError(() => error);",
+	} {
+		if !strings.Contains(angular, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, angular)
+		}
+	}
+
+	doc := buildOpenAPIDocument("Users", types, handlers, false)
+	op := doc.Paths["/users"]["post"]
+	for code, ref := range map[string]string{"201": "User", "400": "ValidationErrors", "409": "Conflict"} {
+		if response := op.Responses[code]; response == nil || response.Content["application/json"].Schema.Ref != "#/components/schemas/"+ref {
+			t.Errorf("Expected the %s response to be a %s, got %+v", code, ref, response)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// goGenericTypeRegex matches an instantiated generic type in a directive, e.g. Paginated[User]
+var goGenericTypeRegex = regexp.MustCompile(`^[A-Za-z_]\w*\[.+\]$`)
+
+// tsGenericType converts an instantiated generic type in a directive to TypeScript, so
+// Paginated[User] becomes Paginated<User>. Other types are returned unchanged.
+func tsGenericType(typeName string) string {
+	if !goGenericTypeRegex.MatchString(typeName) {
+		return typeName
+	}
+	return strings.NewReplacer("[", "<", "]", ">").Replace(typeName)
+}
+
+// paginateHandler wraps the output of a handler annotated with @Paginated in the pagination
+// envelope, e.g. User becomes Paginated<User>, and marks handlers whose output already is the
+// envelope as paginated. Warnings are printed to w.
+func paginateHandler(h HandlerInfo, envelope string, w io.Writer) HandlerInfo {
+	if envelope == "" {
+		if h.Paginated {
+			fmt.Fprintf(w, "Warning: @Paginated on %s requires pagination_envelope. Its output won't be wrapped.\n", h.Name)
+		}
+		return h
+	}
+	if strings.HasPrefix(h.OutputType, envelope+"<") {
+		h.Paginated = true
+		return h
+	}
+	if h.Paginated {
+		if h.OutputType == "" {
+			fmt.Fprintf(w, "Warning: @Paginated on %s has no @Output to wrap\n", h.Name)
+			return h
+		}
+		h.OutputType = envelope + "<" + h.OutputType + ">"
+	}
+	return h
+}
+
+// Envelope fields are recognized by their name
+var (
+	pageFieldNames       = []string{"page", "page_number", "pageNumber", "current_page", "currentPage"}
+	totalFieldNames      = []string{"total", "total_count", "totalCount", "total_items", "totalItems", "count"}
+	pageSizeFieldNames   = []string{"page_size", "pageSize", "per_page", "perPage", "limit", "size"}
+	totalPagesFieldNames = []string{"total_pages", "totalPages", "page_count", "pageCount"}
+)
+
+// envelopeField returns the name of the first of t's fields that has one of names
+func envelopeField(t TypeInfo, names []string) string {
+	for _, name := range names {
+		for _, field := range t.Fields {
+			if field.Name == name {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// paginationHelpers renders the hasNextPage and getPage helpers for the pagination envelope, or
+// returns an empty string for other types. Pages are numbered from 1, and the page count is read
+// from a total pages field or worked out from the total and page size fields. Envelopes the helpers
+// can't be generated for are warned about on w.
+func paginationHelpers(t TypeInfo, envelope string, w io.Writer) string {
+	name := strings.Split(t.Name, " ")[0]
+	if envelope == "" || name != envelope {
+		return ""
+	}
+	if len(t.TypeParams) != 1 {
+		fmt.Fprintf(w, "Warning: pagination_envelope %s must have one type parameter, the item type. No pagination helpers will be generated.\n", envelope)
+		return ""
+	}
+
+	page := envelopeField(t, pageFieldNames)
+	pageCount := ""
+	if totalPages := envelopeField(t, totalPagesFieldNames); totalPages != "" {
+		pageCount = "result." + totalPages
+	} else if total, pageSize := envelopeField(t, totalFieldNames), envelopeField(t, pageSizeFieldNames); total != "" && pageSize != "" {
+		pageCount = fmt.Sprintf("Math.ceil(result.%s / result.%s)", total, pageSize)
+	}
+	if page == "" || pageCount == "" {
+		fmt.Fprintf(w, "Warning: pagination_envelope %s needs a page field and a total pages field, or total and page size fields. No pagination helpers will be generated.\n", envelope)
+		return ""
+	}
+
+	return fmt.Sprintf(`// Returns the number of the page offset pages from result's, or undefined if there's no such page.
+// getPage(result) is the next page, e.g. for React Query's getNextPageParam, and getPage(result, -1)
+// the previous one. Pages are numbered from 1.
+export const getPage = <T>(result: %[1]s<T>, offset = 1): number | undefined => {
+  const page = result.%[2]s + offset;
+  return page >= 1 && page <= %[3]s ? page : undefined;
+};
+// Reports whether there are pages after result's
+export const hasNextPage = <T>(result: %[1]s<T>): boolean => getPage(result) !== undefined;
+`, name, page, pageCount)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPaginatedHandlers(t *testing.T) {
+	src := `package api
+
+type Paginated[T any] struct {
+	Items    []T ` + "`json:\"items\"`" + `
+	Total    int ` + "`json:\"total\"`" + `
+	Page     int ` + "`json:\"page\"`" + `
+	PageSize int ` + "`json:\"page_size\"`" + `
+}
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type Order struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users
+// @Output Paginated[User]
+func ListUsersHandler() {}
+
+// @Method GET
+// @Path /orders
+// @Output Order
+// @Paginated
+func ListOrdersHandler() {}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`
+	types, handlers, err := parseSource([]byte(src), ParseOptions{PaginationEnvelope: "Paginated"})
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	outputs := make(map[string]HandlerInfo)
+	for _, h := range handlers {
+		outputs[h.Name] = h
+	}
+	for name, expected := range map[string]string{
+		"ListUsers":  "Paginated<User>",
+		"ListOrders": "Paginated<Order>",
+		"GetUser":    "User",
+	} {
+		if h := outputs[name]; h.OutputType != expected || h.Paginated != (expected != "User") {
+			t.Errorf("Expected %s to output %s, got %s (paginated: %v)", name, expected, h.OutputType, h.Paginated)
+		}
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, PaginationEnvelope: "Paginated"})
+	for _, expected := range []string{
+		"export type Paginated<T> = {",
+		"items: Array<T>;",
+		"export const ListUsersQuery = async (onResponse?: (response: Response) => void): Promise<Paginated<User>>",
+		"export const ListOrdersQuery = async (onResponse?: (response: Response) => void): Promise<Paginated<Order>>",
+		"export const getPage = <T>(result: Paginated<T>, offset = 1): number | undefined => {",
+		"return page >= 1 && page <= Math.ceil(result.total / result.page_size) ? page : undefined;",
+		"export const hasNextPage = <T>(result: Paginated<T>): boolean => getPage(result) !== undefined;",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, content)
+		}
+	}
+}
+
+func TestPaginationHelpersTotalPages(t *testing.T) {
+	envelope := TypeInfo{Name: "Page", TypeParams: []string{"T"}, Fields: []FieldInfo{
+		{Name: "data", Type: "Array<T>"},
+		{Name: "current_page", Type: "number"},
+		{Name: "total_pages", Type: "number"},
+	}}
+	helpers := paginationHelpers(envelope, "Page", io.Discard)
+	if !strings.Contains(helpers, "const page = result.current_page + offset;") || !strings.Contains(helpers, "page <= result.total_pages ?") {
+		t.Errorf("Expected the helpers to read current_page and total_pages, got:\n%s", helpers)
+	}
+
+	if helpers := paginationHelpers(envelope, "Paginated", io.Discard); helpers != "" {
+		t.Errorf("Expected no helpers for a type that isn't the envelope, got:\n%s", helpers)
+	}
+	envelope.Fields = envelope.Fields[:2]
+	if helpers := paginationHelpers(envelope, "Page", io.Discard); helpers != "" {
+		t.Errorf("Expected no helpers without a page count, got:\n%s", helpers)
+	}
+}
+
+func TestPaginatedWithoutEnvelope(t *testing.T) {
+	h := paginateHandler(HandlerInfo{Name: "ListUsers", OutputType: "User", Paginated: true}, "", io.Discard)
+	if h.OutputType != "User" {
+		t.Errorf("Expected the output to be unchanged without pagination_envelope, got %s", h.OutputType)
+	}
+	if got := tsGenericType("Pair[User, Order]"); got != "Pair<User, Order>" {
+		t.Errorf("Expected Pair<User, Order>, got %s", got)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pathArgs returns the parameters of a handler's path builder: its URL params, which may be
+// numbers as they're encoded as text, then its query params with the optional ones last
+func pathArgs(h HandlerInfo) []queryArg {
+	var args []queryArg
+	for _, param := range h.URLParams {
+		args = append(args, queryArg{Name: param, Type: "string | number"})
+	}
+	for _, arg := range queryArgs(h) {
+		for _, param := range h.QueryParams {
+			if arg.Name == param.Name {
+				args = append(args, arg)
+			}
+		}
+	}
+	return args
+}
+
+// pathParams renders the parameter of a handler's path builder, an object destructured into
+// variables named like the query function's arguments, e.g. { id, page }: { id: string | number;
+// page?: number }. It defaults to an empty object when every field is optional, and is empty for
+// handlers without URL or query params.
+func pathParams(h HandlerInfo) string {
+	args := pathArgs(h)
+	if len(args) == 0 {
+		return ""
+	}
+	names := make([]string, len(args))
+	fields := make([]string, len(args))
+	required := false
+	for i, arg := range args {
+		names[i] = arg.Name
+		if arg.Optional {
+			fields[i] = fmt.Sprintf("%s?: %s", arg.Name, arg.Type)
+		} else {
+			fields[i] = fmt.Sprintf("%s: %s", arg.Name, arg.Type)
+			required = true
+		}
+	}
+	param := fmt.Sprintf("{ %s }: { %s }", strings.Join(names, ", "), strings.Join(fields, "; "))
+	if !required {
+		param += " = {}"
+	}
+	return param
+}
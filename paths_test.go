@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPathBuilders(t *testing.T) {
+	handlers := []HandlerInfo{
+		{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "string", URLParams: []string{"id"}},
+		{Name: "ListUsers", Method: "GET", Path: "/orgs/:org/users", OutputType: "string", URLParams: []string{"org"}, QueryParams: []QueryParamInfo{
+			{Key: "page", Name: "page", Type: "number", Optional: true},
+			{Key: "ids", Name: "ids", Type: "Array<number>"},
+		}},
+		{Name: "SearchUsers", Method: "GET", Path: "/users/search", OutputType: "string", QueryParams: []QueryParamInfo{
+			{Key: "q", Name: "q", Type: "string", Optional: true},
+		}},
+		{Name: "GetHealth", Method: "GET", Path: "/health", OutputType: "string"},
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Handlers: handlers, EmitPaths: true, HTTPClient: "axios"})
+	for _, expected := range []string{
+		"export const Paths = {",
+		"GetUser: ({ id }: { id: string | number }): string => {",
+		"ListUsers: ({ org, ids, page }: { org: string | number; ids: Array<number>; page?: number }): string => {",
+		"SearchUsers: ({ q }: { q?: string } = {}): string => {",
+		"GetHealth: (): string => {",
+		"url = url.replace(':id', encodeURIComponent(id));",
+		"function queryParamEntries(key: string, value: unknown): Array<[string, string]> {",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, content)
+		}
+	}
+	if without := renderTestFile(t, GenerateFileOptions{Handlers: handlers, HTTPClient: "axios"}); strings.Contains(without, "Paths") || strings.Contains(without, "queryParamEntries") {
+		t.Errorf("Expected no path builders without emit_paths, got:\n%s", without)
+	}
+
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found, skipping runtime check")
+	}
+	helper := regexp.MustCompile(`(?s)function queryParamEntries\(.*?\n\}\n`).FindString(content)
+	paths := regexp.MustCompile(`(?s)export const Paths = \{.*?\n\} as const;`).FindString(content)
+	script := regexp.MustCompile(`\}: \{[^}]*\}`).ReplaceAllString(strings.NewReplacer(
+		": Array<[string, string]>", "",
+		": [string, string]", "",
+		"): string =>", ") =>",
+		"key: string, value: unknown", "key, value",
+		"export const", "const",
+		" as const;", ";",
+	).Replace(helper+paths), "}") + `
+for (const [path, expected] of [
+  [Paths.GetUser({ id: 123 }), '/users/123'],
+  [Paths.GetUser({ id: 'a/b' }), '/users/a%2Fb'],
+  [Paths.ListUsers({ org: 'acme', ids: [1, 2], page: 3 }), '/orgs/acme/users?page=3&ids=1&ids=2'],
+  [Paths.SearchUsers(), '/users/search'],
+  [Paths.GetHealth(), '/health'],
+]) {
+  if (path !== expected) {
+    throw new Error('Expected ' + expected + ', got ' + path);
+  }
+}
+`
+	if output, err := exec.Command(node, "-e", script).CombinedOutput(); err != nil {
+		t.Errorf("Path check failed: %v\n%s\n%s", err, output, script)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PollInfo is how the React Query hook of a handler marked with @Poll refetches its query
+type PollInfo struct {
+	// Interval is the number of milliseconds between refetches
+	Interval int
+	// Field is the output field checked to stop polling, status unless set with field=
+	Field string
+	// Until is the TypeScript literal that stops polling once Field has it, or empty to poll
+	// for as long as the query is used
+	Until string
+}
+
+// parsePollDirective parses `interval=2000 until=done field=state` into polling metadata. The
+// interval is required, and until values that aren't numbers or booleans are strings. Invalid
+// options are warned about on w.
+func parsePollDirective(directive string, w io.Writer) (*PollInfo, bool) {
+	poll := &PollInfo{Field: "status"}
+	for _, option := range strings.Fields(directive) {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok || value == "" {
+			fmt.Fprintf(w, "Warning: Invalid @Poll option %s, expected key=value\n", option)
+			return nil, false
+		}
+		switch key {
+		case "interval":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval <= 0 {
+				fmt.Fprintf(w, "Warning: Invalid @Poll interval %s, expected a number of milliseconds\n", value)
+				return nil, false
+			}
+			poll.Interval = interval
+		case "until":
+			poll.Until = pollLiteral(value)
+		case "field":
+			if !tsIdentifierRegex.MatchString(value) {
+				fmt.Fprintf(w, "Warning: Invalid @Poll field %s\n", value)
+				return nil, false
+			}
+			poll.Field = value
+		default:
+			fmt.Fprintf(w, "Warning: Unknown @Poll option %s\n", key)
+		}
+	}
+	if poll.Interval == 0 {
+		fmt.Fprintf(w, "Warning: @Poll %s has no interval\n", directive)
+		return nil, false
+	}
+	return poll, true
+}
+
+// pollLiteral renders an until value as a TypeScript literal
+func pollLiteral(value string) string {
+	if value == "true" || value == "false" {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return tsStringLiteral(value)
+}
+
+// checkPoll warns when @Poll is on a handler without a query hook, or when the output type of a
+// polled handler is in the registry but doesn't have the field its stop condition reads, on w
+func checkPoll(h HandlerInfo, registry *TypeRegistry, w io.Writer) {
+	if h.Poll == nil {
+		return
+	}
+	if !strings.EqualFold(h.Method, "GET") {
+		fmt.Fprintf(w, "Warning: @Poll on %s is ignored, as only GET handlers have query hooks\n", h.Name)
+		return
+	}
+	if h.Poll.Until == "" {
+		return
+	}
+	output, ok := registry.GetType(h.OutputType)
+	if !ok {
+		return
+	}
+	for _, field := range output.Fields {
+		if field.Name == h.Poll.Field {
+			return
+		}
+	}
+	fmt.Fprintf(w, "Warning: @Poll on %s stops when %s is %s, but %s has no %s field\n", h.Name, h.Poll.Field, h.Poll.Until, h.OutputType, h.Poll.Field)
+}
+
+// pollInterval renders the refetchInterval of a polled query: the interval, or a function that
+// returns false to stop polling once the latest data's field has the until value
+func pollInterval(poll PollInfo) string {
+	if poll.Until == "" {
+		return strconv.Itoa(poll.Interval)
+	}
+	return fmt.Sprintf("(query) => (query.state.data?.%s === %s ? false : %d)", poll.Field, poll.Until, poll.Interval)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPollHooks(t *testing.T) {
+	src := `package api
+
+type Job struct {
+	ID     int    ` + "`json:\"id\"`" + `
+	Status string ` + "`json:\"status\"`" + `
+}
+
+type Export struct {
+	Ready bool ` + "`json:\"ready\"`" + `
+}
+
+// @Method GET
+// @Path /jobs/:id
+// @Output Job
+// @Poll interval=2000 until=done
+func GetJobHandler() {}
+
+// @Method GET
+// @Path /exports/:id
+// @Output Export
+// @Poll interval=500 until=true field=ready
+func GetExportHandler() {}
+
+// @Method GET
+// @Path /metrics
+// @Output Job
+// @Poll interval=10000
+func GetMetricsHandler() {}
+`
+	types, handlers, err := parseSource([]byte(src), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types, Handlers: handlers, UseHooks: true, UseReactQuery: true})
+	for _, expected := range []string{
+		"queryFn: () => GetJobQuery(id),\n    refetchInterval: (query) => (query.state.data?.status === \"done\" ? false : 2000),\n    ...options,",
+		"refetchInterval: (query) => (query.state.data?.ready === true ? false : 500),",
+		"refetchInterval: 10000,",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, content)
+		}
+	}
+	if strings.Count(content, "refetchInterval") != 3 {
+		t.Errorf("Expected only the polled hooks to refetch, got:\n%s", content)
+	}
+}
+
+func TestPollDirective(t *testing.T) {
+	var output bytes.Buffer
+	for _, directive := range []string{"until=done", "interval=soon", "interval=-5", "interval=100 field=data.status"} {
+		if _, ok := parsePollDirective(directive, &output); ok {
+			t.Errorf("Expected @Poll %s to be invalid", directive)
+		}
+	}
+	if strings.Count(output.String(), "Warning:") != 4 {
+		t.Errorf("Expected a warning per invalid directive, got:\n%s", output.String())
+	}
+
+	poll, ok := parsePollDirective("interval=1000 until=3", io.Discard)
+	if !ok || poll.Interval != 1000 || poll.Field != "status" || poll.Until != "3" {
+		t.Errorf("Expected a numeric until value, got %+v", poll)
+	}
+
+	registry := &TypeRegistry{Types: map[string]TypeInfo{"Job": {Name: "Job", Fields: []FieldInfo{{Name: "state"}}}}}
+	output.Reset()
+	checkPoll(HandlerInfo{Name: "GetJob", Method: "GET", OutputType: "Job", Poll: poll}, registry, &output)
+	checkPoll(HandlerInfo{Name: "CreateJob", Method: "POST", OutputType: "Job", Poll: poll}, registry, &output)
+	for _, expected := range []string{
+		"Warning: @Poll on GetJob stops when status is 3, but Job has no status field",
+		"Warning: @Poll on CreateJob is ignored, as only GET handlers have query hooks",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected %q, got:\n%s", expected, output.String())
+		}
+	}
+}
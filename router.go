@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RouteInfo is a method and path registered for a handler in a router file
+type RouteInfo struct {
+	Method string
+	Path   string
+}
+
+// defaultRouterPatterns describe the registration call shapes recognised when a package
+// sets a router_file without router_patterns. More specific patterns must come first.
+var defaultRouterPatterns = []string{
+	"HandleFunc(path, handler).Methods(method)",
+	"Handle(path, handler).Methods(method)",
+	"HandleFunc(path, handler)",
+	"Handle(path, handler)",
+}
+
+var httpMethods = map[string]string{
+	"get":     "GET",
+	"post":    "POST",
+	"put":     "PUT",
+	"patch":   "PATCH",
+	"delete":  "DELETE",
+	"head":    "HEAD",
+	"options": "OPTIONS",
+}
+
+// routeParamRegex matches gorilla/mux and net/http style path parameters such as {id}, {id:[0-9]+} or {path...}
+var routeParamRegex = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(?:\.\.\.)?(?::[^}]*)?\}`)
+
+// parseRouterFile scans a router registration file for calls matching one of the patterns and
+// returns the registered routes keyed by handler function name.
+//
+// A pattern is a Go call expression such as `HandleFunc(path, handler).Methods(method)`. The
+// identifiers path, handler and method bind the corresponding arguments, `_` ignores one, and
+// the receiver of the call is ignored. When a pattern has no method argument the method is
+// taken from the call name (e.g. chi's `Get(path, handler)`), from a `GET /path` style path,
+// or defaults to GET.
+func parseRouterFile(filename string, patterns []string) (map[string]RouteInfo, error) {
+	if len(patterns) == 0 {
+		patterns = defaultRouterPatterns
+	}
+
+	var patternExprs []*ast.CallExpr
+	for _, pattern := range patterns {
+		expr, err := parser.ParseExpr(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid router pattern %q: %v", pattern, err)
+		}
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return nil, fmt.Errorf("invalid router pattern %q: must be a call expression", pattern)
+		}
+		patternExprs = append(patternExprs, call)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing router file: %v", err)
+	}
+
+	routes := make(map[string]RouteInfo)
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		for _, pattern := range patternExprs {
+			bindings := make(map[string]ast.Expr)
+			if !matchRouterCall(pattern, call, bindings) {
+				continue
+			}
+
+			route, handler, ok := routeFromBindings(pattern, bindings)
+			if ok {
+				routes[handler] = route
+			}
+			// Don't match the inner calls of a chain we've already handled
+			return false
+		}
+		return true
+	})
+
+	return routes, nil
+}
+
+// matchRouterCall reports whether call has the shape of pattern, recording placeholder arguments in bindings
+func matchRouterCall(pattern, call *ast.CallExpr, bindings map[string]ast.Expr) bool {
+	if len(pattern.Args) != len(call.Args) {
+		return false
+	}
+
+	switch fun := pattern.Fun.(type) {
+	case *ast.Ident:
+		if callName(call) != fun.Name {
+			return false
+		}
+	case *ast.SelectorExpr:
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != fun.Sel.Name {
+			return false
+		}
+		if inner, ok := fun.X.(*ast.CallExpr); ok {
+			innerCall, ok := sel.X.(*ast.CallExpr)
+			if !ok || !matchRouterCall(inner, innerCall, bindings) {
+				return false
+			}
+		}
+	default:
+		return false
+	}
+
+	for i, arg := range pattern.Args {
+		ident, ok := arg.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		switch ident.Name {
+		case "_":
+		case "path", "handler", "method":
+			bindings[ident.Name] = call.Args[i]
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func routeFromBindings(pattern *ast.CallExpr, bindings map[string]ast.Expr) (RouteInfo, string, bool) {
+	pathExpr, ok := bindings["path"]
+	if !ok {
+		return RouteInfo{}, "", false
+	}
+	path, ok := stringLiteral(pathExpr)
+	if !ok {
+		return RouteInfo{}, "", false
+	}
+
+	handler := handlerName(bindings["handler"])
+	if handler == "" {
+		return RouteInfo{}, "", false
+	}
+
+	method := ""
+	if methodExpr, ok := bindings["method"]; ok {
+		method = methodValue(methodExpr)
+	} else if m, ok := httpMethods[strings.ToLower(callName(pattern))]; ok {
+		method = m
+	}
+
+	// net/http (Go 1.22+) patterns carry the method in the path: "GET /users/{id}"
+	if parts := strings.Fields(path); len(parts) == 2 {
+		if m, ok := httpMethods[strings.ToLower(parts[0])]; ok {
+			method, path = m, parts[1]
+		}
+	}
+
+	if method == "" {
+		method = "GET"
+	}
+
+	return RouteInfo{Method: method, Path: routeParamRegex.ReplaceAllString(path, ":$1")}, handler, true
+}
+
+func callName(call *ast.CallExpr) string {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	}
+	return ""
+}
+
+// handlerName returns the function name of a handler argument such as GetUser, h.GetUser or http.HandlerFunc(GetUser)
+func handlerName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.CallExpr:
+		if len(e.Args) == 1 {
+			return handlerName(e.Args[0])
+		}
+	}
+	return ""
+}
+
+// methodValue returns the HTTP method for a method argument such as "GET" or http.MethodGet
+func methodValue(expr ast.Expr) string {
+	if s, ok := stringLiteral(expr); ok {
+		return strings.ToUpper(s)
+	}
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		if m, ok := httpMethods[strings.ToLower(strings.TrimPrefix(sel.Sel.Name, "Method"))]; ok {
+			return m
+		}
+	}
+	return ""
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return s, true
+}
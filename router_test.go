@@ -0,0 +1,143 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseRouterFile(t *testing.T) {
+	tmpdir := createTempFolder(t.Name())
+	defer func() {
+		if !t.Failed() {
+			_ = os.RemoveAll(tmpdir)
+		} else {
+			t.Logf("Test failed. Temporary directory retained at: %s", tmpdir)
+		}
+	}()
+
+	routerContent := `
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func NewRouter(h *Handlers) *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/users/{id:[0-9]+}", GetUser).Methods("GET")
+	r.HandleFunc("/users", h.CreateUser).Methods(http.MethodPost)
+	r.HandleFunc("/users/{id}/posts/{postID}", ListPosts)
+	r.Handle("/health", http.HandlerFunc(Health)).Methods("HEAD")
+	return r
+}
+`
+	routerFile := filepath.Join(tmpdir, "router.go")
+	if err := os.WriteFile(routerFile, []byte(routerContent), 0644); err != nil {
+		t.Fatalf("Failed to write router file: %v", err)
+	}
+
+	routes, err := parseRouterFile(routerFile, nil)
+	if err != nil {
+		t.Fatalf("Failed to parse router file: %v", err)
+	}
+
+	expectedRoutes := map[string]RouteInfo{
+		"GetUser":    {Method: "GET", Path: "/users/:id"},
+		"CreateUser": {Method: "POST", Path: "/users"},
+		"ListPosts":  {Method: "GET", Path: "/users/:id/posts/:postID"},
+		"Health":     {Method: "HEAD", Path: "/health"},
+	}
+	if !reflect.DeepEqual(routes, expectedRoutes) {
+		t.Errorf("Parsed routes do not match expected.\nGot: %+v\nWant: %+v", routes, expectedRoutes)
+	}
+
+	// Custom patterns, e.g. chi-style registration where the call name is the method
+	chiContent := `
+package api
+
+func Routes(r chi.Router) {
+	r.Get("/users/{id}", GetUser)
+	r.Delete("/users/{id}", DeleteUser)
+}
+`
+	chiFile := filepath.Join(tmpdir, "chi.go")
+	if err := os.WriteFile(chiFile, []byte(chiContent), 0644); err != nil {
+		t.Fatalf("Failed to write router file: %v", err)
+	}
+
+	routes, err = parseRouterFile(chiFile, []string{"Get(path, handler)", "Delete(path, handler)"})
+	if err != nil {
+		t.Fatalf("Failed to parse router file: %v", err)
+	}
+	expectedRoutes = map[string]RouteInfo{
+		"GetUser":    {Method: "GET", Path: "/users/:id"},
+		"DeleteUser": {Method: "DELETE", Path: "/users/:id"},
+	}
+	if !reflect.DeepEqual(routes, expectedRoutes) {
+		t.Errorf("Parsed chi routes do not match expected.\nGot: %+v\nWant: %+v", routes, expectedRoutes)
+	}
+}
+
+func TestParseHandlerCommentsWithRoutes(t *testing.T) {
+	src := `
+package api
+
+// @Input GetUserInput
+// @Output User
+func GetUser() {}
+
+// @Method PUT
+// @Path /accounts/:accountID
+// @Output Account
+func UpdateAccount() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "handlers.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	routes := map[string]RouteInfo{
+		"GetUser":       {Method: "GET", Path: "/users/:id"},
+		"UpdateAccount": {Method: "POST", Path: "/ignored"},
+	}
+
+	var handlers []HandlerInfo
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			if handler := parseHandlerComments(fn, routes, io.Discard); handler != nil {
+				handlers = append(handlers, *handler)
+			}
+		}
+	}
+
+	expectedHandlers := []HandlerInfo{
+		{
+			Name:       "GetUser",
+			Method:     "GET",
+			Path:       "/users/:id",
+			InputType:  "GetUserInput",
+			OutputType: "User",
+			URLParams:  []string{"id"},
+		},
+		{
+			// Comment directives take precedence over the router file
+			Name:       "UpdateAccount",
+			Method:     "PUT",
+			Path:       "/accounts/:accountID",
+			OutputType: "Account",
+			URLParams:  []string{"accountID"},
+		},
+	}
+	if !reflect.DeepEqual(handlers, expectedHandlers) {
+		t.Errorf("Parsed handlers do not match expected.\nGot: %+v\nWant: %+v", handlers, expectedHandlers)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// generatedFileServer serves the generated files listed in the configuration over HTTP for dev
+// frontends. Files are read on each request, so they reflect the latest generation; mu is held
+// for writing while regenerating so a file isn't served half-written.
+type generatedFileServer struct {
+	mu sync.RWMutex
+	// configFile is the path of the configuration file, go2type.yaml when empty
+	configFile string
+}
+
+func (s *generatedFileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// The dev frontend usually runs on another origin and must always get the latest content
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-store")
+
+	files := servedFiles(configFileOrDefault(s.configFile))
+	if r.URL.Path == "/" {
+		var urlPaths []string
+		for urlPath := range files {
+			urlPaths = append(urlPaths, urlPath)
+		}
+		sort.Strings(urlPaths)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, urlPath := range urlPaths {
+			fmt.Fprintln(w, urlPath)
+		}
+		return
+	}
+
+	file, ok := files[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/typescript; charset=utf-8")
+	_, _ = w.Write(content)
+}
+
+// servedFiles returns the output files of configFile keyed by their URL path, which is the output
+// path with any leading ../ removed, e.g. /frontend/src/api.generated.ts. The files a split output
+// is written to and the MSW handlers are served along with it, as listed by outputFiles. Only these
+// files are served.
+func servedFiles(configFile string) map[string]string {
+	files := make(map[string]string)
+	config, err := loadConfig(configFile, os.Stdout)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return files
+	}
+
+	var outputs []string
+	if config.Bundle {
+		outputs = append(outputs, config.OutputPath)
+	} else {
+		for _, group := range groupPackagesByOutput(config.Packages) {
+			if group[0].OutputPath != "" {
+				outputs = append(outputs, groupOutputFiles(config, group, io.Discard)...)
+			}
+		}
+	}
+	for _, output := range outputs {
+		if output == "" {
+			continue
+		}
+		files[path.Clean("/"+filepath.ToSlash(output))] = output
+	}
+	return files
+}
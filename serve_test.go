@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestGeneratedFileServer(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go2type.yaml": `packages:
+  - path: users
+    output_path: out/users.generated.ts
+  - path: orders
+    output_path: ../frontend/orders.generated.ts
+  - path: products
+    output_path: products/index.ts
+    split: types-and-client
+`,
+		"products/types.generated.ts":  "export type Product = { id: number };\n",
+		"products/client.generated.ts": "export const getProduct = () => {};\n",
+		"out/users.generated.ts":       "export type User = { id: number };\n",
+		"secret.txt":                   "not generated",
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	server := httptest.NewServer(&generatedFileServer{})
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	if status, body := get("/out/users.generated.ts"); status != http.StatusOK || body != "export type User = { id: number };\n" {
+		t.Errorf("Expected the generated file, got %d %q", status, body)
+	}
+
+	// Regenerated content is served without restarting the server
+	writeTestFiles(t, dir, map[string]string{"out/users.generated.ts": "export type User = { id: number; name: string };\n"})
+	if _, body := get("/out/users.generated.ts"); body != "export type User = { id: number; name: string };\n" {
+		t.Errorf("Expected the regenerated file, got %q", body)
+	}
+
+	if status, body := get("/products/types.generated.ts"); status != http.StatusOK || body != "export type Product = { id: number };\n" {
+		t.Errorf("Expected the split file to be served, got %d %q", status, body)
+	}
+
+	if _, body := get("/"); body != "/frontend/orders.generated.ts\n/out/users.generated.ts\n/products/client.generated.ts\n/products/index.ts\n/products/types.generated.ts\n" {
+		t.Errorf("Expected the generated files to be listed, got %q", body)
+	}
+	for _, path := range []string{"/secret.txt", "/go2type.yaml", "/frontend/orders.generated.ts"} {
+		if status, _ := get(path); status != http.StatusNotFound {
+			t.Errorf("Expected %s not to be served, got %d", path, status)
+		}
+	}
+}
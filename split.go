@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// typesFileName is the file the types are written to when split is types-and-client
+	typesFileName = "types.generated.ts"
+	// clientFileName is the file the request helpers, query functions and hooks are written to when
+	// the output is split
+	clientFileName = "client.generated.ts"
+	// brandFileName is the file the Brand utility type is written to when split is per-type
+	brandFileName = "brand.generated.ts"
+)
+
+// validSplit returns split if it's a known way of splitting the output, and "" for a single file
+// otherwise, warning on w about unknown ones
+func validSplit(split string, w io.Writer) string {
+	if split == "per-type" || split == "types-and-client" {
+		return split
+	} else if split != "" {
+		fmt.Fprintf(w, "Warning: Unknown split %s. Generating a single file instead.\n", split)
+	}
+	return ""
+}
+
+// groupSplit returns how the output of a group is split, which is set by the first package that
+// sets split
+func groupSplit(group []PackageConfig, w io.Writer) string {
+	for _, pkg := range group {
+		if pkg.Split != "" {
+			return validSplit(pkg.Split, w)
+		}
+	}
+	return ""
+}
+
+// splitFile is one of the files a split output is written to
+type splitFile struct {
+	Path  string
+	Types []TypeInfo
+	// Client is set for the file of the request helpers, query functions and hooks
+	Client bool
+	// Brand is set for the file that declares the Brand utility type
+	Brand bool
+}
+
+// splitFiles returns the files the output of opts is split into, which are written next to
+// opts.OutputFile. The types come first, so the client can import from them.
+func splitFiles(opts GenerateFileOptions) []splitFile {
+	dir := filepath.Dir(opts.OutputFile)
+	var files []splitFile
+	if opts.Split == "per-type" {
+		if opts.BrandIDs {
+			files = append(files, splitFile{Path: filepath.Join(dir, brandFileName), Brand: true})
+		}
+		for _, t := range opts.Types {
+			name := strings.Split(t.Name, " ")[0]
+			files = append(files, splitFile{Path: filepath.Join(dir, name+".generated.ts"), Types: []TypeInfo{t}})
+		}
+	} else {
+		files = append(files, splitFile{Path: filepath.Join(dir, typesFileName), Types: opts.Types, Brand: opts.BrandIDs})
+	}
+	return append(files, splitFile{Path: filepath.Join(dir, clientFileName), Client: true})
+}
+
+// outputFiles returns the files generateFile writes for opts: the output file, the files it's split
+// into when the output is split, and the MSW handlers with emit_mocks
+func outputFiles(opts GenerateFileOptions) []string {
+	paths := []string{opts.OutputFile}
+	if opts.Split != "" && len(opts.Namespaces) == 0 {
+		for _, f := range splitFiles(opts) {
+			paths = append(paths, f.Path)
+		}
+	}
+	if mockPath := mockFilePath(opts); mockPath != "" {
+		paths = append(paths, mockPath)
+	}
+	return paths
+}
+
+// groupOutputFiles returns the files generated for a group of packages sharing an output path, as
+// listed by outputFiles. The packages are parsed when the output is split per type, as its files are
+// named after the types, warning on w about those that can't be.
+func groupOutputFiles(config *Config, group []PackageConfig, w io.Writer) []string {
+	opts := GenerateFileOptions{
+		OutputFile: group[0].OutputPath,
+		Split:      groupSplit(group, w),
+		BrandIDs:   config.usesBrand(),
+		EmitMocks:  config.EmitMocks,
+	}
+	if opts.Split == "per-type" {
+		for _, pkg := range group {
+			types, _, err := parseGeneratedPackage(config, pkg, io.Discard)
+			if err != nil {
+				fmt.Fprintf(w, "Warning: Could not find the per-type files of package %s: %v\n", pkg.Path, err)
+				continue
+			}
+			merged, err := mergeTypes(opts.Types, types, opts.OutputFile)
+			if err != nil {
+				fmt.Fprintf(w, "Warning: Could not find the per-type files of package %s: %v\n", pkg.Path, err)
+				continue
+			}
+			opts.Types = merged
+		}
+		affixTypeNames(opts.Types, nil, config.TypePrefix, config.TypeSuffix)
+	}
+	return outputFiles(opts)
+}
+
+// tsExportRegex matches the name of a top-level export, and whether it's only a type
+var tsExportRegex = regexp.MustCompile(`(?m)^export (?:(type|interface)|const|function|class|enum) ([A-Za-z_$][\w$]*)`)
+
+// tsCommentsRegex matches line and block comments, which don't count as references to imports
+var tsCommentsRegex = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+
+// generateSplitFiles writes the types and the client of opts to their own files, importing what
+// each file references from the others, and writes opts.OutputFile as an index re-exporting them so
+// existing imports of the output keep working
+func generateSplitFiles(opts GenerateFileOptions) (bool, error) {
+	tmpl, data := newFileTemplate(opts)
+	headerPiece, typesPiece, clientPiece, handlerPieces := filePieces(opts, data)
+	files := splitFiles(opts)
+	if err := checkSplitFiles(opts, files); err != nil {
+		return false, err
+	}
+
+	contents := make([]string, len(files))
+	for i, f := range files {
+		var buf bytes.Buffer
+		fileData := data
+		pieces := []TemplatePiece{headerPiece}
+		if f.Client {
+			// The Brand type is only referenced by the types
+			fileData.BrandIDs = false
+			pieces = append(append(pieces, clientPiece), handlerPieces...)
+			pieces = append(pieces, TemplatePiece{Name: "defaultExportTemplate", Tmpl: defaultExportTemplate, Render: opts.DefaultExport})
+		} else {
+			fileData.TypesOnly = true
+			fileData.BrandIDs = f.Brand
+			fileData.Types = f.Types
+			pieces = append(pieces, typesPiece)
+		}
+		if err := executeTemplatePieces(&buf, tmpl, pieces, fileData); err != nil {
+			return false, err
+		}
+		contents[i] = buf.String()
+	}
+
+	// Each file imports the exports of the type files that it references
+	exports := make(map[string]int)
+	typeOnly := make(map[string]bool)
+	for i, f := range files {
+		if f.Client {
+			continue
+		}
+		for _, m := range tsExportRegex.FindAllStringSubmatch(contents[i], -1) {
+			exports[m[2]] = i
+			typeOnly[m[2]] = m[1] != ""
+		}
+	}
+	var written bool
+	for i, f := range files {
+		imports := splitImports(contents[i], i, files, exports, typeOnly)
+		if imports != "" {
+			contents[i] = insertAfterHeader(contents[i], imports)
+		}
+		fileWritten, err := writeGeneratedFile(opts, f.Path, contents[i])
+		if err != nil {
+			return false, err
+		}
+		written = written || fileWritten
+	}
+
+	var index strings.Builder
+	fmt.Fprintf(&index, "// This file is auto-generated. DO NOT EDIT.\n// Generated by go2type %s on %s\n\n", data.Version, data.Timestamp)
+	for _, f := range files {
+		fmt.Fprintf(&index, "export * from '%s';\n", importPath(f.Path))
+	}
+	if opts.DefaultExport && (len(opts.Handlers) > 0 || len(opts.Types) == 1) {
+		fmt.Fprintf(&index, "export { default } from '%s';\n", importPath(clientFileName))
+	}
+	indexWritten, err := writeGeneratedFile(opts, opts.OutputFile, index.String())
+	return written || indexWritten, err
+}
+
+// checkSplitFiles returns an error if the output file or two of the files it's split into have the
+// same name. Names are compared case-insensitively, as the files would overwrite each other on
+// case-insensitive file systems. A per-type file also mustn't take the name of the types file of
+// types-and-client, e.g. for a type named Types.
+func checkSplitFiles(opts GenerateFileOptions, files []splitFile) error {
+	names := make(map[string]string)
+	if opts.Split == "per-type" {
+		names[strings.ToLower(filepath.Join(filepath.Dir(opts.OutputFile), typesFileName))] = typesFileName
+	}
+	for _, f := range files {
+		key := strings.ToLower(filepath.Clean(f.Path))
+		if key == strings.ToLower(filepath.Clean(opts.OutputFile)) {
+			return fmt.Errorf("output path %s is also one of the files the output is split into", opts.OutputFile)
+		}
+		if other, ok := names[key]; ok {
+			return fmt.Errorf("split file %s has the same name as %s", filepath.Base(f.Path), other)
+		}
+		names[key] = filepath.Base(f.Path)
+	}
+	return nil
+}
+
+// splitImports returns the import statements of the content of files[self] for the exports of the
+// other files it references, or "" if it references none. Type-only exports are imported with
+// `import type`.
+func splitImports(content string, self int, files []splitFile, exports map[string]int, typeOnly map[string]bool) string {
+	typeNames := make(map[int][]string)
+	valueNames := make(map[int][]string)
+	seen := make(map[string]bool)
+	for _, name := range typeIdentifierRegex.FindAllString(tsCommentsRegex.ReplaceAllString(content, ""), -1) {
+		i, ok := exports[name]
+		if !ok || i == self || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if typeOnly[name] {
+			typeNames[i] = append(typeNames[i], name)
+		} else {
+			valueNames[i] = append(valueNames[i], name)
+		}
+	}
+
+	var imports strings.Builder
+	for i, f := range files {
+		if names := typeNames[i]; len(names) > 0 {
+			sort.Strings(names)
+			fmt.Fprintf(&imports, "import type { %s } from '%s';\n", strings.Join(names, ", "), importPath(f.Path))
+		}
+		if names := valueNames[i]; len(names) > 0 {
+			sort.Strings(names)
+			fmt.Fprintf(&imports, "import { %s } from '%s';\n", strings.Join(names, ", "), importPath(f.Path))
+		}
+	}
+	return imports.String()
+}
+
+// importPath returns the relative import path of a file next to the output, e.g. ./types.generated
+func importPath(filePath string) string {
+	return "./" + strings.TrimSuffix(filepath.Base(filePath), ".ts")
+}
+
+// insertAfterHeader inserts imports after the generated-by line of a rendered file
+func insertAfterHeader(content, imports string) string {
+	loc := generatedLineRegex.FindStringIndex(content)
+	if loc == nil {
+		return imports + content
+	}
+	return content[:loc[1]] + "\n" + imports + content[loc[1]:]
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// splitTestTypes are a user with an address and a role, for testing imports between split files
+var splitTestTypes = []TypeInfo{
+	{Name: "User", Fields: []FieldInfo{
+		{Name: "id", Type: "number", JSONName: "id"},
+		{Name: "address", Type: "Address", JSONName: "address"},
+		{Name: "role", Type: "Role", JSONName: "role"},
+	}},
+	{Name: "Address", Fields: []FieldInfo{{Name: "city", Type: "string", JSONName: "city"}}},
+	{Name: "Role", EnumValues: []string{"'admin'", "'member'"}},
+}
+
+var splitTestHandlers = []HandlerInfo{
+	{Name: "GetUser", Method: "GET", Path: "/users/:id", OutputType: "User", URLParams: []string{"id"}},
+}
+
+// readSplitFile returns the content of a file written next to the output
+func readSplitFile(t *testing.T, outputFile, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(filepath.Dir(outputFile), name))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(content)
+}
+
+func TestSplitTypesAndClient(t *testing.T) {
+	outputFile := filepath.Join(createTempFolder(t.Name()), "api.generated.ts")
+	index := renderTestFile(t, GenerateFileOptions{
+		Types:             splitTestTypes,
+		Handlers:          splitTestHandlers,
+		OutputFile:        outputFile,
+		EmitGuards:        true,
+		ValidateResponses: true,
+		Split:             "types-and-client",
+	})
+
+	for _, expected := range []string{
+		"export * from './types.generated';\n",
+		"export * from './client.generated';\n",
+	} {
+		if !strings.Contains(index, expected) {
+			t.Errorf("Expected the output to re-export the split files with %q, got:\n%s", expected, index)
+		}
+	}
+	if strings.Contains(index, "export type User") || strings.Contains(index, "APIError") {
+		t.Errorf("Expected the output to declare nothing itself, got:\n%s", index)
+	}
+
+	types := readSplitFile(t, outputFile, typesFileName)
+	for _, expected := range []string{"export type User = {", "export type Address = {", "export const isUser ="} {
+		if !strings.Contains(types, expected) {
+			t.Errorf("Expected the types file to contain %q, got:\n%s", expected, types)
+		}
+	}
+	for _, unexpected := range []string{"import ", "APIError", "fetch("} {
+		if strings.Contains(types, unexpected) {
+			t.Errorf("Expected the types file not to contain %q, got:\n%s", unexpected, types)
+		}
+	}
+
+	client := readSplitFile(t, outputFile, clientFileName)
+	for _, expected := range []string{
+		"import type { User } from './types.generated';\n",
+		"import { isUser } from './types.generated';\n",
+		"export class APIError",
+		"export const GetUser",
+	} {
+		if !strings.Contains(client, expected) {
+			t.Errorf("Expected the client file to contain %q, got:\n%s", expected, client)
+		}
+	}
+	if strings.Contains(client, "export type User") {
+		t.Errorf("Expected the client file not to declare the types, got:\n%s", client)
+	}
+}
+
+func TestSplitPerType(t *testing.T) {
+	outputFile := filepath.Join(createTempFolder(t.Name()), "api.generated.ts")
+	index := renderTestFile(t, GenerateFileOptions{
+		Types:      splitTestTypes,
+		Handlers:   splitTestHandlers,
+		OutputFile: outputFile,
+		EmitGuards: true,
+		BrandIDs:   true,
+		Split:      "per-type",
+	})
+
+	expectedIndex := "export * from './brand.generated';\n" +
+		"export * from './User.generated';\n" +
+		"export * from './Address.generated';\n" +
+		"export * from './Role.generated';\n" +
+		"export * from './client.generated';\n"
+	if !strings.Contains(index, expectedIndex) {
+		t.Errorf("Expected the output to re-export every file, got:\n%s", index)
+	}
+
+	user := readSplitFile(t, outputFile, "User.generated.ts")
+	for _, expected := range []string{
+		"import type { Address } from './Address.generated';\n",
+		"import { isAddress } from './Address.generated';\n",
+		"import type { Role } from './Role.generated';\n",
+		"import { isRole } from './Role.generated';\n",
+		"export type User = {",
+	} {
+		if !strings.Contains(user, expected) {
+			t.Errorf("Expected User.generated.ts to contain %q, got:\n%s", expected, user)
+		}
+	}
+	if strings.Contains(user, "export type Address") || strings.Contains(user, "from './User.generated'") {
+		t.Errorf("Expected User.generated.ts to declare only User, got:\n%s", user)
+	}
+
+	address := readSplitFile(t, outputFile, "Address.generated.ts")
+	if strings.Contains(address, "import ") {
+		t.Errorf("Expected Address.generated.ts to import nothing, got:\n%s", address)
+	}
+	if brand := readSplitFile(t, outputFile, brandFileName); !strings.Contains(brand, "export type Brand<T, K extends string>") {
+		t.Errorf("Expected the Brand type in its own file, got:\n%s", brand)
+	}
+
+	client := readSplitFile(t, outputFile, clientFileName)
+	if !strings.Contains(client, "import type { User } from './User.generated';\n") {
+		t.Errorf("Expected the client to import User, got:\n%s", client)
+	}
+	if strings.Contains(client, "Brand<") || strings.Contains(client, "from './Address.generated'") {
+		t.Errorf("Expected the client to import only what it references, got:\n%s", client)
+	}
+}
+
+func TestSplitDryRun(t *testing.T) {
+	opts := GenerateFileOptions{
+		Types:            splitTestTypes,
+		Handlers:         splitTestHandlers,
+		OutputFile:       filepath.Join(createTempFolder(t.Name()), "api.generated.ts"),
+		AuthTokenStorage: "localStorage",
+		Split:            "types-and-client",
+	}
+	diff, err := previewFile(opts)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	for _, name := range []string{"api.generated.ts", typesFileName, clientFileName} {
+		if !strings.Contains(diff, "+++ b/"+filepath.ToSlash(filepath.Join(filepath.Dir(opts.OutputFile), name))) {
+			t.Errorf("Expected the diff to cover %s, got:\n%s", name, diff)
+		}
+	}
+
+	if _, err := generateFile(opts); err != nil {
+		t.Fatalf("Failed to generate file: %v", err)
+	}
+	if diff, err := previewFile(opts); err != nil || diff != "" {
+		t.Errorf("Expected no changes after generating, got %v:\n%s", err, diff)
+	}
+}
+
+func TestSplitOutputPathConflict(t *testing.T) {
+	opts := GenerateFileOptions{
+		Types:      splitTestTypes,
+		OutputFile: filepath.Join(createTempFolder(t.Name()), clientFileName),
+		Split:      "types-and-client",
+	}
+	if _, err := generateFile(opts); err == nil {
+		t.Errorf("Expected an error when the output path is one of the split files")
+	}
+}
+
+func TestSplitPerTypeNameCollision(t *testing.T) {
+	for _, types := range [][]TypeInfo{
+		{{Name: "User"}, {Name: "USER"}},
+		{{Name: "Client"}},
+		{{Name: "types"}},
+	} {
+		opts := GenerateFileOptions{
+			Types:      types,
+			OutputFile: filepath.Join(createTempFolder(t.Name()), "api.generated.ts"),
+			Split:      "per-type",
+		}
+		_, err := generateFile(opts)
+		if err == nil || !strings.Contains(err.Error(), "has the same name as") {
+			t.Errorf("Expected an error for the per-type files of %+v, got %v", types, err)
+		}
+	}
+}
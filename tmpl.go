@@ -2,98 +2,405 @@ package main
 
 // TemplateData is the data passed to the template for generating the TypeScript file
 type TemplateData struct {
-	Version          string
-	Timestamp        string
-	Types            []TypeInfo
+	Version   string
+	Timestamp string
+	Types     []TypeInfo
+	// AllTypes are the types type guards check nested types against. They're the file's types,
+	// or the types of every file when types are split into their own files.
+	AllTypes         []TypeInfo
 	Handlers         []HandlerInfo
 	AuthToken        string
 	AuthTokenStorage string
-	UseHooks         bool
-	UseReactQuery    bool
-	UseDateObject    bool
+	// HookStyle is the kind of hooks rendered: "react-query", "swr", "react" for plain React hooks,
+	// or empty for none
+	HookStyle      string
+	UseDateObject  bool
+	ZeroTimeAsNull bool
+	DedupeRequests bool
+	APIConfig      bool
+	StorageKeys    []storageKeyConst
+	UseAxios       bool
+	UseAngular     bool
+	BrandIDs       bool
+	// HeaderFunctions are the functions fn: headers get their values from, set on apiConfig or,
+	// without api_config, on headerConfig
+	HeaderFunctions []string
+	// EmitGuards renders a type guard for each type, which ValidateResponses checks responses with
+	EmitGuards        bool
+	ValidateResponses bool
+	// RenamedTypes are the types whose values have fields renamed in the generated types, such as by
+	// a ts tag or boolean_prefix, or hold such types
+	RenamedTypes []renamedType
+	// Namespace is the namespace of a bundle the types and handlers are rendered in
+	Namespace string
+	// UseBuilder renders a RequestBuilder for each handler, configured by method chaining
+	UseBuilder bool
+	// BaseURLEnv is the environment variable the base URL of request paths is read from at runtime
+	BaseURLEnv string
+	// UseInterfaces declares object types with the interface keyword. Other types, such as unions,
+	// are always declared with type.
+	UseInterfaces bool
+	// EnumStyle is how enums are declared: "union", "const-array" or "enum"
+	EnumStyle string
+	// MaxResponseBytes is the size responses are rejected over, or 0 for no limit
+	MaxResponseBytes int64
+	// QueryParams is set when a handler has @Query parameters, which are serialized by
+	// queryParamEntries
+	QueryParams bool
+	// ArrayQueryFormat is how arrays are sent as query parameters: "" for repeated keys, "bracket"
+	// or "comma"
+	ArrayQueryFormat string
+	// EmitPaths renders the Paths object of request path builders, whose query params are serialized
+	// by queryParamEntries with any HTTP client
+	EmitPaths bool
+	// ErrorOutputs is set when a handler has an output returned with an error status
+	ErrorOutputs bool
+	// Envelopes is set when a handler unwraps its output from a response envelope
+	Envelopes bool
+	// TypesOnly leaves the imports and request helpers out of the header, for a file of split types
+	TypesOnly bool
 }
 
 const headerTemplate = `// This file is auto-generated. DO NOT EDIT.
 // Generated by go2type {{.Version}} on {{.Timestamp}}
 {{$useDateObject := .UseDateObject}}
-{{if .UseReactQuery}}
-import { useQuery, useMutation, UseQueryOptions, UseMutationOptions, UseMutationResult, UseQueryResult } from '@tanstack/react-query'
-{{else if .UseHooks}}
-import { useState, useEffect, useCallback } from 'react'
-{{end}}
+{{if not .TypesOnly}}{{if .UseAxios}}
+import axios from 'axios';
+import type { AxiosInstance, AxiosResponse } from 'axios';
+{{end}}{{if .UseAngular}}
+import { Injectable } from '@angular/core';
+import { HttpClient, {{if .ErrorOutputs}}HttpErrorResponse, {{end}}HttpParams } from '@angular/common/http';
+import { Observable{{if .Envelopes}}, map{{end}}{{if .ErrorOutputs}}, catchError, of, throwError{{end}} } from 'rxjs';
+{{end}}{{if eq .HookStyle "react-query"}}
+import { useQuery, useQueries, useMutation } from '@tanstack/react-query';
+import type { UseQueryOptions, UseMutationOptions, UseMutationResult, UseQueryResult } from '@tanstack/react-query';
+{{else if eq .HookStyle "swr"}}
+import useSWR from 'swr';
+import type { SWRConfiguration, SWRResponse } from 'swr';
+import useSWRMutation from 'swr/mutation';
+import type { SWRMutationConfiguration, SWRMutationResponse } from 'swr/mutation';
+{{else if eq .HookStyle "react"}}
+import { useState, useEffect, useCallback } from 'react';
+{{end}}{{end}}
 
-{{if $useDateObject}}// Utility function to parse dates
+{{if .BrandIDs}}// Brand makes otherwise identical types, such as the IDs of different types, incompatible
+export type Brand<T, K extends string> = T & { readonly __brand: K };
+{{end}}{{if not .TypesOnly}}{{if $useDateObject}}// Utility function to parse dates
 const parseDate = (dateString: string): Date => new Date(dateString);
+{{end}}{{if .ZeroTimeAsNull}}// Revives dates in responses, converting Go's zero time.Time to null
+const reviveDate = (dateString: string): {{if $useDateObject}}Date{{else}}string{{end}} | null =>
+  dateString.startsWith('0001-01-01T00:00:00') ? null : {{if $useDateObject}}parseDate(dateString){{else}}dateString{{end}};
 {{end}}
-// Custom error class for API errors
+// Error thrown by failed requests, carrying the response's status and parsed body. It's a real
+// Error subclass, so it can be checked with instanceof and caught by React error boundaries.
 export class APIError extends Error {
-  constructor(public status: number, public statusText: string, public data: Record<string, unknown> | string) {    
+  status: number;
+  statusText: string;
+  body: Record<string, unknown> | string;
+
+  constructor(status: number, statusText: string, body: Record<string, unknown> | string) {
     super(` + "`API Error ${status}: ${statusText}`" + `);
     this.name = 'APIError';
+    this.status = status;
+    this.statusText = statusText;
+    this.body = body;
+    // Keep instanceof working when compiled to ES5, where subclasses of Error lose their prototype
+    Object.setPrototypeOf(this, new.target.prototype);
+  }
+
+  /** @deprecated Use body */
+  get data(): Record<string, unknown> | string {
+    return this.body;
   }
 }
-`
+{{end}}`
 
 // Update the template to use the new IsOptional field
-const typesTemplate = `{{range .Types}}export type {{firstWord .Name}} = { {{range .Fields}}
-  {{.Name}}{{if .IsOptional}}?{{end}}: {{.Type}};{{end}}
+const typesTemplate = `{{range .Types}}{{if .Derived}}export type {{firstWord .Name}} = {{.Derived}};
+{{if .BrandBase}}export function {{firstWord .Name}}(value: {{.BrandBase}}): {{firstWord .Name}} {
+  return value as {{firstWord .Name}};
 }
-{{end}}
+{{end}}{{else if .EnumValues}}{{enum . $.EnumStyle}}{{enumMeta .}}{{else if .Extends}}export interface {{firstWord .Name}} extends {{join .Extends ", "}} {}
+{{else if .TSType}}export type {{firstWord .Name}} = {{.TSType}};
+{{else if .Union}}export type {{firstWord .Name}} ={{unionType .}};
+{{else}}export {{if $.UseInterfaces}}interface{{else}}type{{end}} {{firstWord .Name}}{{if .TypeParams}}<{{join .TypeParams ", "}}>{{end}} {{if not $.UseInterfaces}}= {{end}}{ {{range .Fields}}
+  {{jsDoc .Doc "  "}}{{.Name}}{{if .IsOptional}}?{{end}}: {{.Type}};{{end}}
+}{{if not $.UseInterfaces}};{{end}}
+{{validation .}}{{defaults .}}{{pagination .}}{{end}}{{if $.EmitGuards}}{{guard . $.AllTypes}}{{end}}{{end}}
 `
 
-const queryFunctionTemplate = `{{$authToken := .AuthToken}}
+// queryClientTemplate renders the request helpers shared by every query function
+const queryClientTemplate = `{{$authToken := .AuthToken}}
 {{$authTokenStorage := .AuthTokenStorage}}
 {{$useDateObject := .UseDateObject}}
+{{$zeroTimeAsNull := .ZeroTimeAsNull}}
+{{$dedupeRequests := .DedupeRequests}}
+{{$apiConfig := .APIConfig}}
+{{$useAxios := .UseAxios}}
+{{$useBuilder := .UseBuilder}}
+{{$responseType := "Response"}}{{if $useAxios}}{{$responseType = "AxiosResponse"}}{{end}}
+{{if .StorageKeys}}
+// Storage keys read by the generated client
+{{range .StorageKeys}}export const {{.Name}} = '{{js .Key}}';
+{{end}}{{end}}{{if .RenamedTypes}}
+// How the fields of a value are renamed: by the name of its type in renamedTypes, or for each item
+// of an array or value of a map
+type FieldRenames = string | { items: FieldRenames } | { values: FieldRenames };
+
+// A renamed field as [the name it's sent with, its name in the generated types], followed by how
+// the fields of its value are renamed when it holds a renamed type
+type RenamedField = [wire: string, name: string, renames?: FieldRenames];
+
+// Types with fields named differently in the generated types than on the wire, or holding such types
+const renamedTypes: Record<string, RenamedField[]> = {
+{{range .RenamedTypes}}  '{{js .Name}}': [{{range .Fields}}
+    ['{{js .Wire}}', '{{js .Name}}'{{with .Renames}}, {{.}}{{end}}],{{end}}
+  ],
+{{end}}};
+
+// Renames the fields of value, described by renames, from the names they're sent with to their
+// names in the generated types, or back to the names they're sent with when toWire is set
+function renameFields<T>(value: T, renames: FieldRenames, toWire = false): T {
+  if (typeof value !== 'object' || value === null) {
+    return value;
+  }
+  if (typeof renames !== 'string') {
+    if ('items' in renames) {
+      const items = renames.items;
+      return (Array.isArray(value) ? value.map((item) => renameFields(item, items, toWire)) : value) as T;
+    }
+    const values = renames.values;
+    if (Object.getPrototypeOf(value) !== Object.prototype) {
+      return value;
+    }
+    return Object.fromEntries(
+      Object.entries(value).map(([key, item]) => [key, renameFields(item, values, toWire)])
+    ) as T;
+  }
+  if (Object.getPrototypeOf(value) !== Object.prototype) {
+    return value;
+  }
+  const fields = new Map(
+    (renamedTypes[renames] ?? []).map((field): [string, RenamedField] => [toWire ? field[1] : field[0], field])
+  );
+  return Object.fromEntries(
+    Object.entries(value).map(([key, item]) => {
+      const field = fields.get(key);
+      if (!field) {
+        return [key, item];
+      }
+      const [wire, name, fieldRenames] = field;
+      return [toWire ? wire : name, fieldRenames ? renameFields(item, fieldRenames, toWire) : item];
+    })
+  ) as T;
+}
+{{end}}{{if .BaseURLEnv}}
+// Prefix of every request path, read from the environment at runtime
+const baseURL = (process.env.{{.BaseURLEnv}} ?? '').replace(/\/+$/, '');
+{{end}}{{if $apiConfig}}
+// Runtime configuration for the generated client. Configure it once at app startup, e.g.
+// apiConfig.baseUrl = 'https://api.example.com';
+export interface APIConfig {
+  baseUrl: string;
+  getToken: () => string | null | undefined;
+  defaultHeaders: Record<string, string>;
+  onError?: (error: APIError) => void;{{range .HeaderFunctions}}
+  {{.}}?: () => string | Promise<string>;{{end}}
+}
+
+export const apiConfig: APIConfig = {
+  baseUrl: '',
+  getToken: () => {{$authTokenStorage}}.getItem({{storageKey $authToken}}),
+  defaultHeaders: {},
+};
+{{else if .HeaderFunctions}}
+// Functions computing the values of @Header fn: headers, called before each request that sends
+// them. Set them once at app startup, e.g. headerConfig.{{index .HeaderFunctions 0}} = () => ...;
+export interface HeaderConfig {
+{{range .HeaderFunctions}}  {{.}}?: () => string | Promise<string>;
+{{end}}}
+
+export const headerConfig: HeaderConfig = {};
+{{end}}{{if and .QueryParams (or (not $useAxios) .EmitPaths)}}
+// Returns the query string entries of a parameter. {{if eq .ArrayQueryFormat "bracket"}}Arrays are sent as a key with
+// brackets per item, e.g. ids[]=1&ids[]=2.{{else if eq .ArrayQueryFormat "comma"}}Arrays are sent as one comma-separated
+// value, e.g. ids=1,2.{{else}}Arrays are sent as a key per item, e.g. ids=1&ids=2.{{end}}
+function queryParamEntries(key: string, value: unknown): Array<[string, string]> {
+  if (!Array.isArray(value)) {
+    return [[key, String(value)]];
+  }
+  {{if eq .ArrayQueryFormat "bracket"}}return value.map((item): [string, string] => [key + '[]', String(item)]);{{else if eq .ArrayQueryFormat "comma"}}return [[key, value.map(String).join(',')]];{{else}}return value.map((item): [string, string] => [key, String(item)]);{{end}}
+}
+{{end}}{{if $useAxios}}
+// Axios instance used for every request. Inject a configured instance, e.g. one with
+// interceptors, with setHTTPClient.
+let httpClient: AxiosInstance = axios.create();
+
+export const setHTTPClient = (instance: AxiosInstance): void => {
+  httpClient = instance;
+};
+
+// Generic query factory
+async function createQuery<TInput, TOutput>(
+  method: string,
+  url: string,
+  input?: TInput,
+  headers: Record<string, string> = {},
+  onResponse?: (response: AxiosResponse) => void,
+  params?: Record<string, unknown>{{if $useBuilder}},
+  signal?: AbortSignal{{end}}
+): Promise<TOutput> {
+  const token = {{if $apiConfig}}apiConfig.getToken(){{else}}{{$authTokenStorage}}.getItem({{storageKey $authToken}}){{end}};
+
+  if (token) {
+    headers['Authorization'] = ` + "`Bearer ${token}`" + `;
+  }
+
+  try {
+    // Axios serializes objects as JSON and sends other bodies, such as FormData, as-is
+    const response = await httpClient.request<TOutput>({
+      method,
+      {{if $apiConfig}}url: apiConfig.baseUrl + url{{else}}url{{end}},
+      params,{{if .QueryParams}}
+      // Arrays are sent as {{if eq .ArrayQueryFormat "bracket"}}a key with brackets per item, e.g. ids[]=1&ids[]=2{{else}}a key per item, e.g. ids=1&ids=2{{end}}
+      paramsSerializer: { indexes: {{if eq .ArrayQueryFormat "bracket"}}false{{else}}null{{end}} },{{end}}{{if .MaxResponseBytes}}
+      // Enforced while reading the response by axios's Node adapter. Browsers read it regardless.
+      maxContentLength: {{.MaxResponseBytes}},{{end}}
+      data: method !== 'GET' ? input : undefined,
+      {{if $apiConfig}}headers: { ...apiConfig.defaultHeaders, ...headers }{{else}}headers{{end}},{{if $useBuilder}}
+      signal,{{end}}
+    });
+    onResponse?.(response);
+    {{if or $useDateObject $zeroTimeAsNull}}
+    // Parse dates in the response
+    return JSON.parse(JSON.stringify(response.data), (_, value) =>
+      typeof value === 'string' && /^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}/.test(value) ? {{if $zeroTimeAsNull}}reviveDate(value){{else}}parseDate(value){{end}} : value
+    ) as TOutput;
+    {{else}}
+    return response.data;
+    {{end}}
+  } catch (error) {
+    let apiError: APIError;
+    if (axios.isAxiosError(error) && error.response) {
+      onResponse?.(error.response);
+      apiError = new APIError(error.response.status, error.response.statusText, error.response.data as Record<string, unknown> | string);
+    } else if (error instanceof Error) {
+      apiError = new APIError(0, 'Network Error', error.message);
+    } else {
+      apiError = new APIError(0, 'Unknown Error', String(error));
+    }
+    {{if $apiConfig}}apiConfig.onError?.(apiError);
+    {{end}}throw apiError;
+  }
+}
+{{else}}{{if .MaxResponseBytes}}
+// Responses are rejected once they're larger than this, so a misbehaving backend can't exhaust memory
+const maxResponseBytes = {{.MaxResponseBytes}};
 
+// Reads the body of a response as text, rejecting it when its Content-Length is over
+// maxResponseBytes, or when more bytes than that arrive, as the header may be missing or wrong
+async function readResponseText(response: Response): Promise<string> {
+  const tooLarge = (size: number) =>
+    new APIError(0, 'Response Too Large', ` + "`Response of ${size} bytes exceeds the limit of ${maxResponseBytes} bytes`" + `);
+  const contentLength = Number(response.headers.get('Content-Length'));
+  if (contentLength > maxResponseBytes) {
+    throw tooLarge(contentLength);
+  }
+  if (!response.body) {
+    return response.text();
+  }
+
+  const reader = response.body.getReader();
+  const decoder = new TextDecoder();
+  let text = '';
+  let received = 0;
+  for (;;) {
+    const { done, value } = await reader.read();
+    if (done) {
+      return text + decoder.decode();
+    }
+    received += value.byteLength;
+    if (received > maxResponseBytes) {
+      await reader.cancel();
+      throw tooLarge(received);
+    }
+    text += decoder.decode(value, { stream: true });
+  }
+}
+{{end}}
 // Generic query factory
 async function createQuery<TInput, TOutput>(
   method: string,
   url: string,
   input?: TInput,
-  headers: Record<string, string> = {}
+  headers: Record<string, string> = {},
+  onResponse?: (response: Response) => void{{if $useBuilder}},
+  signal?: AbortSignal{{end}}
 ): Promise<TOutput> {
-  const token = {{$authTokenStorage}}.getItem("{{$authToken}}");
-  const defaultHeaders: Record<string, string> = {
-    'Content-Type': 'application/json',
+  const token = {{if $apiConfig}}apiConfig.getToken(){{else}}{{$authTokenStorage}}.getItem({{storageKey $authToken}}){{end}};
+
+  // An explicit Content-Type header (e.g. from @Header input:Content-Type) takes precedence
+  // over the default JSON one, and non-JSON bodies are sent as-is
+  const contentTypeKey = Object.keys(headers).find((key) => key.toLowerCase() === 'content-type');
+  const contentType = contentTypeKey ? headers[contentTypeKey] : 'application/json';
+  const defaultHeaders: Record<string, string> = contentTypeKey ? {} : {
+    'Content-Type': contentType,
   };
 
   if (token) {
     headers['Authorization'] = ` + "`Bearer ${token}`" + `;
   }
 
-  const requestHeaders = { ...defaultHeaders, ...headers };
+  const requestHeaders = { ...defaultHeaders, {{if $apiConfig}}...apiConfig.defaultHeaders, {{end}}...headers };
   const requestOptions: RequestInit = {
     method,
-    headers: requestHeaders,
+    headers: requestHeaders,{{if $useBuilder}}
+    signal,{{end}}
   };
 
   if (method !== 'GET' && input) {
-    requestOptions.body = JSON.stringify(input);
+    requestOptions.body = /json/i.test(contentType) ? JSON.stringify(input) : (input as unknown as BodyInit);
   }
 
   try {
-    const response = await fetch(url, requestOptions);
+    const response = await fetch({{if $apiConfig}}apiConfig.baseUrl + url{{else}}url{{end}}, requestOptions);
+    onResponse?.(response);
 
     if (!response.ok) {
       let errorData;
+      {{if .MaxResponseBytes}}const errorText = await readResponseText(response);
       try {
+        errorData = JSON.parse(errorText);
+      } catch {
+        errorData = errorText;
+      }{{else}}try {
         errorData = await response.json();
       } catch {
         errorData = await response.text();
-      }
+      }{{end}}
       throw new APIError(response.status, response.statusText, errorData);
     }
 
-    const data = await response.json();
-    {{if $useDateObject}}
+    {{$responseJSON := "await response.json()"}}{{if .MaxResponseBytes}}{{$responseJSON = "JSON.parse(await readResponseText(response))"}}{{end}}
+    const data = {{$responseJSON}};
+    {{if or $useDateObject $zeroTimeAsNull}}
     // Parse dates in the response
     return JSON.parse(JSON.stringify(data), (_, value) =>
-      typeof value === 'string' && /^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}/.test(value) ? parseDate(value) : value
+      typeof value === 'string' && /^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}/.test(value) ? {{if $zeroTimeAsNull}}reviveDate(value){{else}}parseDate(value){{end}} : value
     ) as TOutput;
     {{else}}
     return data as TOutput;
     {{end}}
   } catch (error) {
+    {{if $apiConfig}}
+    const apiError = error instanceof APIError
+      ? error
+      : error instanceof Error
+        ? new APIError(0, 'Network Error', error.message)
+        : new APIError(0, 'Unknown Error', String(error));
+    apiConfig.onError?.(apiError);
+    throw apiError;
+    {{else}}
     if (error instanceof APIError) {
       throw error;
     } else if (error instanceof Error) {
@@ -101,27 +408,167 @@ async function createQuery<TInput, TOutput>(
     } else {
       throw new APIError(0, 'Unknown Error', String(error));
     }
+    {{end}}
+  }
+}
+{{end}}{{if $dedupeRequests}}
+// In-flight GET requests keyed by method, URL, body and headers, so identical concurrent requests share
+// one fetch
+const inFlightRequests = new Map<string, Promise<unknown>>();
+
+function dedupeQuery<TInput, TOutput>(
+  method: string,
+  url: string,
+  input?: TInput,
+  headers: Record<string, string> = {},
+  onResponse?: (response: {{$responseType}}) => void{{if $useAxios}},
+  params?: Record<string, unknown>{{end}}{{if $useBuilder}},
+  signal?: AbortSignal{{end}}
+): Promise<TOutput> {
+  {{if $useBuilder}}// A request with its own signal can be aborted, so it isn't shared
+  if (method !== 'GET' || signal) {
+    return createQuery<TInput, TOutput>(method, url, input, headers, onResponse{{if $useAxios}}, params{{end}}, signal);
+  }{{else}}if (method !== 'GET') {
+    return createQuery<TInput, TOutput>(method, url, input, headers, onResponse{{if $useAxios}}, params{{end}});
+  }{{end}}
+
+  const key = ` + "`${method} ${url} ${input === undefined ? '' : JSON.stringify(input)} ${JSON.stringify(Object.entries(headers).sort())}`" + `{{if $useAxios}} + (params ? ' ' + JSON.stringify(params) : ''){{end}};
+  const pending = inFlightRequests.get(key);
+  if (pending) {
+    return pending as Promise<TOutput>;
   }
+
+  const request = createQuery<TInput, TOutput>(method, url, input, headers, onResponse{{if $useAxios}}, params{{end}}).finally(() => {
+    inFlightRequests.delete(key);
+  });
+  inFlightRequests.set(key, request);
+  return request;
+}
+{{end}}{{if $useBuilder}}
+// Per-request options set with a RequestBuilder
+export interface RequestOptions {
+  headers?: Record<string, string>;
+  signal?: AbortSignal;
 }
 
+// Configures a single request by method chaining and sends it with execute(), e.g.
+// api.getUser(id).withHeader('X-Trace-ID', traceId).withSignal(controller.signal).execute()
+export class RequestBuilder<TOutput> {
+  private readonly headers: Record<string, string> = {};
+  private signal?: AbortSignal;
+  private responseCallback?: (response: {{$responseType}}) => void;
+
+  constructor(
+    private readonly send: (options: RequestOptions, onResponse?: (response: {{$responseType}}) => void) => Promise<TOutput>
+  ) {}
+
+  withHeader(name: string, value: string): this {
+    this.headers[name] = value;
+    return this;
+  }
+
+  withHeaders(headers: Record<string, string>): this {
+    Object.assign(this.headers, headers);
+    return this;
+  }
+
+  withSignal(signal: AbortSignal): this {
+    this.signal = signal;
+    return this;
+  }
+
+  onResponse(callback: (response: {{$responseType}}) => void): this {
+    this.responseCallback = callback;
+    return this;
+  }
+
+  execute(): Promise<TOutput> {
+    return this.send({ headers: { ...this.headers }, signal: this.signal }, this.responseCallback);
+  }
+}
+{{end}}
+`
+
+const queryFunctionTemplate = `{{$dedupeRequests := .DedupeRequests}}
+{{$apiConfig := .APIConfig}}
+{{$validateResponses := .ValidateResponses}}
+{{$useAxios := .UseAxios}}
+{{$useBuilder := .UseBuilder}}
+{{$responseType := "Response"}}{{if $useAxios}}{{$responseType = "AxiosResponse"}}{{end}}
 {{range .Handlers}}
-export const {{.Name}}Query = async ({{if .URLParams}}{{range $index, $param := .URLParams}}{{if $index}}, {{end}}{{$param}}: string{{end}}{{if or .InputType (inputHeaders .Headers)}}, {{end}}{{end}}{{if .InputType}}input: {{.InputType}}{{if inputHeaders .Headers}}, {{end}}{{end}}{{range $index, $header := inputHeaders .Headers}}{{if $index}}, {{end}}{{$header.SafeName}}: string{{end}}): Promise<{{.OutputType}}> => {
-  {{if .URLParams}}let{{else}}const{{end}} url = '{{.Path}}'
+{{handlerDoc .}}export const {{.Name}}Query = async ({{with queryArgs .}}{{paramList .}}, {{end}}onResponse?: (response: {{$responseType}}) => void{{if $useBuilder}}, options?: RequestOptions{{end}}): Promise<{{.OutputType}}> => {
+  {{$hasParams := or .QueryParams (and (eq .Method "GET") .InputType)}}
+  {{$inputRenames := fieldRenames .InputType $.Namespace}}
+  {{$outputRenames := fieldRenames (successType .) $.Namespace}}
+  {{if or .URLParams (and $hasParams (not $useAxios))}}let{{else}}const{{end}} url = {{requestPath .Path}};
   {{range .URLParams}}
-  url = url.replace(':{{.}}', encodeURIComponent({{.}}))
+  url = url.replace(':{{.}}', encodeURIComponent({{.}}));
   {{end}}
+  {{if $useAxios}}
+  {{if $hasParams}}
+  // Sent with axios's params option
+  const params: Record<string, unknown> = {};
   {{if and (eq .Method "GET") .InputType}}
-  url += '?' + new URLSearchParams(input as any)
+  Object.assign(params, {{wireFields "input" $inputRenames}});
+  {{end}}
+  {{range .QueryParams}}
+  {{if .Struct}}
+  Object.entries({{wireFields .Name (fieldRenames .Type $.Namespace)}}).forEach(([key, value]) => {
+    if (value !== undefined && value !== null) {
+      params[key] = {{if eq $.ArrayQueryFormat "comma"}}Array.isArray(value) ? value.join(',') : {{end}}value;
+    }
+  });
+  {{else}}
+  if ({{.Name}} !== undefined) {
+    params['{{.Key}}'] = {{if eq $.ArrayQueryFormat "comma"}}Array.isArray({{.Name}}) ? {{.Name}}.join(',') : {{end}}{{.Name}};
+  }
+  {{end}}
+  {{end}}
+  {{end}}
+  {{else}}
+  {{if and (eq .Method "GET") .InputType}}
+  url += '?' + new URLSearchParams({{wireFields "input" $inputRenames}} as any);
+  {{end}}
+  {{if .QueryParams}}
+  const searchParams = new URLSearchParams();
+  {{range .QueryParams}}
+  {{if .Struct}}
+  Object.entries({{wireFields .Name (fieldRenames .Type $.Namespace)}}).forEach(([key, value]) => {
+    if (value !== undefined && value !== null) {
+      queryParamEntries(key, value).forEach((entry) => searchParams.append(...entry));
+    }
+  });
+  {{else}}
+  if ({{.Name}} !== undefined) {
+    queryParamEntries('{{.Key}}', {{.Name}}).forEach((entry) => searchParams.append(...entry));
+  }
+  {{end}}
+  {{end}}
+  if (searchParams.toString()) {
+    url += (url.includes('?') ? '&' : '?') + searchParams.toString();
+  }
+  {{end}}
   {{end}}
 
   const headers: Record<string, string> = {};
-  {{range .Headers}}
+  {{$headers := .Headers}}
+  {{range $i, $header := .Headers}}
   {{if eq .Source "input"}}
   if ({{.SafeName}}) {
     headers['{{.HeaderKey}}'] = {{.SafeName}};
   }
+  {{else if eq .Source "const"}}
+  headers['{{.HeaderKey}}'] = '{{js .Value}}';
+  {{else if eq .Source "fn"}}
+  const {{.SafeName}}Value = await {{if $apiConfig}}apiConfig{{else}}headerConfig{{end}}.{{.Func}}?.();
+  if (!{{.SafeName}}Value) {
+    throw new Error('Missing required header: {{.HeaderKey}}');
+  }
+  headers['{{.HeaderKey}}'] = {{.SafeName}}Value;
+  {{else if storageReadOf $headers $i}}
+  headers['{{.HeaderKey}}'] = {{storageReadOf $headers $i}}Value;
   {{else}}
-  const {{.SafeName}}Value = {{.Source}}.getItem('{{.StorageKey}}');
+  const {{.SafeName}}Value = {{.Source}}.getItem({{storageKey .StorageKey}});
   if (!{{.SafeName}}Value || {{.SafeName}}Value === "") {
 	throw new Error('Missing required header: {{.HeaderKey}}');
   }
@@ -129,8 +576,26 @@ export const {{.Name}}Query = async ({{if .URLParams}}{{range $index, $param :=
   
   {{end}}
   {{end}}
+  {{if $useBuilder}}
+  Object.assign(headers, options?.headers);
+  {{end}}
 
-  return createQuery<{{if .InputType}}{{.InputType}}{{else}}void{{end}}, {{.OutputType}}>('{{.Method}}', url, {{if .InputType}}input{{else}}undefined{{end}}, headers);
+  {{$check := ""}}{{if $validateResponses}}{{$check = guardOutput (successType .) $.Types}}{{end}}
+  {{$errorOutputs := errorOutputs .}}
+  {{if $errorOutputs}}try {
+    {{end}}{{if $check}}const data = {{else}}return {{end}}{{if $outputRenames}}renameFields({{end}}{{if .Envelope}}(await {{else if or $outputRenames $check $errorOutputs}}await {{end}}{{if $dedupeRequests}}dedupeQuery{{else}}createQuery{{end}}<{{if .InputType}}{{.InputType}}{{else}}void{{end}}, {{envelopeType .}}>('{{.Method}}', url, {{if .InputType}}{{wireFields "input" $inputRenames}}{{else}}undefined{{end}}, headers, onResponse{{if and $useAxios (or $hasParams $useBuilder)}}, {{if $hasParams}}params{{else}}undefined{{end}}{{end}}{{if $useBuilder}}, options?.signal{{end}}){{with .Envelope}}).{{.}}{{end}}{{with $outputRenames}}, {{.}}){{end}};{{if $check}}
+  // Catch responses that have drifted from the generated types
+  if (!({{$check}})) {
+    throw new APIError(0, 'Invalid response: expected {{js (successType .)}}', data as unknown as Record<string, unknown>);
+  }
+  return data;{{end}}{{with $errorOutputs}}
+  } catch (error) {
+    // Responses with a status declared by @Output are returned rather than thrown
+    {{range .}}{{$type := .Type}}if (error instanceof APIError && error.status === {{.Status}}) {
+      return {{with fieldRenames .Type $.Namespace}}renameFields(error.body as unknown as {{$type}}, {{.}}){{else}}error.body as unknown as {{$type}}{{end}};
+    }
+    {{end}}throw error;
+  }{{end}}
 };
 {{end}}
 `
@@ -138,66 +603,104 @@ export const {{.Name}}Query = async ({{if .URLParams}}{{range $index, $param :=
 const reactQueryHookTemplate = `{{range .Handlers}}
 // React Query hook
 {{if eq .Method "GET"}}
-export const use{{.Name}} = (
-  {{if .URLParams}}{{range $index, $param := .URLParams}}{{if $index}}, {{end}}{{$param}}: string{{end}}{{if or .InputType (inputHeaders .Headers)}}, {{end}}{{end}}{{if .InputType}}input: {{.InputType}}{{if inputHeaders .Headers}}, {{end}}{{end}}
-  {{range $index, $header := inputHeaders .Headers}}{{if $index}}, {{end}}{{$header.SafeName}}: string{{end}}{{if or .URLParams .InputType (inputHeaders .Headers)}}, {{end}}
-  options?: Omit<UseQueryOptions<{{.OutputType}}, APIError, {{.OutputType}}, [string{{if .URLParams}}{{range .URLParams}}, string{{end}}{{end}}{{if .InputType}}, {{.InputType}}{{end}}{{range inputHeaders .Headers}}, string{{end}}]>, 'queryKey' | 'queryFn'>
+{{deprecated .}}export const use{{.Name}} = (
+  {{with queryArgs .}}{{paramList .}},{{end}}
+  options?: Omit<UseQueryOptions<{{.OutputType}}, APIError, {{.OutputType}}, {{queryKeyType .}}>, 'queryKey' | 'queryFn'>
 ): UseQueryResult<{{.OutputType}}, APIError> =>
-  useQuery<{{.OutputType}}, APIError, {{.OutputType}}, [string{{if .URLParams}}{{range .URLParams}}, string{{end}}{{end}}{{if .InputType}}, {{.InputType}}{{end}}{{range inputHeaders .Headers}}, string{{end}}]>({
-    queryKey: ['{{.Name}}'{{if .URLParams}}{{range .URLParams}}, {{.}}{{end}}{{end}}{{if .InputType}}, input{{end}}{{range inputHeaders .Headers}}, {{.SafeName}}{{end}}],
-    queryFn: () => {{.Name}}Query({{if .URLParams}}{{range $index, $param := .URLParams}}{{if $index}}, {{end}}{{$param}}{{end}}{{if and .URLParams .InputType}}, {{end}}{{end}}{{if .InputType}}input{{if inputHeaders .Headers}}, {{end}}{{end}}{{range $index, $header := inputHeaders .Headers}}{{if $index}}, {{end}}{{$header.SafeName}}{{end}}),
+  useQuery<{{.OutputType}}, APIError, {{.OutputType}}, {{queryKeyType .}}>({
+    queryKey: {{queryKey .}},
+    queryFn: () => {{.Name}}Query({{argList (queryArgs .)}}),{{with .Poll}}
+    refetchInterval: {{pollInterval .}},{{end}}
     ...options,
   });
+{{$args := queryArgs .}}{{if and .Batch $args}}{{$batch := index $args 0}}{{$rest := slice $args 1}}
+// React Query batch hook, fetching one {{.Name}} query per {{$batch.Name}}
+{{deprecated .}}export const use{{.Batch}} = (
+  {{pluralize $batch.Name}}: Array<{{$batch.Type}}>,{{if $rest}}
+  {{paramList $rest}},{{end}}
+  options?: Omit<UseQueryOptions<{{.OutputType}}, APIError, {{.OutputType}}, {{queryKeyType .}}>, 'queryKey' | 'queryFn'>
+): Array<UseQueryResult<{{.OutputType}}, APIError>> =>
+  useQueries({
+    queries: {{pluralize $batch.Name}}.map(({{$batch.Name}}): UseQueryOptions<{{.OutputType}}, APIError, {{.OutputType}}, {{queryKeyType .}}> => ({
+      queryKey: {{queryKey .}},
+      queryFn: () => {{.Name}}Query({{argList $args}}),
+      ...options,
+    })),
+  });
+{{end}}
 {{else}}
-export const use{{.Name}} = (
-  {{range $index, $param := .URLParams}}{{if $index}}, {{end}}{{$param}}: string{{end}}
-  {{if inputHeaders .Headers}}{{if .URLParams}}, {{end}}{{range $index, $header := inputHeaders .Headers}}{{if $index}}, {{end}}{{$header.SafeName}}: string{{end}}{{end}}
-  {{if or .URLParams (inputHeaders .Headers)}}, {{end}}
+{{deprecated .}}export const use{{.Name}} = (
+  {{with mutationArgs .}}{{paramList .}},{{end}}
   options?: Omit<UseMutationOptions<{{.OutputType}}, APIError, {{if .InputType}}{{.InputType}}{{else}}void{{end}}, unknown>, 'mutationFn'>
 ): UseMutationResult<{{.OutputType}}, APIError, {{if .InputType}}{{.InputType}}{{else}}void{{end}}, unknown> =>
   useMutation<{{.OutputType}}, APIError, {{if .InputType}}{{.InputType}}{{else}}void{{end}}, unknown>({
-    mutationFn: ({{if .InputType}}input{{end}}) => {{.Name}}Query(
-      {{range $index, $param := .URLParams}}{{if $index}}, {{end}}{{$param}}{{end}}
-      {{if and .URLParams .InputType}}, {{end}}
-      {{if .InputType}}input{{end}}
-      {{if inputHeaders .Headers}}{{if or .URLParams .InputType}}, {{end}}{{range $index, $header := inputHeaders .Headers}}{{if $index}}, {{end}}{{$header.SafeName}}{{end}}{{end}}
-    ),
+    mutationFn: ({{if .InputType}}input{{end}}) => {{.Name}}Query({{argList (queryArgs .)}}),
     ...options,
   });
 {{end}}
 {{end}}
+// Keys of the React Query hooks, e.g. queryClient.invalidateQueries({ queryKey: QueryKeys.GetUser(id) })
+export const QueryKeys = {
+  {{range .Handlers}}{{if eq .Method "GET"}}{{.Name}}: ({{paramList (queryArgs .)}}): {{queryKeyType .}} => {{queryKey .}},
+  {{end}}{{end}}
+} as const;
+`
+
+const swrHookTemplate = `{{range .Handlers}}
+// SWR hook
+{{if eq .Method "GET"}}
+{{deprecated .}}export const use{{.Name}} = (
+  {{with queryArgs .}}{{paramList .}},{{end}}
+  config?: SWRConfiguration<{{.OutputType}}, APIError>
+): SWRResponse<{{.OutputType}}, APIError> =>
+  useSWR<{{.OutputType}}, APIError>(
+    ['{{.Path}}'{{range queryArgs .}}, {{.Name}}{{end}}],
+    () => {{.Name}}Query({{argList (queryArgs .)}}),
+    config
+  );
+{{else}}
+{{deprecated .}}export const use{{.Name}} = (
+  {{with mutationArgs .}}{{paramList .}},{{end}}
+  config?: SWRMutationConfiguration<{{.OutputType}}, APIError, [string{{range .URLParams}}, string{{end}}], {{if .InputType}}{{.InputType}}{{else}}never{{end}}>
+): SWRMutationResponse<{{.OutputType}}, APIError, [string{{range .URLParams}}, string{{end}}], {{if .InputType}}{{.InputType}}{{else}}never{{end}}> =>
+  useSWRMutation<{{.OutputType}}, APIError, [string{{range .URLParams}}, string{{end}}], {{if .InputType}}{{.InputType}}{{else}}never{{end}}>(
+    ['{{.Path}}'{{range .URLParams}}, {{.}}{{end}}],
+    (_key{{if .InputType}}, { arg }: { arg: {{.InputType}} }{{end}}) => {{.Name}}Query({{range $index, $arg := queryArgs .}}{{if $index}}, {{end}}{{if eq $arg.Name "input"}}arg{{else}}{{$arg.Name}}{{end}}{{end}}),
+    config
+  );
+{{end}}
+{{end}}
 `
 
 const reactHookTemplate = `{{range .Handlers}}
 // Custom React hook
-export const use{{.Name}} = (
-  {{if eq .Method "GET"}}{{if .InputType}}input: {{.InputType}},{{end}}{{end}}
-  {{if .URLParams}}{{range $index, $param := .URLParams}}{{if $index}}, {{end}}{{$param}}: string{{end}}{{if or .InputType (inputHeaders .Headers)}}, {{end}}{{end}}
-  {{range $index, $header := inputHeaders .Headers}}{{if $index}}, {{end}}{{$header.SafeName}}: string{{end}}
+{{deprecated .}}export const use{{.Name}} = (
+  {{paramList (hookArgs .)}}
 ) => {
   const [data, setData] = useState<{{.OutputType}} | null>(null);
   const [error, setError] = useState<APIError | null>(null);
   const [isLoading, setIsLoading] = useState(false);
+  const [status, setStatus] = useState<number | null>(null);
 
   const {{if eq .Method "GET"}}query = useCallback(async () => {{ "{" }}{{else}}mutate = useCallback(async ({{if .InputType}}input: {{.InputType}},{{end}}) => {{ "{" }}{{end}}
     setIsLoading(true);
     try {
       const result = await {{.Name}}Query(
-        {{if .URLParams}}{{range $index, $param := .URLParams}}{{if $index}}, {{end}}{{$param}}{{end}}{{if or .InputType (inputHeaders .Headers)}}, {{end}}{{end}}
-        {{if .InputType}}input{{end}}{{if inputHeaders .Headers}}, {{end}}
-        {{range $index, $header := inputHeaders .Headers}}{{if $index}}, {{end}}{{$header.SafeName}}{{end}}
+        {{$args := queryArgs .}}{{if $args}}{{argList $args}}, {{end}}(response) => setStatus(response.status)
       );
       setData(result);
       setError(null);
       return result;
     } catch (e) {
       setError(e as APIError);
+      // Network errors have no HTTP status
+      setStatus(e instanceof APIError && e.status ? e.status : null);
       setData(null);
       throw e;
     } finally {
       setIsLoading(false);
     }
-  }, [{{if .URLParams}}{{range $index, $param := .URLParams}}{{if $index}}, {{end}}{{$param}}{{end}}{{if or .InputType (inputHeaders .Headers)}}, {{end}}{{end}}{{if and .InputType (eq .Method "GET")}}input{{if inputHeaders .Headers}}, {{end}}{{end}}{{range $index, $header := inputHeaders .Headers}}{{if $index}}, {{end}}{{$header.SafeName}}{{end}}]);
+  }, [{{argList (hookArgs .)}}]);
 
   {{if eq .Method "GET"}}
   useEffect(() => {
@@ -205,11 +708,135 @@ export const use{{.Name}} = (
   }, [query]);
   {{end}}
 
-  return { data, error, isLoading, {{if eq .Method "GET"}}query{{else}}mutate{{end}} };
+  return { data, error, isLoading, status, {{if eq .Method "GET"}}query{{else}}mutate{{end}} };
 };
 {{end}}
 `
 
+// angularServiceTemplate renders an injectable Angular service with a method per handler, used
+// instead of the fetch query functions
+const angularServiceTemplate = `{{$authToken := .AuthToken}}
+{{$authTokenStorage := .AuthTokenStorage}}
+{{if .StorageKeys}}
+// Storage keys read by the generated client
+{{range .StorageKeys}}export const {{.Name}} = '{{js .Key}}';
+{{end}}{{end}}{{if .BaseURLEnv}}
+// Prefix of every request path, read from the environment at runtime
+const baseURL = (process.env.{{.BaseURLEnv}} ?? '').replace(/\/+$/, '');
+{{end}}{{if .HeaderFunctions}}
+// Functions computing the values of @Header fn: headers, called before each request that sends
+// them. Set them once at app startup, e.g. headerConfig.{{index .HeaderFunctions 0}} = () => ...;
+export interface HeaderConfig {
+{{range .HeaderFunctions}}  {{.}}?: () => string;
+{{end}}}
+
+export const headerConfig: HeaderConfig = {};
+{{end}}{{if .QueryParams}}
+// Returns the query string entries of a parameter. {{if eq .ArrayQueryFormat "bracket"}}Arrays are sent as a key with
+// brackets per item, e.g. ids[]=1&ids[]=2.{{else if eq .ArrayQueryFormat "comma"}}Arrays are sent as one comma-separated
+// value, e.g. ids=1,2.{{else}}Arrays are sent as a key per item, e.g. ids=1&ids=2.{{end}}
+function queryParamEntries(key: string, value: unknown): Array<[string, string]> {
+  if (!Array.isArray(value)) {
+    return [[key, String(value)]];
+  }
+  {{if eq .ArrayQueryFormat "bracket"}}return value.map((item): [string, string] => [key + '[]', String(item)]);{{else if eq .ArrayQueryFormat "comma"}}return [[key, value.map(String).join(',')]];{{else}}return value.map((item): [string, string] => [key, String(item)]);{{end}}
+}
+{{end}}
+// Requests are sent with HttpClient, so interceptors provided to the app apply to them
+@Injectable({ providedIn: 'root' })
+export class APIService {
+  constructor(private http: HttpClient) {}
+{{range .Handlers}}
+  {{handlerDoc .}}{{methodName .Name}}({{paramList (queryArgs .)}}): Observable<{{.OutputType}}> {
+    {{$hasParams := or .QueryParams (and (eq .Method "GET") .InputType)}}
+    {{if .URLParams}}let{{else}}const{{end}} url = {{requestPath .Path}};
+    {{range .URLParams}}
+    url = url.replace(':{{.}}', encodeURIComponent({{.}}));
+    {{end}}
+    {{if $hasParams}}
+    let params = new HttpParams();
+    {{if and (eq .Method "GET") .InputType}}
+    Object.entries(input).forEach(([key, value]) => {
+      if (value !== undefined && value !== null) {
+        params = params.append(key, String(value));
+      }
+    });
+    {{end}}
+    {{range .QueryParams}}
+    {{if .Struct}}
+    Object.entries({{.Name}}).forEach(([key, value]) => {
+      if (value !== undefined && value !== null) {
+        queryParamEntries(key, value).forEach((entry) => {
+          params = params.append(...entry);
+        });
+      }
+    });
+    {{else}}
+    if ({{.Name}} !== undefined) {
+      queryParamEntries('{{.Key}}', {{.Name}}).forEach((entry) => {
+        params = params.append(...entry);
+      });
+    }
+    {{end}}
+    {{end}}
+    {{end}}
+
+    const headers: Record<string, string> = {};
+    const token = {{$authTokenStorage}}.getItem({{storageKey $authToken}});
+    if (token) {
+      headers['Authorization'] = ` + "`Bearer ${token}`" + `;
+    }
+    {{$headers := .Headers}}
+    {{range $i, $header := .Headers}}
+    {{if eq .Source "input"}}
+    if ({{.SafeName}}) {
+      headers['{{.HeaderKey}}'] = {{.SafeName}};
+    }
+    {{else if eq .Source "const"}}
+    headers['{{.HeaderKey}}'] = '{{js .Value}}';
+    {{else if eq .Source "fn"}}
+    const {{.SafeName}}Value = headerConfig.{{.Func}}?.();
+    if (!{{.SafeName}}Value) {
+      throw new Error('Missing required header: {{.HeaderKey}}');
+    }
+    headers['{{.HeaderKey}}'] = {{.SafeName}}Value;
+    {{else if storageReadOf $headers $i}}
+    headers['{{.HeaderKey}}'] = {{storageReadOf $headers $i}}Value;
+    {{else}}
+    const {{.SafeName}}Value = {{.Source}}.getItem({{storageKey .StorageKey}});
+    if (!{{.SafeName}}Value) {
+      throw new Error('Missing required header: {{.HeaderKey}}');
+    }
+    headers['{{.HeaderKey}}'] = {{.SafeName}}Value;
+    {{end}}
+    {{end}}
+
+    return this.http.request<{{envelopeType .}}>('{{.Method}}', url, {
+      {{if and .InputType (ne .Method "GET")}}body: input,
+      {{end}}headers,{{if $hasParams}}
+      params,{{end}}
+    }){{with .Envelope}}.pipe(map((response) => response.{{.}})){{end}}{{with errorOutputs .}}.pipe(
+      // Responses with a status declared by @Output are returned rather than thrown
+      catchError((error: unknown) => {
+        {{range .}}if (error instanceof HttpErrorResponse && error.status === {{.Status}}) {
+          return of(error.error as {{.Type}});
+        }
+        {{end}}return throwError(() => error);
+      })
+    ){{end}};
+  }
+{{end}}}
+`
+
+const requestBuilderTemplate = `
+// Request builders, e.g. api.getUser(id).withSignal(signal).execute()
+export const api = {
+  {{range .Handlers}}{{if .IsDeprecated}}{{deprecated .}}  {{end}}{{methodName .Name}}: ({{paramList (queryArgs .)}}): RequestBuilder<{{.OutputType}}> =>
+    new RequestBuilder<{{.OutputType}}>((options, onResponse) => {{.Name}}Query({{with queryArgs .}}{{argList .}}, {{end}}onResponse, options)),
+  {{end}}
+} as const;
+`
+
 const queryDictionaryTemplate = `
 // Query dictionary
 export const queries = {
@@ -217,3 +844,61 @@ export const queries = {
   {{end}}
 } as const;
 `
+
+// pathBuilderTemplate exports the request path of each handler, built the way its query function
+// builds it
+const pathBuilderTemplate = `
+// Request paths, e.g. Paths.GetUser({ id: 1 }) for a link or a prefetch. Path and query params are
+// substituted like the query functions do, so the paths match their requests.
+export const Paths = {
+  {{range .Handlers}}{{if .IsDeprecated}}{{deprecated .}}  {{end}}{{.Name}}: ({{pathParams .}}): string => {
+    {{if or .URLParams .QueryParams}}let{{else}}const{{end}} url = {{requestPath .Path}};
+    {{range .URLParams}}
+    url = url.replace(':{{.}}', encodeURIComponent({{.}}));
+    {{end}}
+    {{if .QueryParams}}
+    const searchParams = new URLSearchParams();
+    {{range .QueryParams}}
+    {{if .Struct}}
+    Object.entries({{wireFields .Name (fieldRenames .Type $.Namespace)}}).forEach(([key, value]) => {
+      if (value !== undefined && value !== null) {
+        queryParamEntries(key, value).forEach((entry) => searchParams.append(...entry));
+      }
+    });
+    {{else}}
+    if ({{.Name}} !== undefined) {
+      queryParamEntries('{{.Key}}', {{.Name}}).forEach((entry) => searchParams.append(...entry));
+    }
+    {{end}}
+    {{end}}
+    if (searchParams.toString()) {
+      url += (url.includes('?') ? '&' : '?') + searchParams.toString();
+    }
+    {{end}}
+    return url;
+  },
+  {{end}}
+} as const;
+`
+
+// metaTemplate summarizes the handlers and types of the file, for dev tools that enumerate them at
+// runtime
+const metaTemplate = `
+// Summary of this module's endpoints and types
+export const __meta = {
+  version: '{{js .Version}}',
+  generatedAt: '{{.Timestamp}}',
+  handlers: [{{range .Handlers}}
+    { name: '{{.Name}}', method: '{{.Method}}', path: '{{js .Path}}'{{with .Description}}, description: '{{js .}}'{{end}} },{{end}}
+  ],
+  types: [{{range $i, $t := .Types}}{{if $i}}, {{end}}'{{firstWord $t.Name}}'{{end}}],
+} as const;
+`
+
+// defaultExportTemplate exports the client by default, or the type of a file with a single type
+// and no handlers
+const defaultExportTemplate = `{{if .Handlers}}
+{{if .UseAngular}}export default APIService;{{else}}export default queries;{{end}}
+{{else if eq (len .Types) 1}}
+export type { {{firstWord (index .Types 0).Name}} as default };
+{{end}}`
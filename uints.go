@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// uintTypeNames are the Go unsigned integer types given a type of their own by uint_type
+var uintTypeNames = map[string]bool{"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true}
+
+// nonNegativeIntType is the branded type unsigned integers are emitted as with uint_type: branded
+const nonNegativeIntType = "NonNegativeInt"
+
+// validUintType returns how unsigned integers are emitted: "branded", "comment", or "" for number
+func validUintType(uintType string, w io.Writer) string {
+	if uintType == "branded" || uintType == "comment" {
+		return uintType
+	} else if uintType != "number" && uintType != "" {
+		fmt.Fprintf(w, "Warning: Unknown uint_type %s. Using number instead.\n", uintType)
+	}
+	return ""
+}
+
+// uintFields replaces number in the type of every unsigned integer field, in either parse path,
+// with NonNegativeInt, a number branded so a plain number needs a cast to be assigned to it, or
+// with number /* uint */. Unsigned types with a type mapping are left alone. NonNegativeInt is
+// returned ahead of the other types when a field uses it.
+func uintFields(types []TypeInfo, uintType string, typeMappings map[string]string) []TypeInfo {
+	if uintType == "" {
+		return types
+	}
+	tsType := "number /* uint */"
+	if uintType == "branded" {
+		tsType = nonNegativeIntType
+	}
+
+	used, declared := false, false
+	for i := range types {
+		if strings.Split(types[i].Name, " ")[0] == nonNegativeIntType {
+			declared = true
+		}
+		for j, field := range types[i].Fields {
+			if _, mapped := typeMappings[field.PackageName]; mapped || !uintTypeNames[field.PackageName] {
+				continue
+			}
+			// The unsigned integer is the last number, after any map key
+			at := strings.LastIndex(field.Type, "number")
+			if at < 0 {
+				continue
+			}
+			types[i].Fields[j].Type = field.Type[:at] + tsType + field.Type[at+len("number"):]
+			used = true
+		}
+	}
+
+	if uintType != "branded" || !used || declared {
+		return types
+	}
+	nonNegativeInt := TypeInfo{Name: nonNegativeIntType, Derived: fmt.Sprintf("number & { readonly __brand: '%s' }", nonNegativeIntType)}
+	return append([]TypeInfo{nonNegativeInt}, types...)
+}
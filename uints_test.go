@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUintType(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"models/stats.go": `package models
+
+type Stats struct {
+	Views  uint64 ` + "`json:\"views\"`" + `
+	Rating int    ` + "`json:\"rating\"`" + `
+}
+`,
+		"api/api.go": `package api
+
+import "github.com/example/testmodule/models"
+
+type Count uint32
+
+type Post struct {
+	ID       uint              ` + "`json:\"id\"`" + `
+	Likes    *Count            ` + "`json:\"likes\"`" + `
+	Tags     []uint16          ` + "`json:\"tags\"`" + `
+	Votes    map[string]uint8  ` + "`json:\"votes\"`" + `
+	Offset   int               ` + "`json:\"offset\"`" + `
+	Stats    models.Stats      ` + "`json:\"stats\"`" + `
+}
+
+// @Method GET
+// @Path /posts/:id
+// @Output Post
+func GetPostHandler() {}
+`,
+	})
+	pkg := PackageConfig{Path: filepath.Join(dir, "api")}
+
+	fieldTypes := func(types []TypeInfo) map[string]string {
+		fields := make(map[string]string)
+		for _, ty := range types {
+			for _, field := range ty.Fields {
+				fields[ty.Name+"."+field.Name] = field.Type
+			}
+		}
+		return fields
+	}
+
+	types, _, err := parseGeneratedPackage(&Config{UintType: "branded"}, pkg, io.Discard)
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+	fields := fieldTypes(types)
+	for name, expected := range map[string]string{
+		"Post.id":            "NonNegativeInt",
+		"Post.likes":         "NonNegativeInt | null",
+		"Post.tags":          "Array<NonNegativeInt>",
+		"Post.votes":         "{ [key: string]: NonNegativeInt }",
+		"Post.offset":        "number",
+		"ModelsStats.views":  "NonNegativeInt",
+		"ModelsStats.rating": "number",
+	} {
+		if fields[name] != expected {
+			t.Errorf("Expected %s to be %s, got %q", name, expected, fields[name])
+		}
+	}
+	if types[0].Name != nonNegativeIntType {
+		t.Errorf("Expected NonNegativeInt to be declared first, got %s", types[0].Name)
+	}
+
+	content := renderTestFile(t, GenerateFileOptions{Types: types})
+	if !strings.Contains(content, "export type NonNegativeInt = number & { readonly __brand: 'NonNegativeInt' };") {
+		t.Errorf("Expected the NonNegativeInt declaration, got:\n%s", content)
+	}
+
+	types, _, err = parseGeneratedPackage(&Config{UintType: "comment"}, pkg, io.Discard)
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+	fields = fieldTypes(types)
+	if fields["Post.tags"] != "Array<number /* uint */>" || fields["ModelsStats.views"] != "number /* uint */" || fields["Post.offset"] != "number" {
+		t.Errorf("Expected unsigned fields to be number /* uint */, got %v", fields)
+	}
+	for _, ty := range types {
+		if ty.Name == nonNegativeIntType {
+			t.Errorf("Expected no NonNegativeInt declaration with uint_type: comment")
+		}
+	}
+}
+
+func TestUintTypeMapped(t *testing.T) {
+	types := uintFields([]TypeInfo{{Name: "Account", Fields: []FieldInfo{
+		{Name: "balance", Type: "string", PackageName: "uint64"},
+		{Name: "age", Type: "number", PackageName: "uint8"},
+	}}}, "branded", map[string]string{"uint64": "string"})
+	if len(types) != 2 || types[1].Fields[0].Type != "string" || types[1].Fields[1].Type != "NonNegativeInt" {
+		t.Errorf("Expected only the unmapped field to be branded, got %+v", types)
+	}
+
+	if got := validUintType("unsigned", io.Discard); got != "" {
+		t.Errorf("Expected an unknown uint_type to fall back to number, got %s", got)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ValidateOptions contains the command line options for the validate command
+type ValidateOptions struct {
+	// ConfigFile is the path of the configuration file, go2type.yaml when empty
+	ConfigFile string
+}
+
+func parseValidateFlags(args []string) (ValidateOptions, error) {
+	var opts ValidateOptions
+
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.StringVar(&opts.ConfigFile, "config", defaultConfigFile, "path of the configuration file")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// tsGlobalTypes are the TypeScript types a handler's @Input or @Output may reference without
+// declaring them
+var tsGlobalTypes = map[string]bool{
+	"string": true, "number": true, "boolean": true, "bigint": true, "object": true, "any": true,
+	"unknown": true, "never": true, "void": true, "null": true, "undefined": true, "true": true,
+	"false": true, "Array": true, "ReadonlyArray": true, "Record": true, "Partial": true,
+	"Required": true, "Readonly": true, "Pick": true, "Omit": true, "Date": true, "Blob": true,
+	"File": true, "FormData": true,
+}
+
+// headerNameRegex matches a valid HTTP header name
+var headerNameRegex = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+
+// validate checks the configuration and the annotations of every configured package without
+// generating anything, printing every problem found to w with its position. Package paths must name
+// Go packages that parse, the types of @Input and @Output must be declared by one of the packages
+// and @Header directives must be well formed. It returns an error if there are any problems.
+func validate(opts ValidateOptions, w io.Writer) error {
+	configFile := configFileOrDefault(opts.ConfigFile)
+	var warnings bytes.Buffer
+	config, err := loadConfig(configFile, &warnings)
+	if err != nil {
+		return fmt.Errorf("error loading config: %v", err)
+	}
+
+	var problems []string
+	for _, warning := range strings.Split(strings.TrimSpace(warnings.String()), "\n") {
+		if warning != "" {
+			problems = append(problems, configFile+": "+strings.TrimPrefix(warning, "Warning: "))
+		}
+	}
+	if len(config.Packages) == 0 {
+		problems = append(problems, configFile+": no packages are configured")
+	}
+
+	// The types of every package are known to the handlers of the others, as packages writing to
+	// the same output share their types
+	fset := token.NewFileSet()
+	var files []*ast.File
+	known := make(map[string]bool)
+	for _, pkg := range config.Packages {
+		dirs, err := packageDirs(pkg)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: package %s: %v", configFile, pkg.Path, err))
+			continue
+		}
+		valid := true
+		for _, dir := range dirs {
+			pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+			if list, ok := err.(scanner.ErrorList); ok {
+				for _, e := range list {
+					problems = append(problems, fmt.Sprintf("%s: %s", displayPosition(e.Pos), e.Msg))
+				}
+				valid = false
+			} else if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: package %s: %v", configFile, pkg.Path, err))
+				valid = false
+			} else if len(pkgs) == 0 && dir == dirs[0] {
+				problems = append(problems, fmt.Sprintf("%s: package %s: no Go files in %s", configFile, pkg.Path, dir))
+				valid = false
+			}
+			for _, p := range pkgs {
+				for _, file := range p.Files {
+					files = append(files, file)
+				}
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		types, _, err := parseGeneratedPackage(config, pkg, io.Discard)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: package %s: %v", configFile, pkg.Path, err))
+			continue
+		}
+		for name := range typeNameSet(types) {
+			known[name] = true
+		}
+	}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			for _, comment := range fn.Doc.List {
+				for _, problem := range directiveProblems(comment.Text, known) {
+					problems = append(problems, fmt.Sprintf("%s: %s: %s", displayPosition(fset.Position(comment.Pos())), funcDeclName(fn), problem))
+				}
+			}
+		}
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintln(w, problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problem(s)", len(problems))
+	}
+	fmt.Fprintf(w, "%s and the annotations of %d package(s) are valid\n", configFile, len(config.Packages))
+	return nil
+}
+
+// directiveProblems returns the problems with a handler comment: an @Input or @Output type that
+// isn't known, or a malformed @Header
+func directiveProblems(text string, known map[string]bool) []string {
+	switch {
+	case strings.Contains(text, "@Description"), strings.Contains(text, "@Deprecated"):
+		// Descriptions and reasons may mention other directives
+		return nil
+	case strings.Contains(text, "@Input"):
+		return unknownTypeProblems("@Input", directiveValue(text, "@Input"), known)
+	case strings.Contains(text, "@Output"):
+		return unknownTypeProblems("@Output", parseOutputDirective(directiveValue(text, "@Output")).Type, known)
+	case strings.Contains(text, "@Header"):
+		if problem := headerDirectiveProblem(directiveValue(text, "@Header")); problem != "" {
+			return []string{problem}
+		}
+	}
+	return nil
+}
+
+// unknownTypeProblems returns a problem for each type referenced by the value of directive that's
+// neither known nor a TypeScript global type
+func unknownTypeProblems(directive, tsType string, known map[string]bool) []string {
+	if tsType == "" {
+		return []string{directive + " has no type"}
+	}
+	var problems []string
+	for _, ref := range typeReferenceRegex.FindAllString(tsType, -1) {
+		if !tsIdentifierRegex.MatchString(ref) || known[ref] || tsGlobalTypes[ref] {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s type %s is not declared by any configured package", directive, ref))
+	}
+	return problems
+}
+
+// headerDirectiveProblem returns what's wrong with the value of an @Header directive, or "" if it's
+// well formed
+func headerDirectiveProblem(directive string) string {
+	source, rest, ok := strings.Cut(directive, ":")
+	if !ok {
+		return fmt.Sprintf("@Header %q should be source:Header-Name[:key]", directive)
+	}
+	var headerName, key string
+	switch source {
+	case "const", "fn":
+		headerName, key, ok = strings.Cut(rest, ":")
+		if source == "const" && !ok {
+			return fmt.Sprintf("const @Header %s has no value", headerName)
+		}
+		if source == "fn" && ok && !tsIdentifierRegex.MatchString(key) {
+			return fmt.Sprintf("@Header function name %s isn't a valid identifier", key)
+		}
+	case "input", "localStorage", "sessionStorage":
+		headerName, key, ok = strings.Cut(rest, ":")
+		if ok && (key == "" || strings.Contains(key, ":")) {
+			return fmt.Sprintf("@Header %q should be source:Header-Name[:key]", directive)
+		}
+	default:
+		return fmt.Sprintf("unknown @Header source %s, expected input, localStorage, sessionStorage, const or fn", source)
+	}
+	if !headerNameRegex.MatchString(headerName) {
+		return fmt.Sprintf("invalid @Header name %q", headerName)
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go2type.yaml": `on_unresolved: bogus
+packages:
+  - path: api
+    output_path: out/api.generated.ts
+  - path: models
+    output_path: out/api.generated.ts
+  - path: missing
+    output_path: out/missing.generated.ts
+  - path: broken
+    output_path: out/broken.generated.ts
+`,
+		"api/users.go": `package api
+
+// @Method GET
+// @Path /users/:id
+// @Header localStorage:X-Account-ID:account_id
+// @Header fn:X-Signature:getSignature
+// @Output User
+func GetUserHandler() {}
+
+// @Method POST
+// @Path /users
+// @Header cookie:X-Session
+// @Header const:X-API-Version
+// @Header input:X Bad
+// @Input Array<CreateUser>
+// @Output Record<string, Usr>
+func CreateUserHandler() {}
+`,
+		"models/user.go":   "package models\n\n// @Method GET\n// @Path /me\n// @Output User\nfunc GetMeHandler() {}\n\ntype User struct {\n\tID int `json:\"id\"`\n}\n",
+		"broken/broken.go": "package broken\n\ntype Item struct {\n",
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	opts, err := parseValidateFlags(nil)
+	if err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	var out bytes.Buffer
+	err = validate(opts, &out)
+	if err == nil || err.Error() != "found 8 problem(s)" {
+		t.Errorf("Expected every problem to be counted, got %v", err)
+	}
+	for _, expected := range []string{
+		"go2type.yaml: Unknown on_unresolved bogus. Using any instead.\n",
+		"go2type.yaml: package missing: ",
+		"broken/broken.go:3:20: expected '}', found 'EOF'\n",
+		"api/users.go:12:1: CreateUserHandler: unknown @Header source cookie, expected input, localStorage, sessionStorage, const or fn\n",
+		"api/users.go:13:1: CreateUserHandler: const @Header X-API-Version has no value\n",
+		"api/users.go:14:1: CreateUserHandler: invalid @Header name \"X Bad\"\n",
+		"api/users.go:15:1: CreateUserHandler: @Input type CreateUser is not declared by any configured package\n",
+		"api/users.go:16:1: CreateUserHandler: @Output type Usr is not declared by any configured package\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected the output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+	// User is declared by another package writing to the same output
+	if strings.Contains(out.String(), "GetUserHandler") {
+		t.Errorf("Expected no problems with GetUserHandler, got:\n%s", out.String())
+	}
+
+	config := "packages:\n  - path: models\n    output_path: out/models.generated.ts\n"
+	if err := os.WriteFile("go2type.models.yaml", []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if opts, err = parseValidateFlags([]string{"--config", "go2type.models.yaml"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	out.Reset()
+	if err := validate(opts, &out); err != nil {
+		t.Errorf("Expected the models package to be valid, got %v:\n%s", err, out.String())
+	}
+	if _, err := os.Stat("out"); !os.IsNotExist(err) {
+		t.Errorf("Expected validate not to write any files, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchOptions contains the command line options for the watch command
+type WatchOptions struct {
+	GenerateOptions
+	// Debounce is how long changes must settle before regenerating
+	Debounce time.Duration
+	// Serve is the address the generated files are served on over HTTP, e.g. :8080, if set
+	Serve string
+}
+
+func parseWatchFlags(args []string) (WatchOptions, error) {
+	opts := WatchOptions{GenerateOptions: GenerateOptions{ShouldFormat: true}}
+
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.DurationVar(&opts.Debounce, "debounce", 300*time.Millisecond, "how long changes must settle before regenerating")
+	fs.StringVar(&opts.Serve, "serve", "", "address to serve the generated files on, e.g. :8080")
+	fs.StringVar(&opts.ConfigFile, "config", defaultConfigFile, "path of the configuration file")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// watch generates every configured package, then watches their directories and regenerates the
+// output of the packages whose Go files change until stop is closed. Changes to the config file
+// regenerate everything. Errors are printed rather than returned so a broken file doesn't end
+// the session. With opts.Serve set, the generated files are also served over HTTP.
+func watch(opts WatchOptions, stop <-chan struct{}) error {
+	configFile := opts.configFile()
+	server := &generatedFileServer{configFile: configFile}
+	if opts.Serve != "" {
+		listener, err := net.Listen("tcp", opts.Serve)
+		if err != nil {
+			return fmt.Errorf("error listening on %s: %v", opts.Serve, err)
+		}
+		httpServer := &http.Server{Handler: server}
+		go func() { _ = httpServer.Serve(listener) }()
+		defer httpServer.Close()
+		fmt.Printf("Serving generated files on http://%s\n", listener.Addr())
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating file watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	regenerate := func(genOpts GenerateOptions) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		if err := generate(genOpts); err != nil {
+			fmt.Printf("Error generating files: %v\n", err)
+		}
+	}
+
+	regenerate(opts.GenerateOptions)
+
+	configPath, err := filepath.Abs(configFile)
+	if err != nil {
+		return fmt.Errorf("error resolving %s: %v", configFile, err)
+	}
+	// The config file's directory is watched rather than the file, so editors that replace the
+	// file on save don't end the watch
+	if err := watcher.Add(filepath.Dir(configPath)); err != nil {
+		return fmt.Errorf("error watching %s: %v", configFile, err)
+	}
+	packages := watchPackageDirs(watcher, configFile, nil)
+
+	// The debounce timer only runs once a change has been seen
+	debounce := time.NewTimer(opts.Debounce)
+	debounce.Stop()
+
+	changed := make(map[string]bool)
+	configChanged := false
+	fmt.Println("Watching for changes...")
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case err := <-watcher.Errors:
+			fmt.Printf("Error watching files: %v\n", err)
+			continue
+		case event := <-watcher.Events:
+			// Permission and access time changes don't change the generated output
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if event.Name == configPath {
+				configChanged = true
+			} else if strings.HasSuffix(event.Name, ".go") {
+				for _, pkgPath := range packages[filepath.Dir(event.Name)] {
+					changed[pkgPath] = true
+				}
+			}
+			if len(changed) > 0 || configChanged {
+				debounce.Reset(opts.Debounce)
+			}
+			continue
+		case <-debounce.C:
+		}
+
+		genOpts := opts.GenerateOptions
+		if configChanged {
+			fmt.Println("Configuration changed, regenerating all packages")
+			packages = watchPackageDirs(watcher, configFile, packages)
+		} else {
+			for pkgPath := range changed {
+				genOpts.Packages = append(genOpts.Packages, pkgPath)
+			}
+			sort.Strings(genOpts.Packages)
+			fmt.Printf("Change in package %s, regenerating\n", strings.Join(genOpts.Packages, ", "))
+		}
+		regenerate(genOpts)
+		changed = make(map[string]bool)
+		configChanged = false
+	}
+}
+
+// watchPackageDirs adds the directories of every package configured in configFile, including the
+// sub-packages of recursive packages, to watcher and removes those of watched, the previously
+// watched directories, that are no longer configured. It returns the configured paths of the
+// packages in each watched directory, keyed by its absolute path.
+func watchPackageDirs(watcher *fsnotify.Watcher, configFile string, watched map[string][]string) map[string][]string {
+	packages := make(map[string][]string)
+	config, err := loadConfig(configFile, os.Stdout)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return watched
+	}
+	for _, pkg := range config.Packages {
+		pkgDirs, err := packageDirs(pkg)
+		if err != nil {
+			fmt.Printf("Warning: Not watching package %s: %v\n", pkg.Path, err)
+			continue
+		}
+		for _, dir := range pkgDirs {
+			if dir, err = filepath.Abs(dir); err == nil {
+				packages[dir] = append(packages[dir], pkg.Path)
+			}
+		}
+	}
+
+	configDir, _ := filepath.Abs(filepath.Dir(configFile))
+	for dir := range watched {
+		if _, ok := packages[dir]; !ok && dir != configDir {
+			_ = watcher.Remove(dir)
+		}
+	}
+	for dir, pkgPaths := range packages {
+		if err := watcher.Add(dir); err != nil {
+			fmt.Printf("Warning: Not watching package %s: %v\n", strings.Join(pkgPaths, ", "), err)
+			delete(packages, dir)
+		}
+	}
+	return packages
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"users/users.go": `package users
+
+type User struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /users/:id
+// @Output User
+func GetUserHandler() {}
+`,
+		"orders/orders.go": `package orders
+
+type Order struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// @Method GET
+// @Path /orders/:id
+// @Output Order
+func GetOrderHandler() {}
+`,
+		"go2type.yaml": `auth_token: token
+hooks: "false"
+packages:
+  - path: users
+    output_path: out/users.generated.ts
+  - path: orders
+    output_path: out/orders.generated.ts
+`,
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- watch(WatchOptions{Debounce: 50 * time.Millisecond}, stop)
+	}()
+
+	usersOutput := filepath.Join(dir, "out", "users.generated.ts")
+	ordersOutput := filepath.Join(dir, "out", "orders.generated.ts")
+	waitForFile := func(path, want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if content, err := os.ReadFile(path); err == nil && strings.Contains(string(content), want) {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("Timed out waiting for %s in %s", want, path)
+	}
+	waitForFile(usersOutput, "export const GetUserQuery = async")
+	waitForFile(ordersOutput, "export const GetOrderQuery = async")
+	ordersInfo, err := os.Stat(ordersOutput)
+	if err != nil {
+		t.Fatalf("Failed to stat orders output: %v", err)
+	}
+
+	// A broken file is reported without ending the watch
+	writeTestFiles(t, dir, map[string]string{"users/broken.go": "package users\n\nfunc {"})
+	time.Sleep(200 * time.Millisecond)
+	if err := os.Remove(filepath.Join(dir, "users", "broken.go")); err != nil {
+		t.Fatalf("Failed to remove broken file: %v", err)
+	}
+
+	writeTestFiles(t, dir, map[string]string{"users/list.go": `package users
+
+// @Method GET
+// @Path /users
+// @Output User
+func ListUsersHandler() {}
+`})
+	waitForFile(usersOutput, "export const ListUsersQuery = async")
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("Watch returned an error: %v", err)
+	}
+
+	// Only the changed package's output is regenerated
+	if info, err := os.Stat(ordersOutput); err != nil || !info.ModTime().Equal(ordersInfo.ModTime()) {
+		t.Errorf("Expected the orders output to be left alone")
+	}
+}